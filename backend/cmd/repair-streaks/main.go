@@ -0,0 +1,78 @@
+// Command repair-streaks rebuilds habit streaks from the daily log history,
+// for a single user or for every user in the database.
+//
+// Usage:
+//
+//	repair-streaks -user <uuid>
+//	repair-streaks -all
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata" // user timezones must resolve even without system zoneinfo
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/config"
+	"github.com/habittracker/backend/internal/database"
+	"github.com/habittracker/backend/internal/repository"
+	"github.com/habittracker/backend/internal/services"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	userFlag := flag.String("user", "", "rebuild the streaks of the user with this ID")
+	allFlag := flag.Bool("all", false, "rebuild the streaks of every user")
+	flag.Parse()
+
+	if (*userFlag == "") == !*allFlag {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	cfg := config.Load()
+
+	db, err := database.NewPostgresConnection(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := database.RunMigrations(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	habitRepo := repository.NewHabitRepository(db)
+	streakRepo := repository.NewStreakRepository(db)
+	dayResolver := services.NewDayResolver(userRepo)
+	streakService := services.NewStreakService(userRepo, habitRepo, streakRepo, dayResolver)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var count int
+	if *allFlag {
+		count, err = streakService.RecomputeAll(ctx)
+	} else {
+		userID, parseErr := uuid.Parse(*userFlag)
+		if parseErr != nil {
+			log.Fatalf("Invalid user ID %q: %v", *userFlag, parseErr)
+		}
+		count, err = streakService.RecomputeUser(ctx, userID)
+	}
+	if err != nil {
+		log.Fatalf("Failed to rebuild streaks: %v", err)
+	}
+
+	log.Printf("Rebuilt %d habit streaks", count)
+}
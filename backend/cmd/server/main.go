@@ -8,10 +8,15 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // user timezones must resolve even without system zoneinfo
 
+	"github.com/habittracker/backend/internal/app"
 	"github.com/habittracker/backend/internal/config"
 	"github.com/habittracker/backend/internal/database"
 	"github.com/habittracker/backend/internal/routes"
+	"github.com/habittracker/backend/internal/scheduler"
+	"github.com/habittracker/backend/internal/services"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
 
@@ -23,6 +28,9 @@ func main() {
 
 	// Initialize configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// Initialize database connection
 	db, err := database.NewPostgresConnection(cfg.DatabaseURL)
@@ -42,8 +50,46 @@ func main() {
 		log.Printf("Warning: Redis connection failed: %v. Continuing without cache.", err)
 	}
 
+	// Initialize access token signing keys
+	keyManager, err := services.NewKeyManager(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	defer keyManager.Close()
+
+	// Initialize push notifications (logged only without service account credentials)
+	var fcmClient *services.FCMClient
+	if cfg.FirebaseCredentialsJSON != "" {
+		fcmClient, err = services.NewFCMClient(cfg.FirebaseCredentialsJSON, cfg.FCMEndpoint)
+		if err != nil {
+			log.Fatalf("Failed to load Firebase credentials: %v", err)
+		}
+	}
+
+	// Initialize email (logged only without an SMTP server)
+	var emailSender services.EmailSender
+	if cfg.SMTPHost != "" {
+		emailSender, err = services.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailFrom)
+		if err != nil {
+			log.Fatalf("Invalid email settings: %v", err)
+		}
+	}
+
+	// Build the repositories and services shared by the API and background work
+	application := app.New(db, redisClient, keyManager, fcmClient, emailSender, cfg)
+
+	// Initialize report generation workers (every replica works the shared queue)
+	reportJobService := application.Services.ReportJob
+	reportJobService.Start()
+
 	// Initialize router
-	router := routes.SetupRouter(db, redisClient, cfg)
+	router := routes.SetupRouter(application, cfg)
+
+	// Initialize background jobs (only the replica holding the leader lock runs them)
+	jobScheduler := newScheduler(db, application, cfg)
+	if cfg.SchedulerEnabled {
+		jobScheduler.Start()
+	}
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -76,5 +122,35 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	if err := jobScheduler.Stop(ctx); err != nil {
+		log.Printf("Scheduler forced to stop: %v", err)
+	}
+
+	if err := reportJobService.Stop(ctx); err != nil {
+		log.Printf("Report job workers forced to stop: %v", err)
+	}
+
 	log.Println("Server exited gracefully")
 }
+
+// newScheduler wires the scheduled jobs: daily and per-habit reminders,
+// at-risk streak alerts, nightly streak rebuilds, completion window learning,
+// weekly digests, month-end reports and push and email delivery of the
+// notification outbox
+func newScheduler(db *pgxpool.Pool, a *app.App, cfg *config.Config) *scheduler.Scheduler {
+	repos, svcs := a.Repositories, a.Services
+
+	s := scheduler.New(db, repos.JobRun, cfg.SchedulerInterval)
+	s.Register(scheduler.NewReminderJob(repos.User, repos.Freeze, svcs.Notification, svcs.DayResolver), time.Hour)
+	s.Register(scheduler.NewHabitReminderJob(repos.User, repos.Habit, repos.Freeze, svcs.Notification, svcs.DayResolver), time.Hour)
+	s.Register(scheduler.NewStreakAlertJob(repos.User, repos.Freeze, svcs.Notification, svcs.DayResolver), time.Hour)
+	s.Register(scheduler.NewStreakRecomputeJob(repos.User, svcs.Streak, svcs.DayResolver), 24*time.Hour)
+	s.Register(scheduler.NewCompletionInsightsJob(repos.User, svcs.Insight, svcs.DayResolver), 24*time.Hour)
+	s.Register(scheduler.NewWeeklyDigestJob(repos.User, repos.Habit, svcs.Digest, svcs.Notification, svcs.DayResolver), 24*time.Hour)
+	s.Register(scheduler.NewMonthlyReportJob(repos.User, repos.Habit, svcs.ReportJob, svcs.DayResolver), 24*time.Hour)
+	// Registered last so notifications queued above go out in the same tick
+	s.Register(scheduler.NewNotificationDeliveryJob(svcs.Notification), time.Hour)
+	s.Register(scheduler.NewEmailDeliveryJob(svcs.Email), time.Hour)
+
+	return s
+}
@@ -0,0 +1,123 @@
+// Package app builds the repositories and services the server runs on. They
+// are built once and shared by the HTTP handlers, the background jobs and
+// the report workers.
+package app
+
+import (
+	"github.com/habittracker/backend/internal/config"
+	"github.com/habittracker/backend/internal/repository"
+	"github.com/habittracker/backend/internal/services"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// Repositories holds the database repositories
+type Repositories struct {
+	User                   *repository.UserRepository
+	Habit                  *repository.HabitRepository
+	Log                    *repository.LogRepository
+	Streak                 *repository.StreakRepository
+	Report                 *repository.ReportRepository
+	ReportJob              *repository.ReportJobRepository
+	Revision               *repository.RevisionRepository
+	RefreshToken           *repository.RefreshTokenRepository
+	Session                *repository.SessionRepository
+	Freeze                 *repository.FreezeRepository
+	Device                 *repository.DeviceRepository
+	Notification           *repository.NotificationRepository
+	NotificationPreference *repository.NotificationPreferenceRepository
+	CompletionWindow       *repository.CompletionWindowRepository
+	JobRun                 *repository.JobRunRepository
+	LLMCall                *repository.LLMCallRepository
+	YearReview             *repository.YearReviewRepository
+	Note                   *repository.NoteRepository
+}
+
+// Services holds the business logic services
+type Services struct {
+	Auth         *services.AuthService
+	Gamification *services.GamificationService
+	DayResolver  *services.DayResolver
+	Streak       *services.StreakService
+	Habit        *services.HabitService
+	Log          *services.LogService
+	Report       *services.ReportService
+	ReportJob    *services.ReportJobService
+	YearReview   *services.YearReviewService
+	Sync         *services.SyncService
+	Freeze       *services.FreezeService
+	Insight      *services.InsightService
+	Notification *services.NotificationService
+	Digest       *services.DigestService
+	Unsubscribe  *services.UnsubscribeService
+	Email        *services.EmailService
+	Note         *services.NoteService
+}
+
+// App is the server's dependency graph
+type App struct {
+	KeyManager   *services.KeyManager
+	Repositories *Repositories
+	Services     *Services
+}
+
+// New builds the dependency graph. Push notifications are only logged when
+// fcmClient is nil, and emails when emailSender is nil.
+func New(
+	db *pgxpool.Pool,
+	redisClient *redis.Client,
+	keyManager *services.KeyManager,
+	fcmClient *services.FCMClient,
+	emailSender services.EmailSender,
+	cfg *config.Config,
+) *App {
+	repos := &Repositories{
+		User:                   repository.NewUserRepository(db),
+		Habit:                  repository.NewHabitRepository(db),
+		Log:                    repository.NewLogRepository(db),
+		Streak:                 repository.NewStreakRepository(db),
+		Report:                 repository.NewReportRepository(db),
+		ReportJob:              repository.NewReportJobRepository(db),
+		Revision:               repository.NewRevisionRepository(db),
+		RefreshToken:           repository.NewRefreshTokenRepository(db),
+		Session:                repository.NewSessionRepository(db),
+		Freeze:                 repository.NewFreezeRepository(db),
+		Device:                 repository.NewDeviceRepository(db),
+		Notification:           repository.NewNotificationRepository(db),
+		NotificationPreference: repository.NewNotificationPreferenceRepository(db),
+		CompletionWindow:       repository.NewCompletionWindowRepository(db),
+		JobRun:                 repository.NewJobRunRepository(db),
+		LLMCall:                repository.NewLLMCallRepository(db),
+		YearReview:             repository.NewYearReviewRepository(db),
+		Note:                   repository.NewNoteRepository(db),
+	}
+
+	svcs := &Services{}
+	firebaseVerifier := services.NewFirebaseVerifier(cfg.FirebaseProjectID, services.NewHTTPKeySource(cfg.FirebaseCertsURL))
+	svcs.Auth = services.NewAuthService(repos.User, repos.RefreshToken, repos.Session, repos.Device, firebaseVerifier, keyManager, cfg)
+	svcs.Gamification = services.NewGamificationService(repos.User)
+	svcs.DayResolver = services.NewDayResolver(repos.User)
+	svcs.Streak = services.NewStreakService(repos.User, repos.Habit, repos.Streak, svcs.DayResolver)
+	svcs.Habit = services.NewHabitService(repos.Habit, repos.Log, repos.Streak, repos.Freeze, svcs.Streak, svcs.DayResolver)
+	svcs.Log = services.NewLogService(repos.Log, repos.Habit, repos.Freeze, svcs.Streak, svcs.Gamification, svcs.DayResolver)
+
+	reportGenerator := services.NewReportGenerator(cfg, redisClient)
+	svcs.Report = services.NewReportService(repos.Report, repos.Habit, repos.Log, repos.Revision, repos.LLMCall, reportGenerator, svcs.DayResolver)
+	svcs.YearReview = services.NewYearReviewService(repos.Report, repos.YearReview, repos.LLMCall, reportGenerator, svcs.DayResolver, cfg.ReportShareSecret, cfg.PublicBaseURL)
+
+	svcs.Sync = services.NewSyncService(repos.Habit, repos.Log, svcs.Streak)
+	svcs.Freeze = services.NewFreezeService(repos.Habit, repos.Log, repos.Freeze, svcs.Streak, svcs.DayResolver)
+	svcs.Insight = services.NewInsightService(repos.Habit, repos.Log, repos.CompletionWindow, svcs.DayResolver)
+	svcs.Notification = services.NewNotificationService(repos.User, repos.Habit, repos.Streak, repos.Freeze, repos.Device, repos.Notification, repos.NotificationPreference, svcs.Habit, svcs.DayResolver, fcmClient, cfg)
+	svcs.ReportJob = services.NewReportJobService(repos.ReportJob, svcs.Report, svcs.YearReview, repos.User, svcs.Notification, cfg.ReportWorkers, cfg.ReportRegenerationQuota)
+	svcs.Digest = services.NewDigestService(repos.User, repos.Habit, repos.Log, repos.Streak, repos.Freeze, svcs.DayResolver)
+	svcs.Unsubscribe = services.NewUnsubscribeService(repos.NotificationPreference, cfg.EmailUnsubscribeSecret, cfg.PublicBaseURL)
+	svcs.Email = services.NewEmailService(repos.User, repos.Notification, repos.NotificationPreference, svcs.Digest, svcs.Unsubscribe, emailSender)
+	svcs.Note = services.NewNoteService(repos.Note)
+
+	return &App{
+		KeyManager:   keyManager,
+		Repositories: repos,
+		Services:     svcs,
+	}
+}
@@ -1,10 +1,22 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"strconv"
 	"time"
 )
 
+// defaultJWTSecret is the development fallback for JWT_SECRET
+const defaultJWTSecret = "your-super-secret-key-change-in-production"
+
+// Language model providers for report generation
+const (
+	LLMProviderGemini = "gemini" // Google Gemini API
+	LLMProviderOpenAI = "openai" // any OpenAI-compatible chat completions API
+	LLMProviderMock   = "mock"   // deterministic reports without a model
+)
+
 // Config holds all configuration for the application
 type Config struct {
 	// Server
@@ -20,15 +32,49 @@ type Config struct {
 	// Firebase
 	FirebaseProjectID      string
 	FirebaseCredentialsJSON string
-
-	// Gemini AI
-	GeminiAPIKey string
+	FirebaseCertsURL       string
+	FCMEndpoint            string
+
+	// AI report generation
+	LLMProvider   string
+	GeminiAPIKey  string
+	GeminiModel   string
+	GeminiTimeout time.Duration
+	OpenAIBaseURL string
+	OpenAIAPIKey  string
+	OpenAIModel   string
+	OpenAITimeout time.Duration
+
+	// AI report limits
+	ReportCacheTTL          time.Duration
+	ReportRegenerationQuota int
+	ReportShareSecret       string
+
+	// Email
+	SMTPHost               string
+	SMTPPort               string
+	SMTPUsername           string
+	SMTPPassword           string
+	EmailFrom              string
+	EmailUnsubscribeSecret string
+	PublicBaseURL          string
 
 	// JWT
 	JWTSecret     string
 	JWTExpiry     time.Duration
 	RefreshExpiry time.Duration
 
+	// JWT signing keys
+	JWTSigningAlgorithm    string
+	JWTPrivateKeys         string
+	JWTKeyDir              string
+	JWTKeyRotationInterval time.Duration
+
+	// Background jobs
+	SchedulerEnabled  bool
+	SchedulerInterval time.Duration
+	ReportWorkers     int
+
 	// App Settings
 	AllowedOrigins []string
 }
@@ -49,15 +95,49 @@ func Load() *Config {
 		// Firebase
 		FirebaseProjectID:      getEnv("FIREBASE_PROJECT_ID", ""),
 		FirebaseCredentialsJSON: getEnv("FIREBASE_CREDENTIALS_JSON", ""),
-
-		// Gemini AI
-		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
+		FirebaseCertsURL:       getEnv("FIREBASE_CERTS_URL", ""), // empty uses Google's published certificates
+		FCMEndpoint:            getEnv("FCM_ENDPOINT", ""),       // empty uses the FCM HTTP v1 API
+
+		// AI report generation
+		LLMProvider:   getEnv("LLM_PROVIDER", defaultLLMProvider()),
+		GeminiAPIKey:  getEnv("GEMINI_API_KEY", ""),
+		GeminiModel:   getEnv("GEMINI_MODEL", "gemini-2.5-flash"),
+		GeminiTimeout: parseDuration(getEnv("GEMINI_TIMEOUT", "60s")),
+		OpenAIBaseURL: getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"), // e.g. http://localhost:11434/v1 for Ollama
+		OpenAIAPIKey:  getEnv("OPENAI_API_KEY", ""),                           // optional for local servers
+		OpenAIModel:   getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		OpenAITimeout: parseDuration(getEnv("OPENAI_TIMEOUT", "120s")),
+
+		// AI report limits
+		ReportCacheTTL:          parseDuration(getEnv("REPORT_CACHE_TTL", "720h")),     // 0 disables caching
+		ReportRegenerationQuota: parseInt(getEnv("REPORT_REGENERATION_QUOTA", "5"), 5), // per user and month, 0 for no limit
+		ReportShareSecret:       getEnv("REPORT_SHARE_SECRET", getEnv("JWT_SECRET", defaultJWTSecret)),
+
+		// Email
+		SMTPHost:               getEnv("SMTP_HOST", ""), // empty logs emails instead of sending them
+		SMTPPort:               getEnv("SMTP_PORT", "587"),
+		SMTPUsername:           getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:           getEnv("SMTP_PASSWORD", ""),
+		EmailFrom:              getEnv("EMAIL_FROM", "Habit Tracker <no-reply@habittracker.app>"),
+		EmailUnsubscribeSecret: getEnv("EMAIL_UNSUBSCRIBE_SECRET", getEnv("JWT_SECRET", defaultJWTSecret)),
+		PublicBaseURL:          getEnv("PUBLIC_BASE_URL", "http://localhost:8080"), // where unsubscribe and share links point
 
 		// JWT
-		JWTSecret:     getEnv("JWT_SECRET", "your-super-secret-key-change-in-production"),
+		JWTSecret:     getEnv("JWT_SECRET", defaultJWTSecret),
 		JWTExpiry:     parseDuration(getEnv("JWT_EXPIRY", "24h")),
 		RefreshExpiry: parseDuration(getEnv("REFRESH_EXPIRY", "168h")), // 7 days
 
+		// JWT signing keys
+		JWTSigningAlgorithm:    getEnv("JWT_SIGNING_ALG", "RS256"), // RS256, EdDSA or HS256 (legacy)
+		JWTPrivateKeys:         getEnv("JWT_PRIVATE_KEYS", ""),    // PEM blocks, the last private key signs
+		JWTKeyDir:              getEnv("JWT_KEY_DIR", ""),
+		JWTKeyRotationInterval: parseDuration(getEnv("JWT_KEY_ROTATION_INTERVAL", "720h")), // 30 days
+
+		// Background jobs
+		SchedulerEnabled:  getEnv("SCHEDULER_ENABLED", "true") == "true",
+		SchedulerInterval: parseDuration(getEnv("SCHEDULER_INTERVAL", "1m")),
+		ReportWorkers:     parseInt(getEnv("REPORT_WORKERS", "2"), 2), // 0 leaves report jobs to other replicas
+
 		// App Settings
 		AllowedOrigins: []string{"http://localhost:3000", "http://localhost:8080"},
 	}
@@ -80,6 +160,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// defaultLLMProvider uses Gemini when an API key is set and the mock
+// generator otherwise
+func defaultLLMProvider() string {
+	if getEnv("GEMINI_API_KEY", "") != "" {
+		return LLMProviderGemini
+	}
+	return LLMProviderMock
+}
+
 // parseDuration parses a duration string or returns a default
 func parseDuration(s string) time.Duration {
 	d, err := time.ParseDuration(s)
@@ -89,6 +178,52 @@ func parseDuration(s string) time.Duration {
 	return d
 }
 
+// parseInt parses an integer or returns a default
+func parseInt(s string, defaultValue int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultValue
+	}
+	return n
+}
+
+// Validate rejects configurations that are unsafe to run with
+func (c *Config) Validate() error {
+	if c.IsProduction() && c.JWTSecret == defaultJWTSecret {
+		return errors.New("JWT_SECRET must be set in production")
+	}
+
+	if c.IsProduction() && c.JWTSigningAlgorithm != "HS256" && c.JWTPrivateKeys == "" && c.JWTKeyDir == "" {
+		return errors.New("JWT_PRIVATE_KEYS or JWT_KEY_DIR must be set in production")
+	}
+
+	// In production these secrets must not fall back to JWT_SECRET, so
+	// that one leaked secret cannot forge the others
+	if c.IsProduction() && (c.EmailUnsubscribeSecret == "" || c.EmailUnsubscribeSecret == c.JWTSecret) {
+		return errors.New("EMAIL_UNSUBSCRIBE_SECRET must be set in production and differ from JWT_SECRET")
+	}
+
+	if c.IsProduction() && (c.ReportShareSecret == "" || c.ReportShareSecret == c.JWTSecret) {
+		return errors.New("REPORT_SHARE_SECRET must be set in production and differ from JWT_SECRET")
+	}
+
+	switch c.LLMProvider {
+	case LLMProviderGemini:
+		if c.GeminiAPIKey == "" {
+			return errors.New("GEMINI_API_KEY must be set to use the gemini provider")
+		}
+	case LLMProviderOpenAI:
+		if c.OpenAIBaseURL == "" || c.OpenAIModel == "" {
+			return errors.New("OPENAI_BASE_URL and OPENAI_MODEL must be set to use the openai provider")
+		}
+	case LLMProviderMock:
+	default:
+		return errors.New("LLM_PROVIDER must be gemini, openai or mock")
+	}
+
+	return nil
+}
+
 // IsDevelopment returns true if running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.Env == "development"
@@ -25,6 +25,27 @@ func RunMigrations(pool *pgxpool.Pool) error {
 		migrationCreateXPLogsTable,
 		migrationCreateBadgesTable,
 		migrationCreateIndexes,
+		migrationCreateRefreshTokensTable,
+		migrationCreateSessionsTable,
+		migrationAddHabitSchedule,
+		migrationAddQuantitativeHabits,
+		migrationCreateStreakFreezeTables,
+		migrationCreateJobRunsTable,
+		migrationCreateUserDevicesTable,
+		migrationCreateNotificationsTable,
+		migrationAddHabitReminders,
+		migrationCreateCompletionWindowsTable,
+		migrationCreateNotificationPreferencesTable,
+		migrationAddEmailDelivery,
+		migrationAddReportGeneration,
+		migrationCreateReportJobsTable,
+		migrationCreateLLMCallsTable,
+		migrationAddReportPeriods,
+		migrationCreateYearReviewsTable,
+		migrationCreateNoteSearch,
+		migrationAddNotificationOccurrenceKeys,
+		migrationAddReminderSnoozedAt,
+		migrationAddYearReviewJobs,
 	}
 
 	for i, migration := range migrations {
@@ -240,3 +261,373 @@ CREATE INDEX IF NOT EXISTS idx_revision_habits_status ON revision_habits(status)
 CREATE INDEX IF NOT EXISTS idx_xp_logs_user_id ON xp_logs(user_id);
 CREATE INDEX IF NOT EXISTS idx_user_badges_user_id ON user_badges(user_id);
 `
+
+const migrationCreateRefreshTokensTable = `
+-- Refresh Tokens Table (opaque, hashed, single-use)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id UUID NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    device_label VARCHAR(100),
+    issued_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+`
+
+const migrationCreateSessionsTable = `
+-- Sessions Table (one per login; the id doubles as the refresh token family id)
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_name VARCHAR(100),
+    platform VARCHAR(20),
+    user_agent TEXT,
+    last_ip VARCHAR(45),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+`
+
+const migrationAddHabitSchedule = `
+-- Habit schedules (weekdays, times per week, interval, day of month)
+ALTER TABLE habits ADD COLUMN IF NOT EXISTS schedule JSONB;
+`
+
+const migrationAddQuantitativeHabits = `
+-- Quantitative habits (target and unit on habits, measured value on logs)
+ALTER TABLE habits ADD COLUMN IF NOT EXISTS target_value DOUBLE PRECISION;
+ALTER TABLE habits ADD COLUMN IF NOT EXISTS unit VARCHAR(20);
+ALTER TABLE daily_logs ADD COLUMN IF NOT EXISTS value DOUBLE PRECISION;
+`
+
+const migrationCreateStreakFreezeTables = `
+-- Streak freezes (token balance on users, frozen days per habit) and rest days
+ALTER TABLE users ADD COLUMN IF NOT EXISTS streak_freezes INT DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS habit_freezes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    habit_id UUID NOT NULL REFERENCES habits(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    freeze_date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(habit_id, freeze_date)
+);
+
+CREATE TABLE IF NOT EXISTS user_vacations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    note VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_habit_freezes_user_date ON habit_freezes(user_id, freeze_date);
+CREATE INDEX IF NOT EXISTS idx_user_vacations_user_id ON user_vacations(user_id);
+`
+
+const migrationCreateJobRunsTable = `
+-- Background job run history (one row per run of a scheduled job)
+CREATE TABLE IF NOT EXISTS job_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_name VARCHAR(50) NOT NULL,
+    instance VARCHAR(100),
+    status VARCHAR(20) NOT NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    window_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    items_processed INT DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_name_status ON job_runs(job_name, status, window_end DESC);
+`
+
+const migrationCreateUserDevicesTable = `
+-- Push devices (one row per FCM registration token)
+CREATE TABLE IF NOT EXISTS user_devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fcm_token TEXT UNIQUE NOT NULL,
+    platform VARCHAR(20),
+    app_version VARCHAR(30),
+    locale VARCHAR(20),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_registered_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_devices_user_id ON user_devices(user_id);
+
+-- Carry over the single token stored on users before devices existed
+INSERT INTO user_devices (user_id, fcm_token, last_registered_at)
+SELECT id, fcm_token, updated_at FROM users
+WHERE fcm_token IS NOT NULL AND fcm_token <> '' AND deleted_at IS NULL
+ON CONFLICT (fcm_token) DO NOTHING;
+
+-- user_devices is the only place tokens live; the column is kept (and left
+-- empty) only so the carry-over above can be replayed
+UPDATE users SET fcm_token = NULL WHERE fcm_token IS NOT NULL;
+`
+
+const migrationCreateNotificationsTable = `
+-- Notifications (push delivery outbox and in-app inbox)
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    data JSONB,
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_outbox ON notifications(status, scheduled_for);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_inbox ON notifications(user_id, scheduled_for DESC);
+`
+
+const migrationAddHabitReminders = `
+-- Per-habit reminder snoozes and quiet hours
+ALTER TABLE habits ADD COLUMN IF NOT EXISTS reminder_snoozed_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quiet_hours_start TIME;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quiet_hours_end TIME;
+
+CREATE INDEX IF NOT EXISTS idx_habits_reminders ON habits(user_id)
+    WHERE deleted_at IS NULL AND (reminder_time IS NOT NULL OR reminder_snoozed_until IS NOT NULL);
+`
+
+const migrationCreateCompletionWindowsTable = `
+-- Completion windows learned from each habit's completion times
+CREATE TABLE IF NOT EXISTS habit_completion_windows (
+    habit_id UUID PRIMARY KEY REFERENCES habits(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    window_start TIME,
+    window_end TIME,
+    typical_time TIME,
+    nudge_time TIME,
+    sample_size INT NOT NULL DEFAULT 0,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_habit_completion_windows_user ON habit_completion_windows(user_id);
+`
+
+const migrationCreateNotificationPreferencesTable = `
+-- Per-type notification preferences; types without a row use the defaults
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL,
+    push_enabled BOOLEAN NOT NULL DEFAULT true,
+    email_enabled BOOLEAN NOT NULL DEFAULT false,
+    in_app_enabled BOOLEAN NOT NULL DEFAULT true,
+    quiet_hours_start TIME,
+    quiet_hours_end TIME,
+    max_per_day INT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, type)
+);
+
+-- Channels each notification was addressed to
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS push BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS in_app BOOLEAN NOT NULL DEFAULT true;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_type ON notifications(user_id, type, created_at);
+`
+
+const migrationAddEmailDelivery = `
+-- Email delivery state, tracked apart from push
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_status VARCHAR(20);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_next_attempt_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_error TEXT;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS email_sent_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_email_due ON notifications(scheduled_for)
+    WHERE email_status IN ('pending', 'sending');
+`
+
+const migrationAddReportGeneration = `
+-- How each report's content was generated
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS generation_source VARCHAR(20) NOT NULL DEFAULT 'unknown';
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS generation_model VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS generation_attempts INT NOT NULL DEFAULT 0;
+`
+
+const migrationCreateReportJobsTable = `
+-- Report generation queue (one row per requested generation)
+CREATE TABLE IF NOT EXISTS report_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    report_month DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    attempts INT NOT NULL DEFAULT 0,
+    report_id UUID REFERENCES reports(id) ON DELETE SET NULL,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- At most one unfinished job per user and period: see migrationAddYearReviewJobs.
+-- report_month is renamed later, so indexing it here would fail on replay.
+CREATE INDEX IF NOT EXISTS idx_report_jobs_queue ON report_jobs(status, created_at);
+`
+
+const migrationCreateLLMCallsTable = `
+-- Language model usage (one row per request to a provider)
+CREATE TABLE IF NOT EXISTS llm_calls (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    purpose VARCHAR(30) NOT NULL,
+    prompt_tokens INT NOT NULL DEFAULT 0,
+    response_tokens INT NOT NULL DEFAULT 0,
+    latency_ms INT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_calls_user_created ON llm_calls(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_llm_calls_created ON llm_calls(created_at);
+`
+
+const migrationAddReportPeriods = `
+-- Reports cover a week, month, quarter or year; report_month keeps the
+-- period's first day for older readers
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS period_type VARCHAR(10) NOT NULL DEFAULT 'month';
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS period_start DATE;
+ALTER TABLE reports ADD COLUMN IF NOT EXISTS period_end DATE;
+
+UPDATE reports SET
+    period_start = report_month,
+    period_end = (report_month + INTERVAL '1 month' - INTERVAL '1 day')::date
+WHERE period_start IS NULL;
+
+ALTER TABLE reports ALTER COLUMN period_start SET NOT NULL;
+ALTER TABLE reports ALTER COLUMN period_end SET NOT NULL;
+
+ALTER TABLE reports DROP CONSTRAINT IF EXISTS reports_user_id_report_month_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_user_period ON reports(user_id, period_type, period_start);
+
+-- Report jobs name the period they generate
+ALTER TABLE report_jobs ADD COLUMN IF NOT EXISTS period_type VARCHAR(10) NOT NULL DEFAULT 'month';
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='report_jobs' AND column_name='report_month') THEN
+        ALTER TABLE report_jobs RENAME COLUMN report_month TO period_start;
+    END IF;
+END $$;
+
+-- The one-unfinished-job index is rebuilt over the period by
+-- migrationAddYearReviewJobs
+DROP INDEX IF EXISTS idx_report_jobs_active;
+`
+
+const migrationCreateYearReviewsTable = `
+-- Year in review, built from the year's monthly reports and logs
+CREATE TABLE IF NOT EXISTS year_reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    year INT NOT NULL,
+    content JSONB NOT NULL,
+    narrative TEXT NOT NULL,
+    narrative_source VARCHAR(20) NOT NULL,
+    narrative_model VARCHAR(100) NOT NULL DEFAULT '',
+    generated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, year)
+);
+`
+
+const migrationCreateNoteSearch = `
+-- Full-text search over learning notes
+ALTER TABLE daily_logs ADD COLUMN IF NOT EXISTS learning_note_tsv tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', COALESCE(learning_note, ''))) STORED;
+CREATE INDEX IF NOT EXISTS idx_daily_logs_learning_note_tsv ON daily_logs USING GIN (learning_note_tsv);
+
+-- #tags written in learning notes, and the logs they appear in. Tags are
+-- backfilled from existing notes when the tables are first created.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = 'note_tags') THEN
+        CREATE TABLE note_tags (
+            id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+            user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            name VARCHAR(50) NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE(user_id, name)
+        );
+
+        CREATE TABLE daily_log_tags (
+            log_id UUID NOT NULL REFERENCES daily_logs(id) ON DELETE CASCADE,
+            tag_id UUID NOT NULL REFERENCES note_tags(id) ON DELETE CASCADE,
+            PRIMARY KEY (log_id, tag_id)
+        );
+        CREATE INDEX idx_daily_log_tags_tag ON daily_log_tags(tag_id);
+
+        WITH extracted AS (
+            SELECT DISTINCT log_id, user_id, name FROM (
+                SELECT dl.id AS log_id, dl.user_id,
+                    LEFT(TRIM(BOTH '-_' FROM LOWER(m[2])), 50) AS name
+                FROM daily_logs dl,
+                    regexp_matches(dl.learning_note, '(^|[^[:alnum:]_&/])#([[:alnum:]_-]+)', 'g') AS m
+                WHERE dl.learning_note LIKE '%#%'
+            ) matches
+            WHERE name <> '' AND name !~ '^[0-9]+$'
+        ), tags AS (
+            INSERT INTO note_tags (user_id, name)
+            SELECT DISTINCT user_id, name FROM extracted
+            RETURNING id, user_id, name
+        )
+        INSERT INTO daily_log_tags (log_id, tag_id)
+        SELECT e.log_id, t.id
+        FROM extracted e
+        JOIN tags t ON t.user_id = e.user_id AND t.name = e.name;
+    END IF;
+END $$;
+`
+
+const migrationAddNotificationOccurrenceKeys = `
+-- One notification per scheduled occurrence, so replayed job runs do not
+-- queue reminders twice
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS occurrence_key VARCHAR(150);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_occurrence ON notifications(user_id, occurrence_key)
+    WHERE occurrence_key IS NOT NULL;
+`
+
+const migrationAddReminderSnoozedAt = `
+-- When a reminder was snoozed, so a snooze only holds back the occurrence
+-- it was made for
+ALTER TABLE habits ADD COLUMN IF NOT EXISTS reminder_snoozed_at TIMESTAMP WITH TIME ZONE;
+`
+
+const migrationAddYearReviewJobs = `
+-- Year reviews are built by report jobs too; a review job and a report job
+-- for the same year run side by side
+DROP INDEX IF EXISTS idx_report_jobs_active_period;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_report_jobs_active_target
+    ON report_jobs(user_id, period_type, period_start, (kind = 'year_review'))
+    WHERE status IN ('queued', 'running');
+`
@@ -29,6 +29,7 @@ func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 // @Param body body models.AuthRegisterRequest true "Registration request"
 // @Success 201 {object} models.AuthTokenResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.AuthRegisterRequest
@@ -40,12 +41,31 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// In production, validate Firebase token here
-	// For now, use the token as Firebase UID (for testing)
-	firebaseUID := req.FirebaseToken
+	identity, err := h.authService.VerifyFirebaseToken(c.Request.Context(), req.FirebaseToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "invalid_firebase_token",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	device := &models.DeviceInfo{
+		Name:      req.DeviceLabel,
+		Platform:  req.Platform,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
 
-	response, err := h.authService.RegisterUser(c.Request.Context(), &req, firebaseUID)
+	response, err := h.authService.RegisterUser(c.Request.Context(), &req, identity, device)
 	if err != nil {
+		if err == services.ErrEmailRequired {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "Email is required when the Firebase account has none",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "registration_failed",
 			"message": err.Error(),
@@ -76,10 +96,23 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// In production, validate Firebase token and get UID
-	firebaseUID := req.FirebaseToken
+	identity, err := h.authService.VerifyFirebaseToken(c.Request.Context(), req.FirebaseToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "invalid_firebase_token",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	device := &models.DeviceInfo{
+		Name:      req.DeviceLabel,
+		Platform:  req.Platform,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
 
-	response, err := h.authService.Login(c.Request.Context(), firebaseUID)
+	response, err := h.authService.Login(c.Request.Context(), identity, device)
 	if err != nil {
 		if err == services.ErrUserNotFound {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -118,12 +151,25 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken)
+	response, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "invalid_token",
-			"message": err.Error(),
-		})
+		switch err {
+		case services.ErrInvalidToken, services.ErrTokenExpired, services.ErrSessionRevoked:
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_token",
+				"message": err.Error(),
+			})
+		case services.ErrTokenReused:
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "token_reused",
+				"message": "Refresh token was already used. Please log in again.",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "refresh_failed",
+				"message": err.Error(),
+			})
+		}
 		return
 	}
 
@@ -160,7 +206,7 @@ func (h *AuthHandler) UpdateFCMToken(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.UpdateFCMToken(c.Request.Context(), userID.(uuid.UUID), req.FCMToken); err != nil {
+	if err := h.authService.UpdateFCMToken(c.Request.Context(), userID.(uuid.UUID), &req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "update_failed",
 			"message": err.Error(),
@@ -177,14 +223,80 @@ func (h *AuthHandler) UpdateFCMToken(c *gin.Context) {
 // Logout handles user logout
 // @Summary Logout user
 // @Tags Auth
+// @Accept json
 // @Security BearerAuth
+// @Param body body models.AuthLogoutRequest true "Logout request"
 // @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// In a stateless JWT setup, logout is handled client-side
-	// by removing the token. Server could blacklist the token if needed.
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.AuthLogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), userID.(uuid.UUID), req.RefreshToken); err != nil {
+		if err == services.ErrInvalidToken {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "invalid_token",
+				"message": "Refresh token not found or already revoked",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "logout_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Logged out successfully",
 	})
 }
+
+// LogoutAll handles logging out every device of the user
+// @Summary Logout from all devices
+// @Tags Auth
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	if err := h.authService.LogoutAll(c.Request.Context(), userID.(uuid.UUID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "logout_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Logged out from all devices",
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/habittracker/backend/internal/services"
+)
+
+// unsubscribePage is shown after following an unsubscribe link. With an
+// Action it asks to confirm with a button posting there.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Heading}}</title>
+</head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,Helvetica,Arial,sans-serif;color:#1f2933;max-width:480px;margin:64px auto;padding:0 16px;">
+<h1 style="font-size:22px;">{{.Heading}}</h1>
+<p style="font-size:15px;line-height:1.5;">{{.Message}}</p>
+{{- if .Action}}
+<form method="post" action="{{.Action}}">
+<button type="submit" style="font-size:15px;padding:10px 20px;border:0;border-radius:6px;background:#1f2933;color:#fff;cursor:pointer;">Unsubscribe</button>
+</form>
+{{- end}}
+</body>
+</html>
+`))
+
+// EmailHandler handles the public endpoints linked from emails
+type EmailHandler struct {
+	unsubscribeService *services.UnsubscribeService
+}
+
+// NewEmailHandler creates a new EmailHandler
+func NewEmailHandler(unsubscribeService *services.UnsubscribeService) *EmailHandler {
+	return &EmailHandler{
+		unsubscribeService: unsubscribeService,
+	}
+}
+
+// ConfirmUnsubscribe handles following an unsubscribe link. It changes
+// nothing, since mail scanners and link previews fetch links too, and asks
+// to confirm with a button posting to Unsubscribe.
+// @Summary Confirm unsubscribing from emails
+// @Tags Email
+// @Produce html
+// @Param token query string true "Signed unsubscribe token"
+// @Success 200 {string} string "Confirmation form"
+// @Failure 400 {string} string "Invalid link page"
+// @Router /email/unsubscribe [get]
+func (h *EmailHandler) ConfirmUnsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	scope, err := h.unsubscribeService.Scope(token)
+	if err != nil {
+		h.renderInvalidLink(c)
+		return
+	}
+
+	message := "Stop receiving " + strings.ReplaceAll(scope, "_", " ") + " emails?"
+	if scope == services.UnsubscribeAll {
+		message = "Stop receiving all emails from Habit Tracker?"
+	}
+	h.renderPage(c, http.StatusOK, "Unsubscribe", message,
+		c.Request.URL.Path+"?token="+url.QueryEscape(token))
+}
+
+// Unsubscribe handles confirmed and one-click unsubscribes. The signed token
+// stands in for a login. Mail clients POST to the link itself (RFC 8058).
+// @Summary Unsubscribe from emails
+// @Tags Email
+// @Produce html
+// @Param token query string true "Signed unsubscribe token"
+// @Success 200 {string} string "Confirmation page"
+// @Failure 400 {string} string "Invalid link page"
+// @Router /email/unsubscribe [post]
+func (h *EmailHandler) Unsubscribe(c *gin.Context) {
+	scope, err := h.unsubscribeService.Unsubscribe(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		if err == services.ErrInvalidUnsubscribeToken {
+			h.renderInvalidLink(c)
+			return
+		}
+		h.renderPage(c, http.StatusInternalServerError, "Something went wrong",
+			"We could not unsubscribe you right now. Please try the link again later.", "")
+		return
+	}
+
+	message := "You will no longer receive " + strings.ReplaceAll(scope, "_", " ") + " emails."
+	if scope == services.UnsubscribeAll {
+		message = "You will no longer receive any emails from Habit Tracker."
+	}
+	h.renderPage(c, http.StatusOK, "You are unsubscribed",
+		message+" You can turn them back on in the app under Settings > Notifications.", "")
+}
+
+// renderInvalidLink responds with the page for a bad unsubscribe link
+func (h *EmailHandler) renderInvalidLink(c *gin.Context) {
+	h.renderPage(c, http.StatusBadRequest, "Invalid link",
+		"This unsubscribe link is invalid. You can turn emails off in the app under Settings > Notifications.", "")
+}
+
+// renderPage responds with a simple HTML page, with a confirmation form
+// posting to action unless it is empty
+func (h *EmailHandler) renderPage(c *gin.Context, status int, heading, message, action string) {
+	var page bytes.Buffer
+	if err := unsubscribePage.Execute(&page, gin.H{"Heading": heading, "Message": message, "Action": action}); err != nil {
+		c.String(http.StatusInternalServerError, message)
+		return
+	}
+
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
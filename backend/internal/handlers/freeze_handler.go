@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+	"github.com/habittracker/backend/internal/services"
+)
+
+// FreezeHandler handles streak freeze and vacation endpoints
+type FreezeHandler struct {
+	freezeService *services.FreezeService
+}
+
+// NewFreezeHandler creates a new FreezeHandler
+func NewFreezeHandler(freezeService *services.FreezeService) *FreezeHandler {
+	return &FreezeHandler{
+		freezeService: freezeService,
+	}
+}
+
+// FreezeHabit handles spending a streak freeze on a habit's day
+// @Summary Freeze a habit's day
+// @Tags Habits
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Habit ID"
+// @Param body body models.StreakFreezeRequest false "Day to freeze, defaults to today"
+// @Success 201 {object} models.StreakFreezeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /habits/{id}/freeze [post]
+func (h *FreezeHandler) FreezeHabit(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	habitID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_id",
+			"message": "Invalid habit ID",
+		})
+		return
+	}
+
+	// The body is optional
+	var req models.StreakFreezeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.freezeService.FreezeDay(c.Request.Context(), userID.(uuid.UUID), habitID, &req)
+	if err != nil {
+		switch err {
+		case repository.ErrHabitNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Habit not found",
+			})
+		case services.ErrInvalidFreezeDate:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_freeze_date",
+				"message": err.Error(),
+			})
+		case services.ErrDayCompleted:
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "already_completed",
+				"message": err.Error(),
+			})
+		case repository.ErrAlreadyFrozen:
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "already_frozen",
+				"message": err.Error(),
+			})
+		case repository.ErrNoStreakFreezes:
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "no_streak_freezes",
+				"message": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "freeze_failed",
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetVacations handles listing the user's rest days
+// @Summary List vacations
+// @Tags User
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.VacationListResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /user/vacations [get]
+func (h *FreezeHandler) GetVacations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	vacations, err := h.freezeService.GetVacations(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	responses := []*models.VacationResponse{}
+	for _, vacation := range vacations {
+		responses = append(responses, vacation.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, models.VacationListResponse{
+		Vacations:  responses,
+		TotalCount: len(responses),
+	})
+}
+
+// CreateVacation handles declaring a range of rest days
+// @Summary Create a vacation
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.VacationCreateRequest true "Vacation dates"
+// @Success 201 {object} models.VacationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /user/vacations [post]
+func (h *FreezeHandler) CreateVacation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.VacationCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	vacation, err := h.freezeService.CreateVacation(c.Request.Context(), userID.(uuid.UUID), &req)
+	if err != nil {
+		if err == services.ErrInvalidVacation {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_vacation",
+				"message": "Start date must be within the last week, and end date on or after it and within 90 days of it",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "creation_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, vacation.ToResponse())
+}
+
+// DeleteVacation handles removing a range of rest days
+// @Summary Delete a vacation
+// @Tags User
+// @Security BearerAuth
+// @Param id path string true "Vacation ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /user/vacations/{id} [delete]
+func (h *FreezeHandler) DeleteVacation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	vacationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_id",
+			"message": "Invalid vacation ID",
+		})
+		return
+	}
+
+	if err := h.freezeService.DeleteVacation(c.Request.Context(), userID.(uuid.UUID), vacationID); err != nil {
+		if err == repository.ErrVacationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Vacation not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "delete_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Vacation deleted successfully",
+	})
+}
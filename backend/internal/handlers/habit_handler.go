@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -92,6 +93,20 @@ func (h *HabitHandler) CreateHabit(c *gin.Context) {
 
 	habit, err := h.habitService.CreateHabit(c.Request.Context(), userID.(uuid.UUID), &req)
 	if err != nil {
+		if err == models.ErrInvalidSchedule {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_schedule",
+				"message": "Schedule does not match the habit frequency",
+			})
+			return
+		}
+		if err == services.ErrInvalidReminderTime {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_reminder_time",
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "creation_failed",
 			"message": err.Error(),
@@ -200,6 +215,20 @@ func (h *HabitHandler) UpdateHabit(c *gin.Context) {
 			})
 			return
 		}
+		if err == models.ErrInvalidSchedule {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_schedule",
+				"message": "Schedule does not match the habit frequency",
+			})
+			return
+		}
+		if err == services.ErrInvalidReminderTime {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_reminder_time",
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "update_failed",
 			"message": err.Error(),
@@ -306,3 +335,68 @@ func (h *HabitHandler) GetHabitStreak(c *gin.Context) {
 
 	c.JSON(http.StatusOK, streak.ToResponse())
 }
+
+// SnoozeReminder handles snoozing a habit's reminder
+// @Summary Snooze a habit's reminder
+// @Tags Habits
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Habit ID"
+// @Param minutes query int false "Minutes to snooze for" default(10)
+// @Success 200 {object} models.HabitSnoozeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /habits/{id}/snooze [post]
+func (h *HabitHandler) SnoozeReminder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	habitID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_id",
+			"message": "Invalid habit ID",
+		})
+		return
+	}
+
+	minutes, err := strconv.Atoi(c.DefaultQuery("minutes", strconv.Itoa(services.DefaultSnoozeMinutes)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_snooze",
+			"message": services.ErrInvalidSnooze.Error(),
+		})
+		return
+	}
+
+	resp, err := h.habitService.SnoozeReminder(c.Request.Context(), userID.(uuid.UUID), habitID, minutes)
+	if err != nil {
+		switch err {
+		case repository.ErrHabitNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Habit not found",
+			})
+		case services.ErrInvalidSnooze:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_snooze",
+				"message": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "snooze_failed",
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
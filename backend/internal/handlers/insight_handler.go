@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/repository"
+	"github.com/habittracker/backend/internal/services"
+)
+
+// InsightHandler handles habit insight endpoints
+type InsightHandler struct {
+	insightService *services.InsightService
+}
+
+// NewInsightHandler creates a new InsightHandler
+func NewInsightHandler(insightService *services.InsightService) *InsightHandler {
+	return &InsightHandler{
+		insightService: insightService,
+	}
+}
+
+// GetHabitInsights handles getting when a habit is usually completed and
+// when its reminder goes out
+// @Summary Get habit insights
+// @Tags Habits
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Habit ID"
+// @Success 200 {object} models.HabitInsightsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /habits/{id}/insights [get]
+func (h *InsightHandler) GetHabitInsights(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	habitID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_id",
+			"message": "Invalid habit ID",
+		})
+		return
+	}
+
+	insights, err := h.insightService.GetHabitInsights(c.Request.Context(), userID.(uuid.UUID), habitID)
+	if err != nil {
+		if err == repository.ErrHabitNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Habit not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, insights)
+}
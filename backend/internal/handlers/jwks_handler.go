@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/habittracker/backend/internal/services"
+)
+
+// JWKSHandler publishes the access token verification keys
+type JWKSHandler struct {
+	keyManager *services.KeyManager
+}
+
+// NewJWKSHandler creates a new JWKSHandler
+func NewJWKSHandler(keyManager *services.KeyManager) *JWKSHandler {
+	return &JWKSHandler{
+		keyManager: keyManager,
+	}
+}
+
+// GetJWKS handles serving the JSON Web Key Set
+// @Summary Get access token verification keys
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} services.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// New keys are published this long before they sign, so verifiers
+	// caching the set know a key before tokens signed with it arrive
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(services.JWKSMaxAge.Seconds())))
+	c.JSON(http.StatusOK, h.keyManager.JWKS())
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
 	"github.com/habittracker/backend/internal/services"
 )
 
@@ -298,3 +299,69 @@ func (h *LogHandler) QuickComplete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, log.ToResponse())
 }
+
+// IncrementLog handles adding partial progress to a quantitative habit
+// @Summary Increment a quantitative habit's value
+// @Tags Logs
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param habit_id path string true "Habit ID"
+// @Param body body models.DailyLogIncrementRequest true "Increment request"
+// @Success 200 {object} models.DailyLogResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /logs/increment/{habit_id} [post]
+func (h *LogHandler) IncrementLog(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	habitID, err := uuid.Parse(c.Param("habit_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_id",
+			"message": "Invalid habit ID",
+		})
+		return
+	}
+
+	var req models.DailyLogIncrementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	log, err := h.logService.IncrementLog(c.Request.Context(), userID.(uuid.UUID), habitID, &req)
+	if err != nil {
+		switch err {
+		case repository.ErrHabitNotFound:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Habit not found",
+			})
+		case services.ErrNotQuantitative:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "not_quantitative",
+				"message": "Habit has no target value to increment",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "increment_failed",
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, log.ToResponse())
+}
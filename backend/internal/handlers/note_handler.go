@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/services"
+)
+
+// NoteHandler handles learning note search endpoints
+type NoteHandler struct {
+	noteService *services.NoteService
+}
+
+// NewNoteHandler creates a new NoteHandler
+func NewNoteHandler(noteService *services.NoteService) *NoteHandler {
+	return &NoteHandler{
+		noteService: noteService,
+	}
+}
+
+// SearchNotes handles full-text search over the user's learning notes
+// @Summary Search learning notes
+// @Tags Notes
+// @Security BearerAuth
+// @Produce json
+// @Param q query string false "Search query: words, \"quoted phrases\", OR and -excluded words"
+// @Param habit_id query string false "Habit ID"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Param tag query string false "Tag, with or without #"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} models.NoteSearchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /notes/search [get]
+func (h *NoteHandler) SearchNotes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	req := &models.NoteSearchRequest{
+		Query:   c.Query("q"),
+		HabitID: c.Query("habit_id"),
+		From:    c.Query("from"),
+		To:      c.Query("to"),
+		Tag:     c.Query("tag"),
+		Limit:   limit,
+		Offset:  offset,
+	}
+
+	results, err := h.noteService.Search(c.Request.Context(), userID.(uuid.UUID), req)
+	if err != nil {
+		switch err {
+		case services.ErrNoteSearchEmpty:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "missing_query",
+				"message": err.Error(),
+			})
+		case services.ErrInvalidNoteSearch:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_search",
+				"message": "Query must be at most 200 characters, habit_id a valid ID, tag a valid tag and from and to dates in order (YYYY-MM-DD)",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "search_failed",
+				"message": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// GetTags handles listing the tags in the user's learning notes
+// @Summary List note tags
+// @Tags Notes
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.NoteTagListResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /notes/tags [get]
+func (h *NoteHandler) GetTags(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	tags, err := h.noteService.GetTags(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NoteTagListResponse{Tags: tags})
+}
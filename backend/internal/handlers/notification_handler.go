@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+// maxNotificationPageSize caps how many inbox entries one request returns
+const maxNotificationPageSize = 100
+
+// NotificationHandler handles the in-app notification inbox endpoints
+type NotificationHandler struct {
+	notificationRepo *repository.NotificationRepository
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(notificationRepo *repository.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// GetNotifications handles getting a page of the user's inbox
+// @Summary Get notifications
+// @Tags Notifications
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Limit" default(30)
+// @Param offset query int false "Offset" default(0)
+// @Param unread_only query bool false "Only unread notifications" default(false)
+// @Success 200 {object} models.NotificationListResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /notifications [get]
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	unreadOnly, _ := strconv.ParseBool(c.DefaultQuery("unread_only", "false"))
+
+	if limit <= 0 || limit > maxNotificationPageSize {
+		limit = maxNotificationPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	notifications, err := h.notificationRepo.GetByUserID(c.Request.Context(), userID.(uuid.UUID), unreadOnly, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	total, unread, err := h.notificationRepo.CountByUserID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	responses := make([]*models.NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		responses = append(responses, notification.ToResponse())
+	}
+
+	c.JSON(http.StatusOK, models.NotificationListResponse{
+		Notifications: responses,
+		TotalCount:    total,
+		UnreadCount:   unread,
+	})
+}
+
+// GetUnreadCount handles getting the number of unread notifications
+// @Summary Get unread notification count
+// @Tags Notifications
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.UnreadCountResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	_, unread, err := h.notificationRepo.CountByUserID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UnreadCountResponse{
+		UnreadCount: unread,
+	})
+}
+
+// MarkRead handles marking a notification as read
+// @Summary Mark notification as read
+// @Tags Notifications
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Notification ID"
+// @Success 200 {object} models.NotificationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /notifications/{id}/read [put]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_id",
+			"message": "Invalid notification ID",
+		})
+		return
+	}
+
+	notification, err := h.notificationRepo.MarkRead(c.Request.Context(), notificationID, userID.(uuid.UUID))
+	if err != nil {
+		if err == repository.ErrNotificationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Notification not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "update_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, notification.ToResponse())
+}
+
+// MarkAllRead handles marking every notification as read
+// @Summary Mark all notifications as read
+// @Tags Notifications
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /notifications/read-all [put]
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	if _, err := h.notificationRepo.MarkAllRead(c.Request.Context(), userID.(uuid.UUID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "update_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "All notifications marked as read",
+	})
+}
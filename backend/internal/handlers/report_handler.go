@@ -1,33 +1,71 @@
 package handlers
 
 import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
 	"github.com/habittracker/backend/internal/repository"
 	"github.com/habittracker/backend/internal/services"
 )
 
+//go:embed templates/year_review.html
+var yearReviewTemplateFS embed.FS
+
+// yearReviewPage renders a year review as a self-contained page, without
+// images or scripts, that can be shared as is
+var yearReviewPage = template.Must(template.New("year_review.html").Funcs(template.FuncMap{
+	"percent": func(rate float64) string {
+		return fmt.Sprintf("%.0f%%", rate)
+	},
+	"barWidth": func(rate float64) string {
+		return fmt.Sprintf("%.0f", min(max(rate, 0), 100))
+	},
+	"monthName": func(month string) string {
+		t, err := time.Parse("2006-01", month)
+		if err != nil {
+			return month
+		}
+		return t.Format("Jan")
+	},
+}).ParseFS(yearReviewTemplateFS, "templates/year_review.html"))
+
 // ReportHandler handles report endpoints
 type ReportHandler struct {
-	reportService *services.ReportService
+	reportService     *services.ReportService
+	reportJobService  *services.ReportJobService
+	yearReviewService *services.YearReviewService
 }
 
 // NewReportHandler creates a new ReportHandler
-func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+func NewReportHandler(
+	reportService *services.ReportService,
+	reportJobService *services.ReportJobService,
+	yearReviewService *services.YearReviewService,
+) *ReportHandler {
 	return &ReportHandler{
-		reportService: reportService,
+		reportService:     reportService,
+		reportJobService:  reportJobService,
+		yearReviewService: yearReviewService,
 	}
 }
 
-// GetReports handles getting all reports for a user
+// GetReports handles getting all reports of a period type for a user.
+// Without a type only monthly reports are listed, as older clients expect.
 // @Summary Get all reports
 // @Tags Reports
 // @Security BearerAuth
 // @Produce json
+// @Param type query string false "Period type (week, month, quarter, year or all)" default(month)
 // @Success 200 {object} models.ReportListResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Router /reports [get]
 func (h *ReportHandler) GetReports(c *gin.Context) {
@@ -40,7 +78,18 @@ func (h *ReportHandler) GetReports(c *gin.Context) {
 		return
 	}
 
-	reports, err := h.reportService.GetAllReports(c.Request.Context(), userID.(uuid.UUID))
+	periodType := models.ReportPeriodType(c.DefaultQuery("type", string(models.ReportPeriodMonth)))
+	if periodType == "all" {
+		periodType = ""
+	} else if !periodType.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_type",
+			"message": "Type must be week, month, quarter, year or all",
+		})
+		return
+	}
+
+	reports, err := h.reportService.GetAllReports(c.Request.Context(), userID.(uuid.UUID), periodType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "fetch_failed",
@@ -65,16 +114,17 @@ func (h *ReportHandler) GetReports(c *gin.Context) {
 	})
 }
 
-// GetReport handles getting a specific report by month
-// @Summary Get report by month
+// GetReport handles getting a specific report by period
+// @Summary Get report by period
 // @Tags Reports
 // @Security BearerAuth
 // @Produce json
-// @Param month path string true "Month (YYYY-MM)"
+// @Param period path string true "Period (YYYY-Www, YYYY-MM, YYYY-Qn or YYYY)"
 // @Success 200 {object} models.ReportResponse
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
-// @Router /reports/{month} [get]
+// @Router /reports/{period} [get]
 func (h *ReportHandler) GetReport(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -85,21 +135,21 @@ func (h *ReportHandler) GetReport(c *gin.Context) {
 		return
 	}
 
-	monthStr := c.Param("month")
-	if len(monthStr) != 7 {
+	period, err := models.ParseReportPeriod(c.Param("period"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid_month",
-			"message": "Month format should be YYYY-MM",
+			"error":   "invalid_period",
+			"message": err.Error(),
 		})
 		return
 	}
 
-	report, err := h.reportService.GetReport(c.Request.Context(), userID.(uuid.UUID), monthStr)
+	report, err := h.reportService.GetReport(c.Request.Context(), userID.(uuid.UUID), period)
 	if err != nil {
 		if err == repository.ErrReportNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":   "not_found",
-				"message": "Report not found for this month",
+				"message": "Report not found for this period",
 			})
 			return
 		}
@@ -122,19 +172,56 @@ func (h *ReportHandler) GetReport(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// GenerateReport handles manual report generation
-// @Summary Generate monthly report
+// GenerateReport queues generation of a report. A period that already has
+// an unfinished job returns that job instead of a second one.
+// @Summary Generate report
 // @Tags Reports
 // @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param year query int true "Year"
-// @Param month query int true "Month (1-12)"
-// @Success 200 {object} models.ReportResponse
+// @Param period query string false "Period (YYYY-Www, YYYY-MM, YYYY-Qn or YYYY)"
+// @Param year query int false "Year, with month instead of period"
+// @Param month query int false "Month (1-12), with year instead of period"
+// @Success 202 {object} models.ReportJobResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Router /reports/generate [post]
 func (h *ReportHandler) GenerateReport(c *gin.Context) {
+	h.enqueue(c, models.ReportJobGenerate)
+}
+
+// RegenerateReport queues regeneration of a report, replacing the existing
+// one once the job succeeds. Regenerations are limited per month; the
+// X-Regenerations-Remaining header tells how many are left.
+// @Summary Regenerate report
+// @Tags Reports
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param period query string false "Period (YYYY-Www, YYYY-MM, YYYY-Qn or YYYY)"
+// @Param year query int false "Year, with month instead of period"
+// @Param month query int false "Month (1-12), with year instead of period"
+// @Success 202 {object} models.ReportJobResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /reports/regenerate [post]
+func (h *ReportHandler) RegenerateReport(c *gin.Context) {
+	h.enqueue(c, models.ReportJobRegenerate)
+}
+
+// GetReportJob handles polling a report job
+// @Summary Get report job status
+// @Tags Reports
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.ReportJobResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /reports/jobs/{id} [get]
+func (h *ReportHandler) GetReportJob(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -144,18 +231,61 @@ func (h *ReportHandler) GenerateReport(c *gin.Context) {
 		return
 	}
 
-	yearStr := c.Query("year")
-	monthStr := c.Query("month")
-
-	if yearStr == "" || monthStr == "" {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "missing_params",
-			"message": "Year and month are required",
+			"error":   "invalid_id",
+			"message": "Invalid job ID",
 		})
 		return
 	}
 
-	year, err := strconv.Atoi(yearStr)
+	job, err := h.reportJobService.GetJob(c.Request.Context(), userID.(uuid.UUID), jobID)
+	if err != nil {
+		if err == repository.ErrReportJobNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Report job not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job.ToResponse())
+}
+
+// GetYearReview handles getting a user's year in review. Browsers, or any
+// client asking with format=html, get the page shown at the share URL. A
+// review not built yet is queued like a report, answering 202 with the job;
+// a stale one is served while a fresh one is queued.
+// @Summary Get year in review
+// @Tags Reports
+// @Security BearerAuth
+// @Produce json,html
+// @Param year path int true "Year"
+// @Param format query string false "html for the shareable page"
+// @Success 200 {object} models.YearReviewResponse
+// @Success 202 {object} models.ReportJobResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /reports/year/{year} [get]
+func (h *ReportHandler) GetYearReview(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	year, err := strconv.Atoi(c.Param("year"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "invalid_year",
@@ -164,49 +294,119 @@ func (h *ReportHandler) GenerateReport(c *gin.Context) {
 		return
 	}
 
-	month, err := strconv.Atoi(monthStr)
-	if err != nil || month < 1 || month > 12 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid_month",
-			"message": "Month must be between 1 and 12",
-		})
+	review, stale, err := h.yearReviewService.GetYearReview(c.Request.Context(), userID.(uuid.UUID), year)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidYear:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_year",
+				"message": err.Error(),
+			})
+		case repository.ErrYearReviewNotFound:
+			h.enqueueYearReview(c, userID.(uuid.UUID), year)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "fetch_failed",
+				"message": err.Error(),
+			})
+		}
 		return
 	}
 
-	report, err := h.reportService.GenerateReport(c.Request.Context(), userID.(uuid.UUID), year, month)
+	if stale {
+		// A rebuild that cannot be queued, e.g. over the quota, leaves the
+		// stored review in place
+		h.reportJobService.Enqueue(c.Request.Context(), userID.(uuid.UUID), models.ReportJobYearReview, models.YearPeriod(year))
+	}
+
+	resp, err := review.ToResponse()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "generation_failed",
+			"error":   "parse_failed",
 			"message": err.Error(),
 		})
 		return
 	}
+	resp.ShareURL = h.yearReviewService.ShareURL(review.UserID, review.Year)
 
-	resp, err := report.ToResponse()
+	if c.Query("format") == "html" || c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		h.renderYearReview(c, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetSharedYearReview handles share links to a year in review. The signed
+// token stands in for a login. Only a stored review is shown; following a
+// link never builds one.
+// @Summary View a shared year in review
+// @Tags Reports
+// @Produce html
+// @Param token query string true "Signed share token"
+// @Success 200 {string} string "Year in review page"
+// @Failure 404 {string} string "Not found"
+// @Failure 410 {string} string "Link expired"
+// @Router /share/year-review [get]
+func (h *ReportHandler) GetSharedYearReview(c *gin.Context) {
+	review, err := h.yearReviewService.GetSharedYearReview(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		switch err {
+		case services.ErrInvalidShareToken, repository.ErrYearReviewNotFound:
+			c.String(http.StatusNotFound, "This year in review does not exist.")
+		case services.ErrShareTokenExpired:
+			c.String(http.StatusGone, "This share link has expired. Ask for a new one.")
+		default:
+			c.String(http.StatusInternalServerError, "This year in review could not be loaded. Please try again later.")
+		}
+		return
+	}
+
+	resp, err := review.ToResponse()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "This year in review could not be loaded. Please try again later.")
+		return
+	}
+
+	h.renderYearReview(c, resp)
+}
+
+// enqueueYearReview queues building a user's review of a year and responds
+// with the job
+func (h *ReportHandler) enqueueYearReview(c *gin.Context, userID uuid.UUID, year int) {
+	job, _, err := h.reportJobService.Enqueue(c.Request.Context(), userID, models.ReportJobYearReview, models.YearPeriod(year))
 	if err != nil {
+		if err == services.ErrReportQuotaExceeded {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "quota_exceeded",
+				"message": "You have built all year reviews you can this month",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "parse_failed",
+			"error":   "enqueue_failed",
 			"message": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.Header("Location", "/api/v1/reports/jobs/"+job.ID.String())
+	c.JSON(http.StatusAccepted, job.ToResponse())
 }
 
-// RegenerateReport handles regenerating a report
-// @Summary Regenerate monthly report
-// @Tags Reports
-// @Security BearerAuth
-// @Accept json
-// @Produce json
-// @Param year query int true "Year"
-// @Param month query int true "Month (1-12)"
-// @Success 200 {object} models.ReportResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 401 {object} ErrorResponse
-// @Router /reports/regenerate [post]
-func (h *ReportHandler) RegenerateReport(c *gin.Context) {
+// renderYearReview responds with the page of a year review
+func (h *ReportHandler) renderYearReview(c *gin.Context, resp *models.YearReviewResponse) {
+	var page bytes.Buffer
+	if err := yearReviewPage.Execute(&page, resp); err != nil {
+		c.String(http.StatusInternalServerError, "This year in review could not be rendered.")
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// enqueue validates the requested period and queues a report job for it
+func (h *ReportHandler) enqueue(c *gin.Context, kind models.ReportJobKind) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -216,15 +416,59 @@ func (h *ReportHandler) RegenerateReport(c *gin.Context) {
 		return
 	}
 
+	period, ok := queryReportPeriod(c)
+	if !ok {
+		return
+	}
+
+	job, _, err := h.reportJobService.Enqueue(c.Request.Context(), userID.(uuid.UUID), kind, period)
+	if kind == models.ReportJobRegenerate {
+		h.setQuotaHeaders(c, userID.(uuid.UUID), err == services.ErrReportQuotaExceeded)
+	}
+	if err != nil {
+		if err == services.ErrReportQuotaExceeded {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "quota_exceeded",
+				"message": "You have used all report regenerations for this month",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "enqueue_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Location", "/api/v1/reports/jobs/"+job.ID.String())
+	c.JSON(http.StatusAccepted, job.ToResponse())
+}
+
+// queryReportPeriod reads the period of a generate request, either as a key
+// in period or, as older clients send it, as year and month. It writes the
+// error response and returns false when the period is missing or invalid.
+func queryReportPeriod(c *gin.Context) (models.ReportPeriod, bool) {
+	if key := c.Query("period"); key != "" {
+		period, err := models.ParseReportPeriod(key)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_period",
+				"message": err.Error(),
+			})
+			return models.ReportPeriod{}, false
+		}
+		return period, true
+	}
+
 	yearStr := c.Query("year")
 	monthStr := c.Query("month")
 
 	if yearStr == "" || monthStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "missing_params",
-			"message": "Year and month are required",
+			"message": "Period, or year and month, are required",
 		})
-		return
+		return models.ReportPeriod{}, false
 	}
 
 	year, err := strconv.Atoi(yearStr)
@@ -233,7 +477,7 @@ func (h *ReportHandler) RegenerateReport(c *gin.Context) {
 			"error":   "invalid_year",
 			"message": "Invalid year",
 		})
-		return
+		return models.ReportPeriod{}, false
 	}
 
 	month, err := strconv.Atoi(monthStr)
@@ -242,26 +486,24 @@ func (h *ReportHandler) RegenerateReport(c *gin.Context) {
 			"error":   "invalid_month",
 			"message": "Month must be between 1 and 12",
 		})
-		return
+		return models.ReportPeriod{}, false
 	}
 
-	report, err := h.reportService.RegenerateReport(c.Request.Context(), userID.(uuid.UUID), year, month)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "generation_failed",
-			"message": err.Error(),
-		})
-		return
-	}
+	return models.MonthPeriod(year, month), true
+}
 
-	resp, err := report.ToResponse()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "parse_failed",
-			"message": err.Error(),
-		})
+// setQuotaHeaders tells the client how many regenerations are left this
+// month, and when to retry once they are used up
+func (h *ReportHandler) setQuotaHeaders(c *gin.Context, userID uuid.UUID, exceeded bool) {
+	quota, err := h.reportJobService.RegenerationQuota(c.Request.Context(), userID)
+	if err != nil || quota == nil {
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.Header("X-Regenerations-Limit", strconv.Itoa(quota.Limit))
+	c.Header("X-Regenerations-Remaining", strconv.Itoa(quota.Remaining()))
+	c.Header("X-Regenerations-Reset", quota.ResetsAt.Format(time.RFC3339))
+	if exceeded {
+		c.Header("Retry-After", strconv.Itoa(int(time.Until(quota.ResetsAt).Seconds())+1))
+	}
 }
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+	"github.com/habittracker/backend/internal/services"
+)
+
+// SessionHandler handles device session endpoints
+type SessionHandler struct {
+	authService *services.AuthService
+}
+
+// NewSessionHandler creates a new SessionHandler
+func NewSessionHandler(authService *services.AuthService) *SessionHandler {
+	return &SessionHandler{
+		authService: authService,
+	}
+}
+
+// GetSessions handles listing the user's active sessions
+// @Summary List active sessions
+// @Tags User
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.SessionListResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /user/sessions [get]
+func (h *SessionHandler) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	sessions, err := h.authService.GetActiveSessions(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	currentSessionID, _ := c.Get("session_id")
+
+	responses := make([]*models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, session.ToResponse(currentSessionID.(uuid.UUID)))
+	}
+
+	c.JSON(http.StatusOK, models.SessionListResponse{
+		Sessions:   responses,
+		TotalCount: len(responses),
+	})
+}
+
+// RevokeSession handles signing out a single device
+// @Summary Revoke a session
+// @Tags User
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /user/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_id",
+			"message": "Invalid session ID",
+		})
+		return
+	}
+
+	if err := h.authService.RevokeSession(c.Request.Context(), userID.(uuid.UUID), sessionID); err != nil {
+		if err == repository.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Session not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "revoke_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Session revoked successfully",
+	})
+}
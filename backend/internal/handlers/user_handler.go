@@ -7,17 +7,26 @@ import (
 	"github.com/google/uuid"
 	"github.com/habittracker/backend/internal/models"
 	"github.com/habittracker/backend/internal/repository"
+	"github.com/habittracker/backend/internal/services"
 )
 
 // UserHandler handles user endpoints
 type UserHandler struct {
-	userRepo *repository.UserRepository
+	userRepo       *repository.UserRepository
+	deviceRepo     *repository.DeviceRepository
+	preferenceRepo *repository.NotificationPreferenceRepository
 }
 
 // NewUserHandler creates a new UserHandler
-func NewUserHandler(userRepo *repository.UserRepository) *UserHandler {
+func NewUserHandler(
+	userRepo *repository.UserRepository,
+	deviceRepo *repository.DeviceRepository,
+	preferenceRepo *repository.NotificationPreferenceRepository,
+) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		deviceRepo:     deviceRepo,
+		preferenceRepo: preferenceRepo,
 	}
 }
 
@@ -96,6 +105,13 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		user.DisplayName = req.DisplayName
 	}
 	if req.Timezone != nil {
+		if _, err := services.LoadTimezone(*req.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_timezone",
+				"message": "Timezone must be an IANA name such as Asia/Kolkata",
+			})
+			return
+		}
 		user.Timezone = *req.Timezone
 	}
 	if req.NotificationEnabled != nil {
@@ -168,8 +184,19 @@ func (h *UserHandler) UpdateSettings(c *gin.Context) {
 	if req.EveningReminderTime != nil {
 		user.EveningReminderTime = *req.EveningReminderTime
 	}
-	if req.FCMToken != nil {
-		user.FCMToken = req.FCMToken
+	if req.QuietHoursStart != nil {
+		user.QuietHoursStart = clearIfEmpty(req.QuietHoursStart)
+	}
+	if req.QuietHoursEnd != nil {
+		user.QuietHoursEnd = clearIfEmpty(req.QuietHoursEnd)
+	}
+
+	if err := user.ValidateQuietHours(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_quiet_hours",
+			"message": err.Error(),
+		})
+		return
 	}
 
 	if err := h.userRepo.Update(c.Request.Context(), user); err != nil {
@@ -180,9 +207,133 @@ func (h *UserHandler) UpdateSettings(c *gin.Context) {
 		return
 	}
 
+	// A token sent with the settings registers the current device
+	if req.FCMToken != nil && *req.FCMToken != "" {
+		device := &models.UserDevice{UserID: user.ID, FCMToken: *req.FCMToken}
+		if err := h.deviceRepo.Upsert(c.Request.Context(), device); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "update_failed",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, user.ToResponse())
 }
 
+// GetNotificationSettings handles getting the notification preference matrix
+// @Summary Get notification preferences
+// @Tags User
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.NotificationPreferencesResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /user/settings/notifications [get]
+func (h *UserHandler) GetNotificationSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	stored, err := h.preferenceRepo.GetByUserID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NotificationPreferencesResponse{
+		Preferences: models.NotificationPreferenceMatrix(userID.(uuid.UUID), stored),
+	})
+}
+
+// UpdateNotificationSettings handles updating per-type notification
+// preferences: channels, quiet hours and daily caps
+// @Summary Update notification preferences
+// @Tags User
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body models.NotificationPreferencesUpdateRequest true "Preference updates"
+// @Success 200 {object} models.NotificationPreferencesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /user/settings/notifications [put]
+func (h *UserHandler) UpdateNotificationSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	var req models.NotificationPreferencesUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	stored, err := h.preferenceRepo.GetByUserID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "fetch_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	matrix := models.NotificationPreferenceMatrix(userID.(uuid.UUID), stored)
+	byType := make(map[models.NotificationType]*models.NotificationPreference, len(matrix))
+	for _, preference := range matrix {
+		byType[preference.Type] = preference
+	}
+
+	var changed []*models.NotificationPreference
+	for _, update := range req.Preferences {
+		preference, ok := byType[update.Type]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_notification_type",
+				"message": models.ErrInvalidNotificationType.Error() + ": " + string(update.Type),
+			})
+			return
+		}
+
+		if err := preference.Apply(update); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_quiet_hours",
+				"message": err.Error(),
+			})
+			return
+		}
+		changed = append(changed, preference)
+	}
+
+	if err := h.preferenceRepo.UpsertAll(c.Request.Context(), changed); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "update_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NotificationPreferencesResponse{
+		Preferences: matrix,
+	})
+}
+
 // DeleteAccount handles user account deletion
 // @Summary Delete user account
 // @Tags User
@@ -213,3 +364,12 @@ func (h *UserHandler) DeleteAccount(c *gin.Context) {
 		"message": "Account deleted successfully",
 	})
 }
+
+// clearIfEmpty maps an empty string, which clients send to clear an optional
+// setting, to nil
+func clearIfEmpty(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
+}
@@ -53,8 +53,27 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens whose session was revoked from another device
+		if err := authService.ValidateSession(c.Request.Context(), claims, c.ClientIP()); err != nil {
+			status := http.StatusUnauthorized
+			message := "Session has been revoked"
+
+			if err != services.ErrSessionRevoked {
+				status = http.StatusInternalServerError
+				message = "Failed to validate session"
+			}
+
+			c.JSON(status, gin.H{
+				"error":   "unauthorized",
+				"message": message,
+			})
+			c.Abort()
+			return
+		}
+
 		// Set user info in context
 		c.Set("user_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
 		c.Set("firebase_uid", claims.FirebaseUID)
 		c.Set("email", claims.Email)
 
@@ -80,8 +99,12 @@ func OptionalAuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 		token := parts[1]
 
 		claims, err := authService.ValidateToken(token)
+		if err == nil {
+			err = authService.ValidateSession(c.Request.Context(), claims, c.ClientIP())
+		}
 		if err == nil {
 			c.Set("user_id", claims.UserID)
+			c.Set("session_id", claims.SessionID)
 			c.Set("firebase_uid", claims.FirebaseUID)
 			c.Set("email", claims.Email)
 		}
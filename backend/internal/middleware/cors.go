@@ -25,6 +25,7 @@ func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Accept, X-Requested-With")
+		c.Header("Access-Control-Expose-Headers", "Location, Retry-After, X-Regenerations-Limit, X-Regenerations-Remaining, X-Regenerations-Reset")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Max-Age", "86400")
 
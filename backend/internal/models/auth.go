@@ -9,13 +9,22 @@ import (
 // AuthRegisterRequest represents the registration request
 type AuthRegisterRequest struct {
 	FirebaseToken string  `json:"firebase_token" binding:"required"`
-	Email         string  `json:"email" binding:"required,email"`
+	Email         string  `json:"email" binding:"omitempty,email"`
 	DisplayName   *string `json:"display_name,omitempty"`
+	DeviceLabel   *string `json:"device_label,omitempty" binding:"omitempty,max=100"`
+	Platform      *string `json:"platform,omitempty" binding:"omitempty,max=20"`
 }
 
 // AuthLoginRequest represents the login request
 type AuthLoginRequest struct {
-	FirebaseToken string `json:"firebase_token" binding:"required"`
+	FirebaseToken string  `json:"firebase_token" binding:"required"`
+	DeviceLabel   *string `json:"device_label,omitempty" binding:"omitempty,max=100"`
+	Platform      *string `json:"platform,omitempty" binding:"omitempty,max=20"`
+}
+
+// AuthLogoutRequest represents the logout request
+type AuthLogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // AuthRefreshRequest represents the token refresh request
@@ -32,9 +41,13 @@ type AuthTokenResponse struct {
 	User         *UserResponse `json:"user"`
 }
 
-// FCMTokenUpdateRequest represents the FCM token update request
+// FCMTokenUpdateRequest represents the FCM token update request. The token
+// is registered as one of the user's push devices.
 type FCMTokenUpdateRequest struct {
-	FCMToken string `json:"fcm_token" binding:"required"`
+	FCMToken   string  `json:"fcm_token" binding:"required"`
+	Platform   *string `json:"platform,omitempty" binding:"omitempty,oneof=android ios web"`
+	AppVersion *string `json:"app_version,omitempty" binding:"omitempty,max=30"`
+	Locale     *string `json:"locale,omitempty" binding:"omitempty,max=20"`
 }
 
 // TokenClaims represents JWT token claims
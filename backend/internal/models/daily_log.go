@@ -13,6 +13,7 @@ type DailyLog struct {
 	UserID       uuid.UUID  `json:"user_id"`
 	LogDate      time.Time  `json:"log_date"`
 	Completed    bool       `json:"completed"`
+	Value        *float64   `json:"value,omitempty"`
 	LearningNote *string    `json:"learning_note,omitempty"`
 	CompletedAt  *time.Time `json:"completed_at,omitempty"`
 	CreatedAt    time.Time  `json:"created_at"`
@@ -27,9 +28,16 @@ type DailyLogCreateRequest struct {
 	HabitID      uuid.UUID `json:"habit_id" binding:"required"`
 	LogDate      string    `json:"log_date" binding:"required"` // Format: YYYY-MM-DD
 	Completed    bool      `json:"completed"`
+	Value        *float64  `json:"value,omitempty" binding:"omitempty,gte=0"` // quantitative habits derive Completed from it
 	LearningNote *string   `json:"learning_note,omitempty"`
 }
 
+// DailyLogIncrementRequest represents partial progress on a quantitative habit
+type DailyLogIncrementRequest struct {
+	Amount  float64 `json:"amount" binding:"required,gt=0"`
+	LogDate *string `json:"log_date,omitempty" binding:"omitempty,datetime=2006-01-02"` // defaults to today
+}
+
 // DailyLogUpdateRequest represents the request body for updating a daily log
 type DailyLogUpdateRequest struct {
 	Completed    *bool   `json:"completed,omitempty"`
@@ -43,6 +51,7 @@ type DailyLogResponse struct {
 	HabitTitle   string     `json:"habit_title,omitempty"`
 	LogDate      string     `json:"log_date"`
 	Completed    bool       `json:"completed"`
+	Value        *float64   `json:"value,omitempty"`
 	LearningNote *string    `json:"learning_note,omitempty"`
 	CompletedAt  *time.Time `json:"completed_at,omitempty"`
 }
@@ -55,6 +64,7 @@ func (dl *DailyLog) ToResponse() *DailyLogResponse {
 		HabitTitle:   dl.HabitTitle,
 		LogDate:      dl.LogDate.Format("2006-01-02"),
 		Completed:    dl.Completed,
+		Value:        dl.Value,
 		LearningNote: dl.LearningNote,
 		CompletedAt:  dl.CompletedAt,
 	}
@@ -78,22 +88,43 @@ type TodayHabitStatus struct {
 	HabitTitle    string    `json:"habit_title"`
 	Category      string    `json:"category"`
 	IsLearning    bool      `json:"is_learning"`
+	Frequency     string    `json:"frequency"`
+	IsDue         bool      `json:"is_due"`
 	Completed     bool      `json:"completed"`
+	Value         *float64  `json:"value,omitempty"`
+	TargetValue   *float64  `json:"target_value,omitempty"`
+	Unit          *string   `json:"unit,omitempty"`
 	LearningNote  *string   `json:"learning_note,omitempty"`
 	CurrentStreak int       `json:"current_streak"`
 }
 
-// CalendarDayData represents data for a single day in calendar view
+// CalendarDayData represents data for a single day in calendar view.
+// TotalHabits counts only the habits due that day; FrozenCount counts the
+// habits skipped with a streak freeze and IsRestDay marks a vacation day.
 type CalendarDayData struct {
 	Date           string `json:"date"`
 	TotalHabits    int    `json:"total_habits"`
 	CompletedCount int    `json:"completed_count"`
 	Percentage     int    `json:"percentage"`
+	FrozenCount    int    `json:"frozen_count"`
+	IsRestDay      bool   `json:"is_rest_day"`
+}
+
+// HabitQuantitySummary totals a quantitative habit's logged values over a
+// period. AverageValue is per due day.
+type HabitQuantitySummary struct {
+	HabitID      uuid.UUID `json:"habit_id"`
+	HabitTitle   string    `json:"habit_title"`
+	Unit         *string   `json:"unit,omitempty"`
+	TargetValue  float64   `json:"target_value"`
+	TotalValue   float64   `json:"total_value"`
+	AverageValue float64   `json:"average_value"`
 }
 
 // CalendarMonthResponse represents calendar data for a month
 type CalendarMonthResponse struct {
-	Month string             `json:"month"`
-	Year  int                `json:"year"`
-	Days  []*CalendarDayData `json:"days"`
+	Month      string                  `json:"month"`
+	Year       int                     `json:"year"`
+	Days       []*CalendarDayData      `json:"days"`
+	Quantities []*HabitQuantitySummary `json:"quantities,omitempty"`
 }
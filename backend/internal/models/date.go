@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// DateLayout is the format of calendar dates exchanged with clients
+const DateLayout = "2006-01-02"
+
+// DateOf returns the calendar date of t in loc, as midnight UTC. Calendar
+// dates are stored in DATE columns, so they carry no timezone of their own.
+func DateOf(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// DaysBetween returns the number of calendar days from one date to another,
+// ignoring the time of day and the location of either value
+func DaysBetween(from, to time.Time) int {
+	fy, fm, fd := from.Date()
+	ty, tm, td := to.Date()
+	start := time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)
+	end := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// ClockLayout is the format of times of day exchanged with clients
+const ClockLayout = "15:04"
+
+// ParseClock parses a time of day given as HH:MM or HH:MM:SS, the latter
+// being how TIME columns are read back
+func ParseClock(clock string) (time.Time, error) {
+	if t, err := time.Parse("15:04:05", clock); err == nil {
+		return t, nil
+	}
+	return time.Parse(ClockLayout, clock)
+}
+
+// AtClock returns the moment the time of day clock occurs on date's
+// calendar day in loc
+func AtClock(date, clock time.Time, loc *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, loc)
+}
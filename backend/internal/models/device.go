@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserDevice is a device registered to receive push notifications. A token
+// belongs to one user at a time; registering it again moves it to the
+// user now signed in on the device.
+type UserDevice struct {
+	ID               uuid.UUID `json:"id"`
+	UserID           uuid.UUID `json:"user_id"`
+	FCMToken         string    `json:"fcm_token"`
+	Platform         *string   `json:"platform,omitempty"` // android, ios or web
+	AppVersion       *string   `json:"app_version,omitempty"`
+	Locale           *string   `json:"locale,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	LastRegisteredAt time.Time `json:"last_registered_at"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WeeklyDigest summarizes a user's Monday-Sunday week for the digest email
+type WeeklyDigest struct {
+	UserID        uuid.UUID      `json:"user_id"`
+	WeekStart     time.Time      `json:"week_start"`
+	WeekEnd       time.Time      `json:"week_end"`
+	Habits        []*HabitDigest `json:"habits"`
+	CompletedDays int            `json:"completed_days"`
+	DueDays       int            `json:"due_days"`
+	XPEarned      int            `json:"xp_earned"`
+}
+
+// CompletionPercent returns the share of the week's due days completed
+// across all habits, as a whole percentage
+func (d *WeeklyDigest) CompletionPercent() int {
+	return percentOf(d.CompletedDays, d.DueDays)
+}
+
+// HabitDigest is one habit's line in the weekly digest
+type HabitDigest struct {
+	HabitID       uuid.UUID `json:"habit_id"`
+	HabitTitle    string    `json:"habit_title"`
+	CompletedDays int       `json:"completed_days"`
+	DueDays       int       `json:"due_days"`
+	StreakBefore  int       `json:"streak_before"` // current streak as the week began
+	StreakAfter   int       `json:"streak_after"`  // current streak as the week ended
+}
+
+// CompletionPercent returns the share of the habit's due days completed,
+// as a whole percentage
+func (h *HabitDigest) CompletionPercent() int {
+	return percentOf(h.CompletedDays, h.DueDays)
+}
+
+// StreakChange returns how much the habit's streak grew, or shrank if
+// negative, over the week
+func (h *HabitDigest) StreakChange() int {
+	return h.StreakAfter - h.StreakBefore
+}
+
+// percentOf returns part as a rounded percentage of whole, or 0 if whole is 0
+func percentOf(part, whole int) int {
+	if whole == 0 {
+		return 0
+	}
+	return (200*part + whole) / (2 * whole)
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxStreakFreezes caps how many unused streak freezes a user can hold
+const MaxStreakFreezes = 5
+
+// StreakFreeze marks a day a habit was skipped using a freeze token.
+// Frozen days neither break nor extend the habit's streak.
+type StreakFreeze struct {
+	ID         uuid.UUID `json:"id"`
+	HabitID    uuid.UUID `json:"habit_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	FreezeDate time.Time `json:"freeze_date"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StreakFreezeRequest represents the request body for freezing a habit's day
+type StreakFreezeRequest struct {
+	Date *string `json:"date,omitempty" binding:"omitempty,datetime=2006-01-02"` // defaults to today
+}
+
+// StreakFreezeResponse is the API response after spending a freeze
+type StreakFreezeResponse struct {
+	HabitID          uuid.UUID       `json:"habit_id"`
+	FreezeDate       string          `json:"freeze_date"`
+	FreezesRemaining int             `json:"freezes_remaining"`
+	Streak           *StreakResponse `json:"streak"`
+}
+
+// Vacation is a user-declared range of rest days (inclusive) during which no
+// habit is due; rest days neither break nor extend streaks.
+type Vacation struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Note      *string   `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// VacationCreateRequest represents the request body for declaring rest days
+type VacationCreateRequest struct {
+	StartDate string  `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate   string  `json:"end_date" binding:"required,datetime=2006-01-02"`
+	Note      *string `json:"note,omitempty" binding:"omitempty,max=255"`
+}
+
+// VacationResponse is the API response for a vacation
+type VacationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	StartDate string    `json:"start_date"`
+	EndDate   string    `json:"end_date"`
+	Note      *string   `json:"note,omitempty"`
+}
+
+// ToResponse converts Vacation to VacationResponse
+func (v *Vacation) ToResponse() *VacationResponse {
+	return &VacationResponse{
+		ID:        v.ID,
+		StartDate: v.StartDate.Format(DateLayout),
+		EndDate:   v.EndDate.Format(DateLayout),
+		Note:      v.Note,
+	}
+}
+
+// VacationListResponse wraps a list of vacations
+type VacationListResponse struct {
+	Vacations  []*VacationResponse `json:"vacations"`
+	TotalCount int                 `json:"total_count"`
+}
+
+// RestDays expands vacations into the set of rest dates between from and to
+// (inclusive)
+func RestDays(vacations []*Vacation, from, to time.Time) map[time.Time]bool {
+	rest := make(map[time.Time]bool)
+	for _, vacation := range vacations {
+		start, end := vacation.StartDate, vacation.EndDate
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+			rest[date] = true
+		}
+	}
+	return rest
+}
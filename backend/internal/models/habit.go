@@ -20,8 +20,12 @@ const (
 type HabitFrequency string
 
 const (
-	FrequencyDaily  HabitFrequency = "daily"
-	FrequencyWeekly HabitFrequency = "weekly"
+	FrequencyDaily        HabitFrequency = "daily"
+	FrequencyWeekly       HabitFrequency = "weekly" // once per week, any day
+	FrequencySpecificDays HabitFrequency = "specific_days"
+	FrequencyTimesPerWeek HabitFrequency = "times_per_week"
+	FrequencyEveryNDays   HabitFrequency = "every_n_days"
+	FrequencyMonthly      HabitFrequency = "monthly"
 )
 
 // Habit represents a habit in the system
@@ -32,6 +36,9 @@ type Habit struct {
 	Description     *string        `json:"description,omitempty"`
 	Category        HabitCategory  `json:"category"`
 	Frequency       HabitFrequency `json:"frequency"`
+	Schedule        *HabitSchedule `json:"schedule,omitempty"`
+	TargetValue     *float64       `json:"target_value,omitempty"`
+	Unit            *string        `json:"unit,omitempty"`
 	IsActive        bool           `json:"is_active"`
 	IsLearningHabit bool           `json:"is_learning_habit"`
 	Color           string         `json:"color"`
@@ -45,6 +52,11 @@ type Habit struct {
 	CurrentStreak int `json:"current_streak,omitempty"`
 	LongestStreak int `json:"longest_streak,omitempty"`
 	TodayCompleted bool `json:"today_completed,omitempty"`
+
+	// Only loaded when scheduling reminders
+	ReminderSnoozedUntil *time.Time `json:"-"`
+	ReminderSnoozedAt    *time.Time `json:"-"` // when the snooze was made
+	LearnedReminderTime  *string    `json:"-"` // nudge before the learned completion window
 }
 
 // HabitCreateRequest represents the request body for creating a habit
@@ -52,7 +64,10 @@ type HabitCreateRequest struct {
 	Title           string         `json:"title" binding:"required,min=1,max=255"`
 	Description     *string        `json:"description,omitempty"`
 	Category        HabitCategory  `json:"category" binding:"omitempty,oneof=learning health productivity personal"`
-	Frequency       HabitFrequency `json:"frequency" binding:"omitempty,oneof=daily weekly"`
+	Frequency       HabitFrequency `json:"frequency" binding:"omitempty,oneof=daily weekly specific_days times_per_week every_n_days monthly"`
+	Schedule        *HabitSchedule `json:"schedule,omitempty"`
+	TargetValue     *float64       `json:"target_value,omitempty" binding:"omitempty,gt=0"`
+	Unit            *string        `json:"unit,omitempty" binding:"omitempty,max=20"`
 	IsLearningHabit bool           `json:"is_learning_habit"`
 	Color           string         `json:"color" binding:"omitempty,hexcolor"`
 	Icon            string         `json:"icon" binding:"omitempty,max=50"`
@@ -64,7 +79,10 @@ type HabitUpdateRequest struct {
 	Title           *string         `json:"title,omitempty" binding:"omitempty,min=1,max=255"`
 	Description     *string         `json:"description,omitempty"`
 	Category        *HabitCategory  `json:"category,omitempty" binding:"omitempty,oneof=learning health productivity personal"`
-	Frequency       *HabitFrequency `json:"frequency,omitempty" binding:"omitempty,oneof=daily weekly"`
+	Frequency       *HabitFrequency `json:"frequency,omitempty" binding:"omitempty,oneof=daily weekly specific_days times_per_week every_n_days monthly"`
+	Schedule        *HabitSchedule  `json:"schedule,omitempty"`
+	TargetValue     *float64        `json:"target_value,omitempty" binding:"omitempty,gt=0"`
+	Unit            *string         `json:"unit,omitempty" binding:"omitempty,max=20"`
 	IsActive        *bool           `json:"is_active,omitempty"`
 	IsLearningHabit *bool           `json:"is_learning_habit,omitempty"`
 	Color           *string         `json:"color,omitempty" binding:"omitempty,hexcolor"`
@@ -79,6 +97,9 @@ type HabitResponse struct {
 	Description     *string        `json:"description,omitempty"`
 	Category        HabitCategory  `json:"category"`
 	Frequency       HabitFrequency `json:"frequency"`
+	Schedule        *HabitSchedule `json:"schedule,omitempty"`
+	TargetValue     *float64       `json:"target_value,omitempty"`
+	Unit            *string        `json:"unit,omitempty"`
 	IsActive        bool           `json:"is_active"`
 	IsLearningHabit bool           `json:"is_learning_habit"`
 	Color           string         `json:"color"`
@@ -98,6 +119,9 @@ func (h *Habit) ToResponse() *HabitResponse {
 		Description:     h.Description,
 		Category:        h.Category,
 		Frequency:       h.Frequency,
+		Schedule:        h.Schedule,
+		TargetValue:     h.TargetValue,
+		Unit:            h.Unit,
 		IsActive:        h.IsActive,
 		IsLearningHabit: h.IsLearningHabit,
 		Color:           h.Color,
@@ -110,8 +134,19 @@ func (h *Habit) ToResponse() *HabitResponse {
 	}
 }
 
+// IsQuantitative reports whether the habit is measured against a target value
+func (h *Habit) IsQuantitative() bool {
+	return h.TargetValue != nil
+}
+
 // HabitListResponse wraps a list of habits
 type HabitListResponse struct {
 	Habits     []*HabitResponse `json:"habits"`
 	TotalCount int              `json:"total_count"`
 }
+
+// HabitSnoozeResponse is the API response for snoozing a habit's reminder
+type HabitSnoozeResponse struct {
+	HabitID      uuid.UUID `json:"habit_id"`
+	SnoozedUntil time.Time `json:"snoozed_until"`
+}
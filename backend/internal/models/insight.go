@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MinCompletionSamples is how many completions a habit needs before its
+	// completion window is trusted over the configured reminder times
+	MinCompletionSamples = 5
+	// CompletionLookbackDays is how far back completion times are considered
+	CompletionLookbackDays = 60
+	// NudgeLeadMinutes is how long before the completion window a nudge goes out
+	NudgeLeadMinutes = 20
+)
+
+// minutesPerDay is the number of minutes in a day on a wall clock
+const minutesPerDay = 24 * 60
+
+// ReminderSource tells where the time a habit's reminder goes out came from
+type ReminderSource string
+
+const (
+	ReminderSourceLearned    ReminderSource = "learned"    // shortly before the completion window
+	ReminderSourceConfigured ReminderSource = "configured" // the habit's reminder time
+	ReminderSourceNone       ReminderSource = "none"       // no per-habit reminder
+)
+
+// CompletionWindow is the time of day a user typically completes a habit,
+// learned from the completion times of their logs. The window and nudge
+// are nil while the history is too thin.
+type CompletionWindow struct {
+	HabitID     uuid.UUID `json:"habit_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	WindowStart *string   `json:"window_start,omitempty"` // HH:MM in the user's timezone
+	WindowEnd   *string   `json:"window_end,omitempty"`
+	TypicalTime *string   `json:"typical_time,omitempty"`
+	NudgeTime   *string   `json:"nudge_time,omitempty"`
+	SampleSize  int       `json:"sample_size"`
+	ComputedAt  time.Time `json:"computed_at"`
+}
+
+// CompletionWindowResponse is the learned window in API responses
+type CompletionWindowResponse struct {
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Typical string `json:"typical"`
+}
+
+// HabitInsightsResponse explains when a habit is usually completed and when
+// its reminder goes out
+type HabitInsightsResponse struct {
+	HabitID          uuid.UUID                 `json:"habit_id"`
+	SampleSize       int                       `json:"sample_size"`
+	MinSamples       int                       `json:"min_samples"`
+	LookbackDays     int                       `json:"lookback_days"`
+	CompletionWindow *CompletionWindowResponse `json:"completion_window,omitempty"`
+	ReminderTime     *string                   `json:"reminder_time,omitempty"`
+	ReminderSource   ReminderSource            `json:"reminder_source"`
+	Explanation      string                    `json:"explanation"`
+	ComputedAt       time.Time                 `json:"computed_at"`
+}
+
+// NewCompletionWindow learns a habit's completion window from the local
+// minutes of the day its completions happened at. The window is the
+// shortest span of the clock holding 60% of the completions, so a few
+// outliers do not stretch it, and it may run past midnight.
+func NewCompletionWindow(habitID, userID uuid.UUID, minutes []int) *CompletionWindow {
+	window := &CompletionWindow{
+		HabitID:    habitID,
+		UserID:     userID,
+		SampleSize: len(minutes),
+		ComputedAt: time.Now(),
+	}
+
+	n := len(minutes)
+	if n < MinCompletionSamples {
+		return window
+	}
+
+	sorted := make([]int, n, 2*n)
+	copy(sorted, minutes)
+	sort.Ints(sorted)
+
+	// Unroll the clock once so spans can wrap past midnight
+	for _, minute := range sorted[:n] {
+		sorted = append(sorted, minute+minutesPerDay)
+	}
+
+	size := (3*n + 4) / 5
+	best := 0
+	for i := 1; i < n; i++ {
+		if sorted[i+size-1]-sorted[i] < sorted[best+size-1]-sorted[best] {
+			best = i
+		}
+	}
+
+	start := formatMinutes(sorted[best])
+	end := formatMinutes(sorted[best+size-1])
+	typical := formatMinutes(sorted[best+(size-1)/2])
+	nudge := formatMinutes(sorted[best] - NudgeLeadMinutes)
+
+	window.WindowStart = &start
+	window.WindowEnd = &end
+	window.TypicalTime = &typical
+	window.NudgeTime = &nudge
+
+	return window
+}
+
+// MinuteOfDay returns how many minutes past local midnight t is in loc
+func MinuteOfDay(t time.Time, loc *time.Location) int {
+	local := t.In(loc)
+	return local.Hour()*60 + local.Minute()
+}
+
+// formatMinutes formats minutes past midnight, wrapping around the clock,
+// as HH:MM
+func formatMinutes(minutes int) string {
+	minutes = ((minutes % minutesPerDay) + minutesPerDay) % minutesPerDay
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
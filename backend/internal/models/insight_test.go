@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewCompletionWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		minutes []int
+		start   string
+		end     string
+		typical string
+		nudge   string
+	}{
+		{
+			name:    "morning with evening outliers",
+			minutes: []int{480, 490, 500, 510, 1200, 1260},
+			start:   "08:00", end: "08:30", typical: "08:10", nudge: "07:40",
+		},
+		{
+			name:    "across midnight",
+			minutes: []int{1430, 0, 10, 600, 900},
+			start:   "23:50", end: "00:10", typical: "00:00", nudge: "23:30",
+		},
+		{
+			name:    "nudge before midnight",
+			minutes: []int{30, 10, 25, 20, 15},
+			start:   "00:10", end: "00:20", typical: "00:15", nudge: "23:50",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := NewCompletionWindow(uuid.New(), uuid.New(), tt.minutes)
+			if window.SampleSize != len(tt.minutes) {
+				t.Errorf("SampleSize = %d; want %d", window.SampleSize, len(tt.minutes))
+			}
+			if window.NudgeTime == nil {
+				t.Fatal("no window learned")
+			}
+
+			got := [4]string{*window.WindowStart, *window.WindowEnd, *window.TypicalTime, *window.NudgeTime}
+			want := [4]string{tt.start, tt.end, tt.typical, tt.nudge}
+			if got != want {
+				t.Errorf("start, end, typical, nudge = %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNewCompletionWindowTooFewSamples(t *testing.T) {
+	window := NewCompletionWindow(uuid.New(), uuid.New(), []int{480, 490, 500, 510})
+
+	if window.SampleSize != 4 {
+		t.Errorf("SampleSize = %d; want 4", window.SampleSize)
+	}
+	if window.WindowStart != nil || window.WindowEnd != nil || window.TypicalTime != nil || window.NudgeTime != nil {
+		t.Error("learned a window from fewer than MinCompletionSamples completions")
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobRunStatus represents the outcome of a background job run
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed"
+)
+
+// JobRun records one run of a scheduled background job. Each run covers the
+// window (WindowStart, WindowEnd] so events due while no replica was leading
+// are picked up by the next run.
+type JobRun struct {
+	ID             uuid.UUID    `json:"id"`
+	JobName        string       `json:"job_name"`
+	Instance       string       `json:"instance"`
+	Status         JobRunStatus `json:"status"`
+	WindowStart    time.Time    `json:"window_start"`
+	WindowEnd      time.Time    `json:"window_end"`
+	ItemsProcessed int          `json:"items_processed"`
+	Error          *string      `json:"error,omitempty"`
+	StartedAt      time.Time    `json:"started_at"`
+	FinishedAt     *time.Time   `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LLMCall records one request to a language model, for cost and latency
+// accounting. Token counts come from the provider's usage metadata and are
+// zero when it reports none.
+type LLMCall struct {
+	ID             uuid.UUID `json:"id"`
+	UserID         uuid.UUID `json:"user_id"`
+	Provider       string    `json:"provider"`
+	Model          string    `json:"model"`
+	Purpose        string    `json:"purpose"` // e.g. monthly_report
+	PromptTokens   int       `json:"prompt_tokens"`
+	ResponseTokens int       `json:"response_tokens"`
+	LatencyMs      int       `json:"latency_ms"`
+	Error          *string   `json:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
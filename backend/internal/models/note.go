@@ -0,0 +1,104 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// MaxNoteTagLength bounds the length of a note tag, in characters
+const MaxNoteTagLength = 50
+
+// noteTagPattern matches a #tag that starts a word, so URL fragments and
+// HTML entities such as &#39; are not taken for tags
+var noteTagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/])#([\p{L}\p{N}_-]+)`)
+
+// NoteTag is a #tag written in a user's learning notes
+type NoteTag struct {
+	Name     string `json:"name"`
+	LogCount int    `json:"log_count"`
+}
+
+// NoteSearchRequest is a learning note search as given in a request's query
+// string. A query or a tag is required.
+type NoteSearchRequest struct {
+	Query   string
+	HabitID string
+	From    string // YYYY-MM-DD
+	To      string // YYYY-MM-DD
+	Tag     string
+	Limit   int
+	Offset  int
+}
+
+// NoteSearchFilter narrows a learning note search. Query is in web search
+// syntax: words, "quoted phrases", OR and -excluded words.
+type NoteSearchFilter struct {
+	Query   string
+	HabitID *uuid.UUID
+	From    *time.Time
+	To      *time.Time
+	Tag     string
+	Limit   int
+	Offset  int
+}
+
+// NoteSearchResult is a learning note matching a search. The highlight is
+// the note's best matching fragments, HTML-escaped, with matches wrapped in
+// <mark> tags.
+type NoteSearchResult struct {
+	LogID        uuid.UUID `json:"log_id"`
+	HabitID      uuid.UUID `json:"habit_id"`
+	HabitTitle   string    `json:"habit_title"`
+	LogDate      string    `json:"log_date"`
+	LearningNote string    `json:"learning_note"`
+	Highlight    string    `json:"highlight"`
+	Tags         []string  `json:"tags"`
+	Rank         float64   `json:"rank"`
+}
+
+// NoteSearchResponse is the API response for a learning note search
+type NoteSearchResponse struct {
+	Results    []*NoteSearchResult `json:"results"`
+	TotalCount int                 `json:"total_count"` // matches before paging
+}
+
+// NoteTagListResponse wraps a user's note tags
+type NoteTagListResponse struct {
+	Tags []*NoteTag `json:"tags"`
+}
+
+// NormalizeNoteTag lowercases a tag and strips its # and surrounding dashes
+// and underscores. It returns "" for tags that are empty or only digits,
+// such as issue numbers.
+func NormalizeNoteTag(tag string) string {
+	tag = strings.Trim(strings.ToLower(strings.TrimPrefix(tag, "#")), "-_")
+	if utf8.RuneCountInString(tag) > MaxNoteTagLength {
+		tag = string([]rune(tag)[:MaxNoteTagLength])
+	}
+
+	if strings.IndexFunc(tag, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+	return tag
+}
+
+// ExtractNoteTags returns the distinct normalized #tags of a note, in the
+// order they first appear
+func ExtractNoteTags(note string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, match := range noteTagPattern.FindAllStringSubmatch(note, -1) {
+		tag := NormalizeNoteTag(match[1])
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
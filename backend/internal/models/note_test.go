@@ -0,0 +1,59 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractNoteTags(t *testing.T) {
+	long := strings.Repeat("a", MaxNoteTagLength+10)
+
+	tests := []struct {
+		name string
+		note string
+		want []string
+	}{
+		{"none", "Read two chapters today", nil},
+		{"start of note", "#golang channels", []string{"golang"}},
+		{"order of first appearance", "Worked on #sql, then #Go and #SQL again", []string{"sql", "go"}},
+		{"dashes and underscores", "#go-routines and #_private_ and #--", []string{"go-routines", "private"}},
+		{"after punctuation", "(#parens) [#brackets] \"#quoted\"", []string{"parens", "brackets", "quoted"}},
+		{"unicode", "#日本語 と #café", []string{"日本語", "café"}},
+		{"numbers only", "Fixed issue #42 and #2026", nil},
+		{"numbers and letters", "#100days of #code", []string{"100days", "code"}},
+		{"inside words", "C# and foo#bar", nil},
+		{"URL fragments", "See https://example.com/docs#intro and example.com/#top", nil},
+		{"HTML entities", "it&#39;s and &#x27;", nil},
+		{"truncated", "#" + long, []string{long[:MaxNoteTagLength]}},
+		{"multiline", "first line\n#second line\n\t#third", []string{"second", "third"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractNoteTags(tt.note); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractNoteTags(%q) = %q; want %q", tt.note, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeNoteTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"#Go", "go"},
+		{"go", "go"},
+		{"-go_", "go"},
+		{"#42", ""},
+		{"#", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeNoteTag(tt.tag); got != tt.want {
+			t.Errorf("NormalizeNoteTag(%q) = %q; want %q", tt.tag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationType represents the type of notification
+type NotificationType string
+
+const (
+	NotificationTypeMorningReminder  NotificationType = "morning_reminder"
+	NotificationTypeEveningReminder  NotificationType = "evening_reminder"
+	NotificationTypeHabitReminder    NotificationType = "habit_reminder"
+	NotificationTypeStreakAlert      NotificationType = "streak_alert"
+	NotificationTypeReportReady      NotificationType = "report_ready"
+	NotificationTypeRevisionReminder NotificationType = "revision_reminder"
+	NotificationTypeWeeklyDigest     NotificationType = "weekly_digest"
+)
+
+// TimeSensitive reports whether a notification is only worth pushing at the
+// moment it was meant for, so it is not held back until quiet hours end
+func (t NotificationType) TimeSensitive() bool {
+	switch t {
+	case NotificationTypeMorningReminder, NotificationTypeEveningReminder,
+		NotificationTypeHabitReminder, NotificationTypeStreakAlert:
+		return true
+	}
+	return false
+}
+
+// NotificationStatus represents where a notification is in push or email
+// delivery
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending" // waiting in the outbox
+	NotificationSending NotificationStatus = "sending" // claimed by a worker
+	NotificationSent    NotificationStatus = "sent"    // reached at least one device, or the mail server
+	NotificationSkipped NotificationStatus = "skipped" // channel disabled, no devices or quiet hours
+	NotificationFailed  NotificationStatus = "failed"  // gave up after retries
+)
+
+// Notification is a notification for a user. It is written to the outbox
+// before any push or email is attempted and doubles as the in-app inbox
+// entry. Push and email are delivered independently, each with its own
+// status and retries.
+type Notification struct {
+	ID            uuid.UUID          `json:"id"`
+	UserID        uuid.UUID          `json:"user_id"`
+	Type          NotificationType   `json:"type"`
+	Title         string             `json:"title"`
+	Body          string             `json:"body"`
+	Data          map[string]string  `json:"data,omitempty"`
+	ScheduledFor  time.Time          `json:"scheduled_for"`
+	Push          bool               `json:"push"`   // deliver to the user's devices
+	Email         bool               `json:"email"`  // deliver by email
+	InApp         bool               `json:"in_app"` // show in the inbox
+	Status        NotificationStatus `json:"status"`
+	Attempts      int                `json:"attempts"`
+	NextAttemptAt *time.Time         `json:"next_attempt_at,omitempty"` // set while backing off after a failure
+	LastError     *string            `json:"last_error,omitempty"`
+	SentAt        *time.Time         `json:"sent_at,omitempty"`
+	ReadAt        *time.Time         `json:"read_at,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+
+	// OccurrenceKey identifies the scheduled occurrence a notification was
+	// queued for, e.g. a habit's reminder on a date. A user gets at most one
+	// notification per key, so job runs replayed after a failure do not
+	// queue it twice.
+	OccurrenceKey *string `json:"-"`
+
+	// Email delivery; EmailStatus is nil unless Email is set
+	EmailStatus        *NotificationStatus `json:"email_status,omitempty"`
+	EmailAttempts      int                 `json:"email_attempts"`
+	EmailNextAttemptAt *time.Time          `json:"email_next_attempt_at,omitempty"`
+	EmailError         *string             `json:"email_error,omitempty"`
+	EmailSentAt        *time.Time          `json:"email_sent_at,omitempty"`
+}
+
+// NotificationOccurrence builds the occurrence key of a notification type
+// from the parts that identify one occurrence of it
+func NotificationOccurrence(notificationType NotificationType, parts ...string) string {
+	return string(notificationType) + ":" + strings.Join(parts, ":")
+}
+
+// NotificationResponse is the API response for an inbox entry
+type NotificationResponse struct {
+	ID           uuid.UUID         `json:"id"`
+	Type         NotificationType  `json:"type"`
+	Title        string            `json:"title"`
+	Body         string            `json:"body"`
+	Data         map[string]string `json:"data,omitempty"`
+	Read         bool              `json:"read"`
+	ReadAt       *time.Time        `json:"read_at,omitempty"`
+	ScheduledFor time.Time         `json:"scheduled_for"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// ToResponse converts Notification to NotificationResponse
+func (n *Notification) ToResponse() *NotificationResponse {
+	return &NotificationResponse{
+		ID:           n.ID,
+		Type:         n.Type,
+		Title:        n.Title,
+		Body:         n.Body,
+		Data:         n.Data,
+		Read:         n.ReadAt != nil,
+		ReadAt:       n.ReadAt,
+		ScheduledFor: n.ScheduledFor,
+		CreatedAt:    n.CreatedAt,
+	}
+}
+
+// NotificationListResponse wraps a page of the inbox
+type NotificationListResponse struct {
+	Notifications []*NotificationResponse `json:"notifications"`
+	TotalCount    int                     `json:"total_count"`
+	UnreadCount   int                     `json:"unread_count"`
+}
+
+// UnreadCountResponse reports how many inbox entries are unread
+type UnreadCountResponse struct {
+	UnreadCount int `json:"unread_count"`
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidNotificationType = errors.New("unknown notification type")
+)
+
+// NotificationTypes lists every notification type, in the order preferences
+// are shown
+var NotificationTypes = []NotificationType{
+	NotificationTypeMorningReminder,
+	NotificationTypeEveningReminder,
+	NotificationTypeHabitReminder,
+	NotificationTypeStreakAlert,
+	NotificationTypeReportReady,
+	NotificationTypeRevisionReminder,
+	NotificationTypeWeeklyDigest,
+}
+
+// NotificationPreference is how a user wants one type of notification
+// delivered: on which channels, outside which quiet hours and how often
+type NotificationPreference struct {
+	UserID          uuid.UUID        `json:"user_id"`
+	Type            NotificationType `json:"type"`
+	Push            bool             `json:"push"`
+	Email           bool             `json:"email"`
+	InApp           bool             `json:"in_app"`
+	QuietHoursStart *string          `json:"quiet_hours_start,omitempty"` // overrides the user's quiet hours
+	QuietHoursEnd   *string          `json:"quiet_hours_end,omitempty"`
+	MaxPerDay       *int             `json:"max_per_day,omitempty"` // nil means no cap
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// DefaultNotificationPreference returns the preference used for a type the
+// user never configured: push and in-app, no email, no cap. The weekly
+// digest is an email and goes to the inbox instead of being pushed.
+func DefaultNotificationPreference(userID uuid.UUID, notificationType NotificationType) *NotificationPreference {
+	if notificationType == NotificationTypeWeeklyDigest {
+		return &NotificationPreference{
+			UserID: userID,
+			Type:   notificationType,
+			Email:  true,
+			InApp:  true,
+		}
+	}
+
+	return &NotificationPreference{
+		UserID: userID,
+		Type:   notificationType,
+		Push:   true,
+		InApp:  true,
+	}
+}
+
+// NotificationPreferenceMatrix fills in defaults for the types missing from
+// stored, returning one preference per type
+func NotificationPreferenceMatrix(userID uuid.UUID, stored []*NotificationPreference) []*NotificationPreference {
+	byType := make(map[NotificationType]*NotificationPreference, len(stored))
+	for _, preference := range stored {
+		byType[preference.Type] = preference
+	}
+
+	matrix := make([]*NotificationPreference, 0, len(NotificationTypes))
+	for _, notificationType := range NotificationTypes {
+		if preference, ok := byType[notificationType]; ok {
+			matrix = append(matrix, preference)
+			continue
+		}
+		matrix = append(matrix, DefaultNotificationPreference(userID, notificationType))
+	}
+
+	return matrix
+}
+
+// Muted reports whether the type is delivered on no channel at all
+func (p *NotificationPreference) Muted() bool {
+	return !p.Push && !p.Email && !p.InApp
+}
+
+// HasQuietHours reports whether the type has quiet hours of its own
+func (p *NotificationPreference) HasQuietHours() bool {
+	return p.QuietHoursStart != nil && p.QuietHoursEnd != nil
+}
+
+// QuietHoursAt reports whether t falls within the type's own quiet hours in
+// loc and, if so, when they end
+func (p *NotificationPreference) QuietHoursAt(t time.Time, loc *time.Location) (time.Time, bool) {
+	return quietHoursAt(p.QuietHoursStart, p.QuietHoursEnd, t, loc)
+}
+
+// Apply merges an update into the preference and validates the result
+func (p *NotificationPreference) Apply(update *NotificationPreferenceUpdate) error {
+	if update.Push != nil {
+		p.Push = *update.Push
+	}
+	if update.Email != nil {
+		p.Email = *update.Email
+	}
+	if update.InApp != nil {
+		p.InApp = *update.InApp
+	}
+	if update.QuietHoursStart != nil {
+		p.QuietHoursStart = update.QuietHoursStart
+		if *update.QuietHoursStart == "" {
+			p.QuietHoursStart = nil
+		}
+	}
+	if update.QuietHoursEnd != nil {
+		p.QuietHoursEnd = update.QuietHoursEnd
+		if *update.QuietHoursEnd == "" {
+			p.QuietHoursEnd = nil
+		}
+	}
+	if update.MaxPerDay != nil {
+		p.MaxPerDay = update.MaxPerDay
+		if *update.MaxPerDay == 0 {
+			p.MaxPerDay = nil
+		}
+	}
+
+	return validateQuietHours(p.QuietHoursStart, p.QuietHoursEnd)
+}
+
+// NotificationPreferenceUpdate changes one type's preference. Omitted
+// fields are left as they are; an empty quiet hours time clears them and a
+// max_per_day of 0 removes the cap.
+type NotificationPreferenceUpdate struct {
+	Type            NotificationType `json:"type" binding:"required"`
+	Push            *bool            `json:"push,omitempty"`
+	Email           *bool            `json:"email,omitempty"`
+	InApp           *bool            `json:"in_app,omitempty"`
+	QuietHoursStart *string          `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string          `json:"quiet_hours_end,omitempty"`
+	MaxPerDay       *int             `json:"max_per_day,omitempty" binding:"omitempty,min=0,max=50"`
+}
+
+// NotificationPreferencesUpdateRequest represents the request body for
+// updating notification preferences
+type NotificationPreferencesUpdateRequest struct {
+	Preferences []*NotificationPreferenceUpdate `json:"preferences" binding:"required,min=1,dive"`
+}
+
+// NotificationPreferencesResponse is the API response for the preference
+// matrix, with one entry per notification type
+type NotificationPreferencesResponse struct {
+	Preferences []*NotificationPreference `json:"preferences"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// validateQuietHours checks that quiet hours are either off or have a valid
+// start and end
+func validateQuietHours(start, end *string) error {
+	if start == nil && end == nil {
+		return nil
+	}
+	if start == nil || end == nil {
+		return ErrInvalidQuietHours
+	}
+	if _, err := ParseClock(*start); err != nil {
+		return ErrInvalidQuietHours
+	}
+	if _, err := ParseClock(*end); err != nil {
+		return ErrInvalidQuietHours
+	}
+	return nil
+}
+
+// quietHoursAt reports whether t falls within the quiet hours from start to
+// end in loc and, if so, when they end
+func quietHoursAt(start, end *string, t time.Time, loc *time.Location) (time.Time, bool) {
+	if start == nil || end == nil {
+		return time.Time{}, false
+	}
+
+	from, err := ParseClock(*start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	until, err := ParseClock(*end)
+	if err != nil || from.Equal(until) {
+		return time.Time{}, false
+	}
+
+	local := t.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	startsAt := AtClock(today, from, loc)
+	endsAt := AtClock(today, until, loc)
+
+	switch {
+	case !startsAt.Before(endsAt) && !local.Before(startsAt):
+		// Started this evening, ends tomorrow
+		return AtClock(today.AddDate(0, 0, 1), until, loc), true
+	case !startsAt.Before(endsAt) && local.Before(endsAt):
+		// Started yesterday evening
+		return endsAt, true
+	case startsAt.Before(endsAt) && !local.Before(startsAt) && local.Before(endsAt):
+		return endsAt, true
+	}
+
+	return time.Time{}, false
+}
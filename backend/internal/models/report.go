@@ -2,21 +2,77 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// Report represents a monthly AI-generated report
+// Report represents an AI-generated report of a week, month, quarter or year
 type Report struct {
-	ID                        uuid.UUID       `json:"id"`
-	UserID                    uuid.UUID       `json:"user_id"`
-	ReportMonth               time.Time       `json:"report_month"`
-	ReportContent             json.RawMessage `json:"report_content"`
-	SkillsLearned             []string        `json:"skills_learned"`
-	HabitsCompletedPercentage json.RawMessage `json:"habits_completed_percentage"`
-	RevisionSuggestions       json.RawMessage `json:"revision_suggestions"`
-	GeneratedAt               time.Time       `json:"generated_at"`
+	ID                        uuid.UUID        `json:"id"`
+	UserID                    uuid.UUID        `json:"user_id"`
+	PeriodType                ReportPeriodType `json:"period_type"`
+	PeriodStart               time.Time        `json:"period_start"`
+	PeriodEnd                 time.Time        `json:"period_end"`
+	ReportContent             json.RawMessage  `json:"report_content"`
+	SkillsLearned             []string         `json:"skills_learned"`
+	HabitsCompletedPercentage json.RawMessage  `json:"habits_completed_percentage"`
+	RevisionSuggestions       json.RawMessage  `json:"revision_suggestions"`
+	GenerationSource          ReportSource     `json:"generation_source"`
+	GenerationModel           string           `json:"generation_model"`
+	GenerationAttempts        int              `json:"generation_attempts"` // model calls, including repairs
+	GeneratedAt               time.Time        `json:"generated_at"`
+}
+
+// Period returns the period the report covers
+func (r *Report) Period() ReportPeriod {
+	return ReportPeriod{Type: r.PeriodType, Start: r.PeriodStart}
+}
+
+// ReportSource tells what wrote a report's content
+type ReportSource string
+
+const (
+	ReportSourceModel    ReportSource = "model"    // the configured language model
+	ReportSourceMock     ReportSource = "mock"     // the mock generator, as configured
+	ReportSourceFallback ReportSource = "fallback" // the mock generator, after the model failed
+	ReportSourceCache    ReportSource = "cache"    // the model, for identical input earlier
+	ReportSourceUnknown  ReportSource = "unknown"  // generated before sources were recorded
+)
+
+// ReportGeneration is freshly generated report content and how it was made
+type ReportGeneration struct {
+	Content  *ReportContent
+	Source   ReportSource
+	Model    string
+	Attempts int
+	Calls    []*LLMCall // model calls made, for usage accounting
+}
+
+// Bounds of valid report content, also given to models as a response schema
+const (
+	MaxReportSummaryLength  = 1000
+	MaxReportNoteLength     = 500
+	MaxReportImprovements   = 6
+	MaxReportSkillsLearned  = 10
+	MaxReportAreasToImprove = 5
+	MaxReportRevisions      = 5
+	MinRevisionDurationDays = 1
+	MaxRevisionDurationDays = 90
+	MinRevisionDailyMinutes = 5
+	MaxRevisionDailyMinutes = 240
+)
+
+// ReportValidationError lists what is wrong with generated report content
+type ReportValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *ReportValidationError) Error() string {
+	return "invalid report content: " + strings.Join(e.Problems, "; ")
 }
 
 // ReportContent represents the structure of AI-generated report content
@@ -27,6 +83,73 @@ type ReportContent struct {
 	AreasToImprove      []string             `json:"areas_to_improve"`
 	RevisionSuggestions []RevisionSuggestion `json:"revision_suggestions"`
 	MotivationalNote    string               `json:"motivational_note"`
+
+	// Computed from the habit data rather than generated; missing from
+	// reports generated before comparisons were added
+	Comparison *ReportComparison `json:"comparison,omitempty"`
+}
+
+// Validate checks generated content against the report bounds and returns
+// a *ReportValidationError listing every problem found
+func (c *ReportContent) Validate() error {
+	var problems []string
+
+	if strings.TrimSpace(c.Summary) == "" {
+		problems = append(problems, "summary is empty")
+	} else if len(c.Summary) > MaxReportSummaryLength {
+		problems = append(problems, fmt.Sprintf("summary is longer than %d characters", MaxReportSummaryLength))
+	}
+	if strings.TrimSpace(c.MotivationalNote) == "" {
+		problems = append(problems, "motivational_note is empty")
+	} else if len(c.MotivationalNote) > MaxReportNoteLength {
+		problems = append(problems, fmt.Sprintf("motivational_note is longer than %d characters", MaxReportNoteLength))
+	}
+
+	problems = append(problems, validateReportList("improvements", c.Improvements, 1, MaxReportImprovements)...)
+	problems = append(problems, validateReportList("skills_learned", c.SkillsLearned, 0, MaxReportSkillsLearned)...)
+	problems = append(problems, validateReportList("areas_to_improve", c.AreasToImprove, 0, MaxReportAreasToImprove)...)
+
+	if len(c.RevisionSuggestions) > MaxReportRevisions {
+		problems = append(problems, fmt.Sprintf("revision_suggestions has more than %d items", MaxReportRevisions))
+	}
+	for i, suggestion := range c.RevisionSuggestions {
+		if strings.TrimSpace(suggestion.Skill) == "" {
+			problems = append(problems, fmt.Sprintf("revision_suggestions[%d].skill is empty", i))
+		}
+		if suggestion.SuggestedDurationDays < MinRevisionDurationDays || suggestion.SuggestedDurationDays > MaxRevisionDurationDays {
+			problems = append(problems, fmt.Sprintf("revision_suggestions[%d].suggested_duration_days must be between %d and %d",
+				i, MinRevisionDurationDays, MaxRevisionDurationDays))
+		}
+		if suggestion.DailyMinutes < MinRevisionDailyMinutes || suggestion.DailyMinutes > MaxRevisionDailyMinutes {
+			problems = append(problems, fmt.Sprintf("revision_suggestions[%d].daily_minutes must be between %d and %d",
+				i, MinRevisionDailyMinutes, MaxRevisionDailyMinutes))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ReportValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateReportList checks a list of short texts for its length and for
+// empty or overlong entries
+func validateReportList(field string, items []string, minItems, maxItems int) []string {
+	var problems []string
+	if len(items) < minItems {
+		problems = append(problems, fmt.Sprintf("%s needs at least %d items", field, minItems))
+	}
+	if len(items) > maxItems {
+		problems = append(problems, fmt.Sprintf("%s has more than %d items", field, maxItems))
+	}
+	for i, item := range items {
+		if strings.TrimSpace(item) == "" {
+			problems = append(problems, fmt.Sprintf("%s[%d] is empty", field, i))
+		} else if len(item) > MaxReportNoteLength {
+			problems = append(problems, fmt.Sprintf("%s[%d] is longer than %d characters", field, i, MaxReportNoteLength))
+		}
+	}
+	return problems
 }
 
 // RevisionSuggestion represents an AI-suggested revision habit
@@ -42,27 +165,41 @@ type HabitCompletionData struct {
 	HabitID        uuid.UUID `json:"habit_id"`
 	HabitTitle     string    `json:"habit_title"`
 	Category       string    `json:"category"`
+	DueDays        int       `json:"due_days"`
+	Completions    int       `json:"completions"` // of due days
 	CompletionRate float64   `json:"completion_rate"`
-	Streak         int       `json:"streak"`
+	Streak         int       `json:"streak"`      // current streak
+	BestStreak     int       `json:"best_streak"` // longest run within the period
 	LearningNotes  []string  `json:"learning_notes"`
+
+	// Quantitative habits only
+	Unit         *string  `json:"unit,omitempty"`
+	TargetValue  *float64 `json:"target_value,omitempty"`
+	TotalValue   *float64 `json:"total_value,omitempty"`
+	AverageValue *float64 `json:"average_value,omitempty"`
 }
 
 // ReportGenerationInput represents input data for AI report generation
 type ReportGenerationInput struct {
 	UserID            uuid.UUID              `json:"user_id"`
-	Month             string                 `json:"month"` // Format: YYYY-MM
+	Period            ReportPeriod           `json:"period"`
 	Habits            []*HabitCompletionData `json:"habits"`
 	TotalHabits       int                    `json:"total_habits"`
 	OverallCompletion float64                `json:"overall_completion"`
+	Comparison        *ReportComparison      `json:"comparison"` // with the previous period
 }
 
 // ReportResponse is the API response for report data
 type ReportResponse struct {
-	ID            uuid.UUID      `json:"id"`
-	ReportMonth   string         `json:"report_month"`
-	Content       *ReportContent `json:"content"`
-	SkillsLearned []string       `json:"skills_learned"`
-	GeneratedAt   time.Time      `json:"generated_at"`
+	ID            uuid.UUID        `json:"id"`
+	PeriodType    ReportPeriodType `json:"period_type"`
+	Period        string           `json:"period"`                 // e.g. 2026-W07, 2026-02, 2026-Q1 or 2026
+	PeriodStart   string           `json:"period_start"`           // first day, YYYY-MM-DD
+	PeriodEnd     string           `json:"period_end"`             // last day, YYYY-MM-DD
+	ReportMonth   string           `json:"report_month,omitempty"` // monthly reports only, YYYY-MM
+	Content       *ReportContent   `json:"content"`
+	SkillsLearned []string         `json:"skills_learned"`
+	GeneratedAt   time.Time        `json:"generated_at"`
 }
 
 // ToResponse converts Report to ReportResponse
@@ -72,13 +209,21 @@ func (r *Report) ToResponse() (*ReportResponse, error) {
 		return nil, err
 	}
 
-	return &ReportResponse{
+	resp := &ReportResponse{
 		ID:            r.ID,
-		ReportMonth:   r.ReportMonth.Format("2006-01"),
+		PeriodType:    r.PeriodType,
+		Period:        r.Period().Key(),
+		PeriodStart:   r.PeriodStart.Format(DateLayout),
+		PeriodEnd:     r.PeriodEnd.Format(DateLayout),
 		Content:       &content,
 		SkillsLearned: r.SkillsLearned,
 		GeneratedAt:   r.GeneratedAt,
-	}, nil
+	}
+	if r.PeriodType == ReportPeriodMonth {
+		resp.ReportMonth = r.PeriodStart.Format("2006-01")
+	}
+
+	return resp, nil
 }
 
 // ReportListResponse wraps a list of reports
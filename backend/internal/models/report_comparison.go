@@ -0,0 +1,133 @@
+package models
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// ReportComparisonThreshold is how many completion points a habit has to
+// gain or lose from one period to the next to count as improved or declined
+const ReportComparisonThreshold = 5.0
+
+// ReportComparison compares a period's habit data with the previous period
+// of the same type
+type ReportComparison struct {
+	PreviousPeriod     string        `json:"previous_period"` // key, e.g. 2026-01
+	PreviousCompletion float64       `json:"previous_completion"`
+	CurrentCompletion  float64       `json:"current_completion"`
+	Change             float64       `json:"change"` // completion points
+	Improved           []HabitChange `json:"improved"`
+	Declined           []HabitChange `json:"declined"`
+	New                []HabitChange `json:"new"`     // nothing due in the previous period
+	Dropped            []HabitChange `json:"dropped"` // completed in the previous period, not once in this one, or gone
+}
+
+// HabitChange is how a habit's completion and best streak changed from the
+// previous period
+type HabitChange struct {
+	HabitID            uuid.UUID `json:"habit_id"`
+	HabitTitle         string    `json:"habit_title"`
+	PreviousRate       float64   `json:"previous_rate"`
+	CurrentRate        float64   `json:"current_rate"`
+	Change             float64   `json:"change"` // completion points
+	PreviousBestStreak int       `json:"previous_best_streak"`
+	CurrentBestStreak  int       `json:"current_best_streak"`
+}
+
+// OverallCompletion averages the completion rates of the habits that had
+// days due, so habits started after the period do not count as missed
+func OverallCompletion(habits []*HabitCompletionData) float64 {
+	var total float64
+	var counted int
+	for _, habit := range habits {
+		if habit.DueDays > 0 {
+			total += habit.CompletionRate
+			counted++
+		}
+	}
+	if counted == 0 {
+		return 0
+	}
+	return total / float64(counted)
+}
+
+// CompareHabitData compares the habit data of a period with that of the
+// previous period. Habits completed in the previous period that have nothing
+// due in the current one, or are missing from it, were dropped. Other habits
+// with nothing due in the current period are left out, as are habits within
+// ReportComparisonThreshold of their previous rate.
+func CompareHabitData(previousPeriod ReportPeriod, previous, current []*HabitCompletionData) *ReportComparison {
+	comparison := &ReportComparison{
+		PreviousPeriod:     previousPeriod.Key(),
+		PreviousCompletion: OverallCompletion(previous),
+		CurrentCompletion:  OverallCompletion(current),
+		Improved:           []HabitChange{},
+		Declined:           []HabitChange{},
+		New:                []HabitChange{},
+		Dropped:            []HabitChange{},
+	}
+	comparison.Change = comparison.CurrentCompletion - comparison.PreviousCompletion
+
+	before := make(map[uuid.UUID]*HabitCompletionData, len(previous))
+	for _, habit := range previous {
+		before[habit.HabitID] = habit
+	}
+
+	tracked := make(map[uuid.UUID]bool, len(current))
+	for _, habit := range current {
+		if habit.DueDays == 0 {
+			continue
+		}
+		tracked[habit.HabitID] = true
+
+		change := HabitChange{
+			HabitID:           habit.HabitID,
+			HabitTitle:        habit.HabitTitle,
+			CurrentRate:       habit.CompletionRate,
+			CurrentBestStreak: habit.BestStreak,
+		}
+
+		earlier, ok := before[habit.HabitID]
+		if !ok || earlier.DueDays == 0 {
+			comparison.New = append(comparison.New, change)
+			continue
+		}
+		change.PreviousRate = earlier.CompletionRate
+		change.PreviousBestStreak = earlier.BestStreak
+		change.Change = change.CurrentRate - change.PreviousRate
+
+		switch {
+		case earlier.Completions > 0 && habit.Completions == 0:
+			comparison.Dropped = append(comparison.Dropped, change)
+		case change.Change >= ReportComparisonThreshold:
+			comparison.Improved = append(comparison.Improved, change)
+		case change.Change <= -ReportComparisonThreshold:
+			comparison.Declined = append(comparison.Declined, change)
+		}
+	}
+
+	// Deleted or paused habits drop out of the current period entirely
+	for _, earlier := range previous {
+		if tracked[earlier.HabitID] || earlier.DueDays == 0 || earlier.Completions == 0 {
+			continue
+		}
+		comparison.Dropped = append(comparison.Dropped, HabitChange{
+			HabitID:            earlier.HabitID,
+			HabitTitle:         earlier.HabitTitle,
+			PreviousRate:       earlier.CompletionRate,
+			Change:             -earlier.CompletionRate,
+			PreviousBestStreak: earlier.BestStreak,
+		})
+	}
+
+	// Biggest changes first
+	sort.SliceStable(comparison.Improved, func(i, j int) bool {
+		return comparison.Improved[i].Change > comparison.Improved[j].Change
+	})
+	sort.SliceStable(comparison.Declined, func(i, j int) bool {
+		return comparison.Declined[i].Change < comparison.Declined[j].Change
+	})
+
+	return comparison
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCompareHabitData(t *testing.T) {
+	ids := make(map[string]uuid.UUID)
+	habit := func(title string, dueDays, completions int) *HabitCompletionData {
+		if _, ok := ids[title]; !ok {
+			ids[title] = uuid.New()
+		}
+		data := &HabitCompletionData{
+			HabitID:     ids[title],
+			HabitTitle:  title,
+			DueDays:     dueDays,
+			Completions: completions,
+		}
+		if dueDays > 0 {
+			data.CompletionRate = float64(completions) / float64(dueDays) * 100
+		}
+		return data
+	}
+
+	tests := []struct {
+		name     string
+		previous []*HabitCompletionData
+		current  []*HabitCompletionData
+		improved []string
+		declined []string
+		new      []string
+		dropped  []string
+	}{
+		{
+			name:     "improved, declined and steady",
+			previous: []*HabitCompletionData{habit("read", 10, 5), habit("run", 10, 8), habit("stretch", 10, 5)},
+			current:  []*HabitCompletionData{habit("read", 10, 9), habit("run", 10, 4), habit("stretch", 20, 10)},
+			improved: []string{"read"},
+			declined: []string{"run"},
+		},
+		{
+			name:     "biggest changes first",
+			previous: []*HabitCompletionData{habit("read", 10, 5), habit("run", 10, 2), habit("journal", 10, 9), habit("stretch", 10, 6)},
+			current:  []*HabitCompletionData{habit("read", 10, 7), habit("run", 10, 9), habit("journal", 10, 7), habit("stretch", 10, 1)},
+			improved: []string{"run", "read"},
+			declined: []string{"stretch", "journal"},
+		},
+		{
+			name:     "started this period",
+			previous: []*HabitCompletionData{habit("read", 0, 0)},
+			current:  []*HabitCompletionData{habit("read", 10, 3), habit("run", 5, 5)},
+			new:      []string{"read", "run"},
+		},
+		{
+			name:     "not completed once this period",
+			previous: []*HabitCompletionData{habit("read", 10, 6)},
+			current:  []*HabitCompletionData{habit("read", 10, 0)},
+			dropped:  []string{"read"},
+		},
+		{
+			name:     "removed since the previous period",
+			previous: []*HabitCompletionData{habit("read", 10, 6), habit("run", 10, 4), habit("journal", 10, 0)},
+			current:  []*HabitCompletionData{habit("run", 0, 0)},
+			dropped:  []string{"read", "run"},
+		},
+		{
+			name:     "nothing due in either period",
+			previous: []*HabitCompletionData{habit("read", 0, 0)},
+			current:  []*HabitCompletionData{habit("read", 0, 0)},
+		},
+	}
+
+	titles := func(changes []HabitChange) []string {
+		var names []string
+		for _, change := range changes {
+			names = append(names, change.HabitTitle)
+		}
+		return names
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison := CompareHabitData(MonthPeriod(2026, 1), tt.previous, tt.current)
+
+			for _, category := range []struct {
+				name string
+				got  []HabitChange
+				want []string
+			}{
+				{"Improved", comparison.Improved, tt.improved},
+				{"Declined", comparison.Declined, tt.declined},
+				{"New", comparison.New, tt.new},
+				{"Dropped", comparison.Dropped, tt.dropped},
+			} {
+				if got := titles(category.got); !reflect.DeepEqual(got, category.want) {
+					t.Errorf("%s = %v; want %v", category.name, got, category.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareHabitDataDroppedRates(t *testing.T) {
+	id := uuid.New()
+	previous := []*HabitCompletionData{{HabitID: id, HabitTitle: "read", DueDays: 4, Completions: 3, CompletionRate: 75, BestStreak: 2}}
+
+	comparison := CompareHabitData(MonthPeriod(2026, 1), previous, nil)
+
+	want := []HabitChange{{HabitID: id, HabitTitle: "read", PreviousRate: 75, Change: -75, PreviousBestStreak: 2}}
+	if !reflect.DeepEqual(comparison.Dropped, want) {
+		t.Errorf("Dropped = %+v; want %+v", comparison.Dropped, want)
+	}
+	if comparison.PreviousPeriod != "2026-01" || comparison.PreviousCompletion != 75 || comparison.CurrentCompletion != 0 || comparison.Change != -75 {
+		t.Errorf("overall = %s %v -> %v (%v); want 2026-01 75 -> 0 (-75)",
+			comparison.PreviousPeriod, comparison.PreviousCompletion, comparison.CurrentCompletion, comparison.Change)
+	}
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReportJobKind is what a report job does with the period's report
+type ReportJobKind string
+
+const (
+	ReportJobGenerate   ReportJobKind = "generate"    // keeps an existing report
+	ReportJobRegenerate ReportJobKind = "regenerate"  // replaces an existing report
+	ReportJobYearReview ReportJobKind = "year_review" // builds the year in review of a year period
+)
+
+// Limited reports whether jobs of the kind count against the monthly quota
+func (k ReportJobKind) Limited() bool {
+	return k == ReportJobRegenerate || k == ReportJobYearReview
+}
+
+// ReportJobStatus represents where a report job is in the queue
+type ReportJobStatus string
+
+const (
+	ReportJobQueued    ReportJobStatus = "queued"    // waiting for a worker
+	ReportJobRunning   ReportJobStatus = "running"   // claimed by a worker
+	ReportJobSucceeded ReportJobStatus = "succeeded" // the report is ready
+	ReportJobFailed    ReportJobStatus = "failed"    // see Error
+)
+
+// Active reports whether the job has yet to finish
+func (s ReportJobStatus) Active() bool {
+	return s == ReportJobQueued || s == ReportJobRunning
+}
+
+// ReportJob is a queued request to generate a user's report, or year review,
+// for a period. A user has at most one active job per period for each, so
+// repeated requests share it.
+type ReportJob struct {
+	ID          uuid.UUID        `json:"id"`
+	UserID      uuid.UUID        `json:"user_id"`
+	Kind        ReportJobKind    `json:"kind"`
+	PeriodType  ReportPeriodType `json:"period_type"`
+	PeriodStart time.Time        `json:"period_start"`
+	Status      ReportJobStatus  `json:"status"`
+	Attempts    int              `json:"attempts"`
+	ReportID    *uuid.UUID       `json:"report_id,omitempty"`
+	Error       *string          `json:"error,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// Period returns the period the job generates a report for
+func (j *ReportJob) Period() ReportPeriod {
+	return ReportPeriod{Type: j.PeriodType, Start: j.PeriodStart}
+}
+
+// ReportJobResponse is the API response for a report job
+type ReportJobResponse struct {
+	ID         uuid.UUID        `json:"id"`
+	Kind       ReportJobKind    `json:"kind"`
+	PeriodType ReportPeriodType `json:"period_type"`
+	Period     string           `json:"period"`
+	Status     ReportJobStatus  `json:"status"`
+	ReportID   *uuid.UUID       `json:"report_id,omitempty"`
+	Error      *string          `json:"error,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	StartedAt  *time.Time       `json:"started_at,omitempty"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+}
+
+// ToResponse converts ReportJob to ReportJobResponse
+func (j *ReportJob) ToResponse() *ReportJobResponse {
+	return &ReportJobResponse{
+		ID:         j.ID,
+		Kind:       j.Kind,
+		PeriodType: j.PeriodType,
+		Period:     j.Period().Key(),
+		Status:     j.Status,
+		ReportID:   j.ReportID,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.FinishedAt,
+	}
+}
+
+// ReportQuota is how many report regenerations a user has used this month
+type ReportQuota struct {
+	Limit    int       `json:"limit"`
+	Used     int       `json:"used"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+// Remaining returns how many regenerations are left this month
+func (q *ReportQuota) Remaining() int {
+	return max(q.Limit-q.Used, 0)
+}
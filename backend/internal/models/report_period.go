@@ -0,0 +1,194 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidReportPeriod = errors.New("period must be YYYY-Www, YYYY-MM, YYYY-Qn or YYYY")
+)
+
+// ReportPeriodType is the length of the period a report covers
+type ReportPeriodType string
+
+const (
+	ReportPeriodWeek    ReportPeriodType = "week"    // ISO week, Monday to Sunday
+	ReportPeriodMonth   ReportPeriodType = "month"   // calendar month
+	ReportPeriodQuarter ReportPeriodType = "quarter" // calendar quarter
+	ReportPeriodYear    ReportPeriodType = "year"    // calendar year
+)
+
+// ReportPeriodTypes lists every report period type
+var ReportPeriodTypes = []ReportPeriodType{
+	ReportPeriodWeek,
+	ReportPeriodMonth,
+	ReportPeriodQuarter,
+	ReportPeriodYear,
+}
+
+// Valid reports whether t is a known period type
+func (t ReportPeriodType) Valid() bool {
+	for _, known := range ReportPeriodTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Adjective names reports of the period type, e.g. weekly
+func (t ReportPeriodType) Adjective() string {
+	switch t {
+	case ReportPeriodWeek:
+		return "weekly"
+	case ReportPeriodQuarter:
+		return "quarterly"
+	case ReportPeriodYear:
+		return "yearly"
+	}
+	return "monthly"
+}
+
+// ReportPeriod is the span of calendar days a report covers. Start is the
+// first day, as midnight UTC like other calendar dates.
+type ReportPeriod struct {
+	Type  ReportPeriodType
+	Start time.Time
+}
+
+// ReportPeriodOf returns the period of type t that contains date
+func ReportPeriodOf(t ReportPeriodType, date time.Time) ReportPeriod {
+	year, month, day := date.Date()
+	date = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	switch t {
+	case ReportPeriodWeek:
+		return ReportPeriod{Type: t, Start: WeekStart(date)}
+	case ReportPeriodQuarter:
+		first := time.Month((int(month)-1)/3*3 + 1)
+		return ReportPeriod{Type: t, Start: time.Date(year, first, 1, 0, 0, 0, 0, time.UTC)}
+	case ReportPeriodYear:
+		return ReportPeriod{Type: t, Start: time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	}
+	return ReportPeriod{Type: ReportPeriodMonth, Start: time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// MonthPeriod returns the period of a calendar month
+func MonthPeriod(year, month int) ReportPeriod {
+	return ReportPeriod{Type: ReportPeriodMonth, Start: time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// YearPeriod returns the period of a calendar year
+func YearPeriod(year int) ReportPeriod {
+	return ReportPeriod{Type: ReportPeriodYear, Start: time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseReportPeriod parses a period key: 2026-W07 for an ISO week, 2026-02
+// for a month, 2026-Q1 for a quarter or 2026 for a year
+func ParseReportPeriod(key string) (ReportPeriod, error) {
+	yearPart, rest, hasRest := strings.Cut(strings.ToUpper(key), "-")
+	year, err := strconv.Atoi(yearPart)
+	if err != nil || len(yearPart) != 4 || year < 1 {
+		return ReportPeriod{}, ErrInvalidReportPeriod
+	}
+
+	if !hasRest {
+		return YearPeriod(year), nil
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "W"):
+		week, err := strconv.Atoi(rest[1:])
+		if err != nil || len(rest) != 3 {
+			return ReportPeriod{}, ErrInvalidReportPeriod
+		}
+		// Week 1 is the week containing January 4th
+		start := WeekStart(time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)).AddDate(0, 0, 7*(week-1))
+		if isoYear, isoWeek := start.ISOWeek(); isoYear != year || isoWeek != week {
+			return ReportPeriod{}, ErrInvalidReportPeriod
+		}
+		return ReportPeriod{Type: ReportPeriodWeek, Start: start}, nil
+
+	case strings.HasPrefix(rest, "Q"):
+		quarter, err := strconv.Atoi(rest[1:])
+		if err != nil || len(rest) != 2 || quarter < 1 || quarter > 4 {
+			return ReportPeriod{}, ErrInvalidReportPeriod
+		}
+		return ReportPeriod{Type: ReportPeriodQuarter, Start: time.Date(year, time.Month(3*quarter-2), 1, 0, 0, 0, 0, time.UTC)}, nil
+	}
+
+	month, err := strconv.Atoi(rest)
+	if err != nil || len(rest) != 2 || month < 1 || month > 12 {
+		return ReportPeriod{}, ErrInvalidReportPeriod
+	}
+	return MonthPeriod(year, month), nil
+}
+
+// End returns the last day of the period
+func (p ReportPeriod) End() time.Time {
+	return p.Next().Start.AddDate(0, 0, -1)
+}
+
+// Next returns the period of the same type that follows p
+func (p ReportPeriod) Next() ReportPeriod {
+	switch p.Type {
+	case ReportPeriodWeek:
+		return ReportPeriod{Type: p.Type, Start: p.Start.AddDate(0, 0, 7)}
+	case ReportPeriodQuarter:
+		return ReportPeriod{Type: p.Type, Start: p.Start.AddDate(0, 3, 0)}
+	case ReportPeriodYear:
+		return ReportPeriod{Type: p.Type, Start: p.Start.AddDate(1, 0, 0)}
+	}
+	return ReportPeriod{Type: p.Type, Start: p.Start.AddDate(0, 1, 0)}
+}
+
+// Previous returns the period of the same type that precedes p
+func (p ReportPeriod) Previous() ReportPeriod {
+	return ReportPeriodOf(p.Type, p.Start.AddDate(0, 0, -1))
+}
+
+// Key identifies the period in URLs, e.g. 2026-W07, 2026-02, 2026-Q1 or 2026
+func (p ReportPeriod) Key() string {
+	switch p.Type {
+	case ReportPeriodWeek:
+		year, week := p.Start.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case ReportPeriodQuarter:
+		return fmt.Sprintf("%04d-Q%d", p.Start.Year(), (int(p.Start.Month())-1)/3+1)
+	case ReportPeriodYear:
+		return fmt.Sprintf("%04d", p.Start.Year())
+	}
+	return p.Start.Format("2006-01")
+}
+
+// Label describes the period in prose, e.g. "the week of February 9, 2026"
+func (p ReportPeriod) Label() string {
+	switch p.Type {
+	case ReportPeriodWeek:
+		return "the week of " + p.Start.Format("January 2, 2006")
+	case ReportPeriodQuarter:
+		return fmt.Sprintf("Q%d %d", (int(p.Start.Month())-1)/3+1, p.Start.Year())
+	case ReportPeriodYear:
+		return strconv.Itoa(p.Start.Year())
+	}
+	return p.Start.Format("January 2006")
+}
+
+// MarshalText encodes the period as its key
+func (p ReportPeriod) MarshalText() ([]byte, error) {
+	return []byte(p.Key()), nil
+}
+
+// UnmarshalText decodes a period key
+func (p *ReportPeriod) UnmarshalText(text []byte) error {
+	parsed, err := ParseReportPeriod(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
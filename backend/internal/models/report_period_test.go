@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReportPeriod(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		key   string
+		want  ReportPeriod
+		valid bool
+	}{
+		{"2026-W01", ReportPeriod{Type: ReportPeriodWeek, Start: date(2025, time.December, 29)}, true},
+		{"2026-W07", ReportPeriod{Type: ReportPeriodWeek, Start: date(2026, time.February, 9)}, true},
+		{"2026-w07", ReportPeriod{Type: ReportPeriodWeek, Start: date(2026, time.February, 9)}, true},
+		// 2026 starts on a Thursday, so it has 53 ISO weeks; 2025 does not
+		{"2026-W53", ReportPeriod{Type: ReportPeriodWeek, Start: date(2026, time.December, 28)}, true},
+		{"2020-W53", ReportPeriod{Type: ReportPeriodWeek, Start: date(2020, time.December, 28)}, true},
+		{"2025-W53", ReportPeriod{}, false},
+		{"2026-W54", ReportPeriod{}, false},
+		{"2026-W00", ReportPeriod{}, false},
+		{"2026-W0", ReportPeriod{}, false},
+		{"2026-W7", ReportPeriod{}, false},
+		{"2026-W-1", ReportPeriod{}, false},
+		{"2026-02", MonthPeriod(2026, 2), true},
+		{"2026-00", ReportPeriod{}, false},
+		{"2026-13", ReportPeriod{}, false},
+		{"2026-Q1", ReportPeriod{Type: ReportPeriodQuarter, Start: date(2026, time.January, 1)}, true},
+		{"2026-Q4", ReportPeriod{Type: ReportPeriodQuarter, Start: date(2026, time.October, 1)}, true},
+		{"2026-Q0", ReportPeriod{}, false},
+		{"2026-Q5", ReportPeriod{}, false},
+		{"2026", ReportPeriod{Type: ReportPeriodYear, Start: date(2026, time.January, 1)}, true},
+		{"26", ReportPeriod{}, false},
+		{"", ReportPeriod{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := ParseReportPeriod(tt.key)
+			if !tt.valid {
+				if err != ErrInvalidReportPeriod {
+					t.Errorf("ParseReportPeriod(%q) = %v, %v; want ErrInvalidReportPeriod", tt.key, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseReportPeriod(%q): %v", tt.key, err)
+			}
+			if got.Type != tt.want.Type || !got.Start.Equal(tt.want.Start) {
+				t.Errorf("ParseReportPeriod(%q) = %s %s; want %s %s",
+					tt.key, got.Type, got.Start.Format(DateLayout), tt.want.Type, tt.want.Start.Format(DateLayout))
+			}
+		})
+	}
+}
+
+func TestReportPeriodKeyRoundTrip(t *testing.T) {
+	for _, key := range []string{"2026-W01", "2026-W53", "2027-W01", "2026-02", "2026-Q3", "2026"} {
+		period, err := ParseReportPeriod(key)
+		if err != nil {
+			t.Fatalf("ParseReportPeriod(%q): %v", key, err)
+		}
+		if got := period.Key(); got != key {
+			t.Errorf("ParseReportPeriod(%q).Key() = %q", key, got)
+		}
+	}
+
+	// The week after 2026-W53 is the first week of 2027
+	period, _ := ParseReportPeriod("2026-W53")
+	if got := period.Next().Key(); got != "2027-W01" {
+		t.Errorf("2026-W53 Next = %q; want 2027-W01", got)
+	}
+}
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidSchedule = errors.New("invalid habit schedule")
+)
+
+// HabitSchedule holds the parameters of a habit's frequency. Which fields
+// apply depends on the frequency:
+//   - specific_days: Weekdays (0 = Sunday ... 6 = Saturday)
+//   - times_per_week: TimesPerWeek, any days within a Monday-Sunday week
+//   - every_n_days: IntervalDays, counted from StartDate
+//   - monthly: DayOfMonth, clamped to the last day of shorter months
+type HabitSchedule struct {
+	Weekdays     []time.Weekday `json:"weekdays,omitempty"`
+	TimesPerWeek int            `json:"times_per_week,omitempty"`
+	IntervalDays int            `json:"interval_days,omitempty"`
+	DayOfMonth   int            `json:"day_of_month,omitempty"`
+	StartDate    string         `json:"start_date,omitempty"` // Format: YYYY-MM-DD
+}
+
+// Validate checks that the schedule has the fields its frequency needs
+func (s *HabitSchedule) Validate(frequency HabitFrequency) error {
+	switch frequency {
+	case FrequencyDaily, FrequencyWeekly:
+		return nil
+	case FrequencySpecificDays:
+		if s == nil || len(s.Weekdays) == 0 {
+			return ErrInvalidSchedule
+		}
+		for _, day := range s.Weekdays {
+			if day < time.Sunday || day > time.Saturday {
+				return ErrInvalidSchedule
+			}
+		}
+	case FrequencyTimesPerWeek:
+		if s == nil || s.TimesPerWeek < 1 || s.TimesPerWeek > 7 {
+			return ErrInvalidSchedule
+		}
+	case FrequencyEveryNDays:
+		if s == nil || s.IntervalDays < 1 || s.IntervalDays > 365 {
+			return ErrInvalidSchedule
+		}
+	case FrequencyMonthly:
+		if s == nil || s.DayOfMonth < 1 || s.DayOfMonth > 31 {
+			return ErrInvalidSchedule
+		}
+	default:
+		return ErrInvalidSchedule
+	}
+
+	if s != nil && s.StartDate != "" {
+		if _, err := time.Parse(DateLayout, s.StartDate); err != nil {
+			return ErrInvalidSchedule
+		}
+	}
+
+	return nil
+}
+
+// weeklyTarget returns the completions required per week for quota
+// frequencies, or 0 when the habit is due on fixed days
+func (h *Habit) weeklyTarget() int {
+	switch h.Frequency {
+	case FrequencyWeekly:
+		return 1
+	case FrequencyTimesPerWeek:
+		if h.Schedule != nil {
+			return h.Schedule.TimesPerWeek
+		}
+	}
+	return 0
+}
+
+// startDate returns the date an every_n_days habit counts its interval from
+func (h *Habit) startDate() time.Time {
+	if h.Schedule != nil && h.Schedule.StartDate != "" {
+		if start, err := time.Parse(DateLayout, h.Schedule.StartDate); err == nil {
+			return start
+		}
+	}
+	return DateOf(h.CreatedAt, time.UTC)
+}
+
+// isScheduledOn reports whether a fixed-day habit falls on date
+func (h *Habit) isScheduledOn(date time.Time) bool {
+	switch h.Frequency {
+	case FrequencySpecificDays:
+		if h.Schedule == nil {
+			return false
+		}
+		for _, day := range h.Schedule.Weekdays {
+			if date.Weekday() == day {
+				return true
+			}
+		}
+		return false
+	case FrequencyEveryNDays:
+		if h.Schedule == nil || h.Schedule.IntervalDays < 1 {
+			return true
+		}
+		days := DaysBetween(h.startDate(), date)
+		return days >= 0 && days%h.Schedule.IntervalDays == 0
+	case FrequencyMonthly:
+		if h.Schedule == nil {
+			return date.Day() == 1
+		}
+		lastDay := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		day := h.Schedule.DayOfMonth
+		if day > lastDay {
+			day = lastDay
+		}
+		return date.Day() == day
+	default:
+		return true
+	}
+}
+
+// IsDueOn reports whether the habit is due on a calendar date.
+// completedEarlierInWeek is the number of completions earlier in the same
+// Monday-Sunday week; quota frequencies stay due until the target is met.
+// Skipped days (frozen or rest days) are never due and shrink the quota.
+func (h *Habit) IsDueOn(date time.Time, completedEarlierInWeek int, skipped map[time.Time]bool) bool {
+	if skipped[date] {
+		return false
+	}
+	if h.weeklyTarget() > 0 {
+		return completedEarlierInWeek < h.weekQuota(WeekStart(date), nil, skipped)
+	}
+	return h.isScheduledOn(date)
+}
+
+// weekQuota returns the completions a quota habit needs in the week starting
+// on week. Skipped days (frozen or rest days) shrink the target in proportion
+// to the days left, so a fully skipped week needs nothing.
+func (h *Habit) weekQuota(week time.Time, completed, skipped map[time.Time]bool) int {
+	target := h.weeklyTarget()
+
+	skippedDays := 0
+	for date := week; date.Before(week.AddDate(0, 0, 7)); date = date.AddDate(0, 0, 1) {
+		if skipped[date] && !completed[date] {
+			skippedDays++
+		}
+	}
+	if skippedDays == 0 {
+		return target
+	}
+
+	return int(math.Ceil(float64(target*(7-skippedDays)) / 7))
+}
+
+// DueDates returns the dates between from and to (inclusive) on which the
+// habit was due, given the dates it was completed on and the days skipped
+// with a freeze or rest day, which are not due unless completed anyway. For
+// weekly quotas the due dates are the completions counting towards the
+// target plus, for each target still unmet, the last uncompleted days of the
+// week, i.e. the days a completion could no longer be put off.
+func (h *Habit) DueDates(from, to time.Time, completed, skipped map[time.Time]bool) []time.Time {
+	var due []time.Time
+
+	if h.weeklyTarget() == 0 {
+		for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+			if h.isScheduledOn(date) && (completed[date] || !skipped[date]) {
+				due = append(due, date)
+			}
+		}
+		return due
+	}
+
+	for week := WeekStart(from); !week.After(to); week = week.AddDate(0, 0, 7) {
+		target := h.weekQuota(week, completed, skipped)
+		weekDue := make(map[time.Time]bool)
+
+		count := 0
+		for date := week; date.Before(week.AddDate(0, 0, 7)) && count < target; date = date.AddDate(0, 0, 1) {
+			if completed[date] {
+				weekDue[date] = true
+				count++
+			}
+		}
+
+		for date := week.AddDate(0, 0, 6); !date.Before(week) && count < target; date = date.AddDate(0, 0, -1) {
+			if !completed[date] && !skipped[date] {
+				weekDue[date] = true
+				count++
+			}
+		}
+
+		for date := week; date.Before(week.AddDate(0, 0, 7)); date = date.AddDate(0, 0, 1) {
+			if weekDue[date] && !date.Before(from) && !date.After(to) {
+				due = append(due, date)
+			}
+		}
+	}
+
+	return due
+}
+
+// MissedBetween reports whether a due date went uncompleted strictly between
+// two dates. Skipped days are never missed. A weekly quota only counts as
+// missed once its week has ended short of the target, so it never breaks on
+// a day inside the current week.
+func (h *Habit) MissedBetween(after, before time.Time, completed, skipped map[time.Time]bool) bool {
+	if h.weeklyTarget() > 0 {
+		for week := WeekStart(after); week.AddDate(0, 0, 6).Before(before); week = week.AddDate(0, 0, 7) {
+			count := 0
+			for date := week; date.Before(week.AddDate(0, 0, 7)); date = date.AddDate(0, 0, 1) {
+				if completed[date] {
+					count++
+				}
+			}
+			if count < h.weekQuota(week, completed, skipped) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for date := after.AddDate(0, 0, 1); date.Before(before); date = date.AddDate(0, 0, 1) {
+		if h.isScheduledOn(date) && !completed[date] && !skipped[date] {
+			return true
+		}
+	}
+	return false
+}
+
+// WeekStart returns the Monday of the week containing date
+func WeekStart(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7
+	return date.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// March 2026 starts on a Sunday, so Monday-Sunday weeks begin on the 2nd,
+// 9th and 16th
+
+func mustDate(value string) time.Time {
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+func mustDates(values ...string) []time.Time {
+	var dates []time.Time
+	for _, value := range values {
+		dates = append(dates, mustDate(value))
+	}
+	return dates
+}
+
+func dateSet(values ...string) map[time.Time]bool {
+	set := make(map[time.Time]bool, len(values))
+	for _, value := range values {
+		set[mustDate(value)] = true
+	}
+	return set
+}
+
+func scheduledHabit(frequency HabitFrequency, schedule *HabitSchedule) *Habit {
+	return &Habit{
+		Frequency: frequency,
+		Schedule:  schedule,
+		CreatedAt: mustDate("2026-01-01"),
+	}
+}
+
+var (
+	dailyHabit        = scheduledHabit(FrequencyDaily, nil)
+	monWedFriHabit    = scheduledHabit(FrequencySpecificDays, &HabitSchedule{Weekdays: []time.Weekday{time.Monday, time.Wednesday, time.Friday}})
+	threePerWeekHabit = scheduledHabit(FrequencyTimesPerWeek, &HabitSchedule{TimesPerWeek: 3})
+	everyThirdHabit   = scheduledHabit(FrequencyEveryNDays, &HabitSchedule{IntervalDays: 3, StartDate: "2026-03-01"})
+	monthlyHabit      = scheduledHabit(FrequencyMonthly, &HabitSchedule{DayOfMonth: 31})
+)
+
+func TestDueDates(t *testing.T) {
+	tests := []struct {
+		name      string
+		habit     *Habit
+		from, to  string
+		completed map[time.Time]bool
+		skipped   map[time.Time]bool
+		want      []time.Time
+	}{
+		{
+			name:  "daily",
+			habit: dailyHabit, from: "2026-03-02", to: "2026-03-04",
+			want: mustDates("2026-03-02", "2026-03-03", "2026-03-04"),
+		},
+		{
+			name:  "daily with a frozen day",
+			habit: dailyHabit, from: "2026-03-02", to: "2026-03-04",
+			skipped: dateSet("2026-03-03"),
+			want:    mustDates("2026-03-02", "2026-03-04"),
+		},
+		{
+			name:  "frozen day completed anyway",
+			habit: dailyHabit, from: "2026-03-02", to: "2026-03-04",
+			completed: dateSet("2026-03-03"), skipped: dateSet("2026-03-03"),
+			want: mustDates("2026-03-02", "2026-03-03", "2026-03-04"),
+		},
+		{
+			name:  "specific days",
+			habit: monWedFriHabit, from: "2026-03-02", to: "2026-03-08",
+			want: mustDates("2026-03-02", "2026-03-04", "2026-03-06"),
+		},
+		{
+			name:  "specific days on a rest day",
+			habit: monWedFriHabit, from: "2026-03-02", to: "2026-03-08",
+			skipped: dateSet("2026-03-03", "2026-03-04"),
+			want:    mustDates("2026-03-02", "2026-03-06"),
+		},
+		{
+			name:  "times per week counts completions, then the last days",
+			habit: threePerWeekHabit, from: "2026-03-02", to: "2026-03-08",
+			completed: dateSet("2026-03-02"),
+			want:      mustDates("2026-03-02", "2026-03-07", "2026-03-08"),
+		},
+		{
+			name:  "times per week met",
+			habit: threePerWeekHabit, from: "2026-03-02", to: "2026-03-08",
+			completed: dateSet("2026-03-02", "2026-03-03", "2026-03-05", "2026-03-06"),
+			want:      mustDates("2026-03-02", "2026-03-03", "2026-03-05"),
+		},
+		{
+			name:  "times per week across a week boundary",
+			habit: threePerWeekHabit, from: "2026-03-05", to: "2026-03-11",
+			completed: dateSet("2026-03-02", "2026-03-10"),
+			want:      mustDates("2026-03-07", "2026-03-08", "2026-03-10"),
+		},
+		{
+			name:  "times per week shrunk by rest days",
+			habit: threePerWeekHabit, from: "2026-03-02", to: "2026-03-08",
+			skipped: dateSet("2026-03-02", "2026-03-03", "2026-03-04"),
+			want:    mustDates("2026-03-07", "2026-03-08"),
+		},
+		{
+			name:  "every third day",
+			habit: everyThirdHabit, from: "2026-02-27", to: "2026-03-10",
+			want: mustDates("2026-03-01", "2026-03-04", "2026-03-07", "2026-03-10"),
+		},
+		{
+			name:  "monthly on the 31st",
+			habit: monthlyHabit, from: "2026-01-01", to: "2026-04-30",
+			want: mustDates("2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30"),
+		},
+		{
+			name:  "monthly on the 31st in a leap year",
+			habit: monthlyHabit, from: "2028-02-01", to: "2028-02-29",
+			want: mustDates("2028-02-29"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.habit.DueDates(mustDate(tt.from), mustDate(tt.to), tt.completed, tt.skipped)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DueDates = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDueOn(t *testing.T) {
+	tests := []struct {
+		name           string
+		habit          *Habit
+		date           string
+		completedEarly int
+		skipped        map[time.Time]bool
+		want           bool
+	}{
+		{"specific days on a scheduled day", monWedFriHabit, "2026-03-02", 0, nil, true},
+		{"specific days on an off day", monWedFriHabit, "2026-03-03", 0, nil, false},
+		{"frozen day", dailyHabit, "2026-03-03", 0, dateSet("2026-03-03"), false},
+		{"times per week short of the target", threePerWeekHabit, "2026-03-05", 2, nil, true},
+		{"times per week met", threePerWeekHabit, "2026-03-05", 3, nil, false},
+		{"times per week shrunk by rest days", threePerWeekHabit, "2026-03-07", 2, dateSet("2026-03-02", "2026-03-03", "2026-03-04"), false},
+		{"every third day on an interval day", everyThirdHabit, "2026-03-04", 0, nil, true},
+		{"every third day between", everyThirdHabit, "2026-03-05", 0, nil, false},
+		{"every third day before its start", everyThirdHabit, "2026-02-26", 0, nil, false},
+		{"monthly on the 31st in April", monthlyHabit, "2026-04-30", 0, nil, true},
+		{"monthly on the 31st mid-month", monthlyHabit, "2026-04-15", 0, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.habit.IsDueOn(mustDate(tt.date), tt.completedEarly, tt.skipped); got != tt.want {
+				t.Errorf("IsDueOn(%s) = %v; want %v", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissedBetween(t *testing.T) {
+	tests := []struct {
+		name          string
+		habit         *Habit
+		after, before string
+		completed     map[time.Time]bool
+		skipped       map[time.Time]bool
+		want          bool
+	}{
+		{"daily consecutive", dailyHabit, "2026-03-02", "2026-03-03", nil, nil, false},
+		{"daily gap", dailyHabit, "2026-03-02", "2026-03-04", nil, nil, true},
+		{"daily gap frozen", dailyHabit, "2026-03-02", "2026-03-04", nil, dateSet("2026-03-03"), false},
+		{"specific days over an off day", monWedFriHabit, "2026-03-02", "2026-03-04", nil, nil, false},
+		{"specific days over the weekend", monWedFriHabit, "2026-03-06", "2026-03-09", nil, nil, false},
+		{"specific days missing a Wednesday", monWedFriHabit, "2026-03-02", "2026-03-06", nil, nil, true},
+		{"times per week inside the week", threePerWeekHabit, "2026-03-02", "2026-03-08", dateSet("2026-03-02"), nil, false},
+		{"times per week ended short", threePerWeekHabit, "2026-03-07", "2026-03-09", dateSet("2026-03-07", "2026-03-08"), nil, true},
+		{"times per week ended met", threePerWeekHabit, "2026-03-07", "2026-03-09", dateSet("2026-03-06", "2026-03-07", "2026-03-08"), nil, false},
+		{"every third day between due days", everyThirdHabit, "2026-03-04", "2026-03-07", nil, nil, false},
+		{"every third day skipping one", everyThirdHabit, "2026-03-04", "2026-03-10", nil, nil, true},
+		{"monthly over a short month", monthlyHabit, "2026-01-31", "2026-03-31", dateSet("2026-02-28"), nil, false},
+		{"monthly missing February", monthlyHabit, "2026-01-31", "2026-03-31", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.habit.MissedBetween(mustDate(tt.after), mustDate(tt.before), tt.completed, tt.skipped); got != tt.want {
+				t.Errorf("MissedBetween(%s, %s) = %v; want %v", tt.after, tt.before, got, tt.want)
+			}
+		})
+	}
+}
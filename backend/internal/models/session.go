@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session represents a logged-in device.
+// Its ID is shared with the refresh token family issued at login.
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	DeviceName *string    `json:"device_name,omitempty"`
+	Platform   *string    `json:"platform,omitempty"`
+	UserAgent  *string    `json:"user_agent,omitempty"`
+	LastIP     *string    `json:"last_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// DeviceInfo describes the device a login request came from
+type DeviceInfo struct {
+	Name      *string
+	Platform  *string
+	UserAgent string
+	IPAddress string
+}
+
+// SessionResponse is the API response for session data
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	DeviceName *string   `json:"device_name,omitempty"`
+	Platform   *string   `json:"platform,omitempty"`
+	LastIP     *string   `json:"last_ip,omitempty"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	Current    bool      `json:"current"`
+}
+
+// ToResponse converts Session to SessionResponse
+func (s *Session) ToResponse(currentSessionID uuid.UUID) *SessionResponse {
+	return &SessionResponse{
+		ID:         s.ID,
+		DeviceName: s.DeviceName,
+		Platform:   s.Platform,
+		LastIP:     s.LastIP,
+		LastUsedAt: s.LastUsedAt,
+		CreatedAt:  s.CreatedAt,
+		Current:    s.ID == currentSessionID,
+	}
+}
+
+// SessionListResponse wraps a list of sessions
+type SessionListResponse struct {
+	Sessions   []*SessionResponse `json:"sessions"`
+	TotalCount int                `json:"total_count"`
+}
@@ -40,3 +40,39 @@ func (s *Streak) ToResponse() *StreakResponse {
 		LastCompletedDate: lastCompleted,
 	}
 }
+
+// ComputeStreak derives a habit's streaks from its completed dates, given in
+// ascending order. A run continues as long as no due day was missed between
+// completions; skipped days (frozen or rest days) are never missed and, being
+// uncompleted, do not add to the run either. The current streak drops to 0
+// once a due day after the last completion has passed; today never breaks it
+// because it is not over yet.
+func ComputeStreak(habit *Habit, dates []time.Time, skipped map[time.Time]bool, today time.Time) (current, longest int, last *time.Time) {
+	completed := make(map[time.Time]bool, len(dates))
+	for _, date := range dates {
+		completed[date] = true
+	}
+
+	run := 0
+	for i, date := range dates {
+		if i > 0 && !habit.MissedBetween(dates[i-1], date, completed, skipped) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	if len(dates) == 0 {
+		return 0, 0, nil
+	}
+
+	lastDate := dates[len(dates)-1]
+	if !habit.MissedBetween(lastDate, today, completed, skipped) {
+		current = run
+	}
+
+	return current, longest, &lastDate
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStreak(t *testing.T) {
+	tests := []struct {
+		name    string
+		habit   *Habit
+		dates   []time.Time
+		skipped map[time.Time]bool
+		today   string
+		current int
+		longest int
+	}{
+		{
+			name:  "no completions",
+			habit: dailyHabit, today: "2026-03-13",
+		},
+		{
+			name:  "through yesterday, today still open",
+			habit: dailyHabit, today: "2026-03-13",
+			dates:   mustDates("2026-03-10", "2026-03-11", "2026-03-12"),
+			current: 3, longest: 3,
+		},
+		{
+			name:  "through today",
+			habit: dailyHabit, today: "2026-03-13",
+			dates:   mustDates("2026-03-10", "2026-03-11", "2026-03-12", "2026-03-13"),
+			current: 4, longest: 4,
+		},
+		{
+			name:  "missed yesterday",
+			habit: dailyHabit, today: "2026-03-13",
+			dates:   mustDates("2026-03-09", "2026-03-10", "2026-03-11"),
+			current: 0, longest: 3,
+		},
+		{
+			name:  "un-completing a day splits the run",
+			habit: dailyHabit, today: "2026-03-13",
+			dates:   mustDates("2026-03-09", "2026-03-10", "2026-03-12"),
+			current: 1, longest: 2,
+		},
+		{
+			name:  "back-filling a past date joins the run",
+			habit: dailyHabit, today: "2026-03-13",
+			dates:   mustDates("2026-03-09", "2026-03-10", "2026-03-11", "2026-03-12"),
+			current: 4, longest: 4,
+		},
+		{
+			name:  "frozen day neither breaks nor extends",
+			habit: dailyHabit, today: "2026-03-13",
+			dates:   mustDates("2026-03-09", "2026-03-10", "2026-03-12"),
+			skipped: dateSet("2026-03-11"),
+			current: 3, longest: 3,
+		},
+		{
+			name:  "rest days after the last completion",
+			habit: dailyHabit, today: "2026-03-13",
+			dates:   mustDates("2026-03-09", "2026-03-10"),
+			skipped: dateSet("2026-03-11", "2026-03-12"),
+			current: 2, longest: 2,
+		},
+		{
+			name:  "specific days over an off day",
+			habit: monWedFriHabit, today: "2026-03-10",
+			dates:   mustDates("2026-03-02", "2026-03-04", "2026-03-06", "2026-03-09"),
+			current: 4, longest: 4,
+		},
+		{
+			name:  "specific days after a missed Wednesday",
+			habit: monWedFriHabit, today: "2026-03-12",
+			dates:   mustDates("2026-03-02", "2026-03-04", "2026-03-06", "2026-03-09"),
+			current: 0, longest: 4,
+		},
+		{
+			name:  "times per week into an open week",
+			habit: threePerWeekHabit, today: "2026-03-12",
+			dates:   mustDates("2026-03-05", "2026-03-06", "2026-03-07", "2026-03-10"),
+			current: 4, longest: 4,
+		},
+		{
+			name:  "times per week after a short week",
+			habit: threePerWeekHabit, today: "2026-03-12",
+			dates:   mustDates("2026-03-07", "2026-03-08", "2026-03-09", "2026-03-10", "2026-03-11"),
+			current: 3, longest: 3,
+		},
+		{
+			name:  "times per week after a week ended short",
+			habit: threePerWeekHabit, today: "2026-03-16",
+			dates:   mustDates("2026-03-04", "2026-03-05", "2026-03-06", "2026-03-12", "2026-03-13"),
+			current: 0, longest: 5,
+		},
+		{
+			name:  "times per week shrunk by rest days",
+			habit: threePerWeekHabit, today: "2026-03-16",
+			dates:   mustDates("2026-03-04", "2026-03-05", "2026-03-06", "2026-03-14", "2026-03-15"),
+			skipped: dateSet("2026-03-09", "2026-03-10", "2026-03-11"),
+			current: 5, longest: 5,
+		},
+		{
+			name:  "every third day",
+			habit: everyThirdHabit, today: "2026-03-12",
+			dates:   mustDates("2026-03-01", "2026-03-04", "2026-03-07", "2026-03-10"),
+			current: 4, longest: 4,
+		},
+		{
+			name:  "every third day after a missed interval",
+			habit: everyThirdHabit, today: "2026-03-14",
+			dates:   mustDates("2026-03-01", "2026-03-04", "2026-03-10"),
+			current: 0, longest: 2,
+		},
+		{
+			name:  "monthly on the 31st through short months",
+			habit: monthlyHabit, today: "2026-04-15",
+			dates:   mustDates("2026-01-31", "2026-02-28", "2026-03-31"),
+			current: 3, longest: 3,
+		},
+		{
+			name:  "monthly on the 31st after a missed April",
+			habit: monthlyHabit, today: "2026-05-01",
+			dates:   mustDates("2026-01-31", "2026-02-28", "2026-03-31"),
+			current: 0, longest: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest, last := ComputeStreak(tt.habit, tt.dates, tt.skipped, mustDate(tt.today))
+			if current != tt.current || longest != tt.longest {
+				t.Errorf("ComputeStreak = %d, %d; want %d, %d", current, longest, tt.current, tt.longest)
+			}
+
+			switch {
+			case len(tt.dates) == 0 && last != nil:
+				t.Errorf("last = %v; want nil", last)
+			case len(tt.dates) > 0 && (last == nil || !last.Equal(tt.dates[len(tt.dates)-1])):
+				t.Errorf("last = %v; want %v", last, tt.dates[len(tt.dates)-1])
+			}
+		})
+	}
+}
@@ -1,11 +1,16 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidQuietHours = errors.New("quiet hours need both a start and an end time as HH:MM")
+)
+
 // User represents a user in the system
 type User struct {
 	ID                  uuid.UUID  `json:"id"`
@@ -15,11 +20,13 @@ type User struct {
 	AvatarURL           *string    `json:"avatar_url,omitempty"`
 	XP                  int        `json:"xp"`
 	Level               int        `json:"level"`
+	StreakFreezes       int        `json:"streak_freezes"`
 	Timezone            string     `json:"timezone"`
 	NotificationEnabled bool       `json:"notification_enabled"`
 	MorningReminderTime string     `json:"morning_reminder_time"`
 	EveningReminderTime string     `json:"evening_reminder_time"`
-	FCMToken            *string    `json:"fcm_token,omitempty"`
+	QuietHoursStart     *string    `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd       *string    `json:"quiet_hours_end,omitempty"`
 	CreatedAt           time.Time  `json:"created_at"`
 	UpdatedAt           time.Time  `json:"updated_at"`
 	DeletedAt           *time.Time `json:"deleted_at,omitempty"`
@@ -43,9 +50,11 @@ type UserUpdateRequest struct {
 
 // UserSettingsRequest represents notification settings update
 type UserSettingsRequest struct {
-	NotificationEnabled *bool   `json:"notification_enabled,omitempty"`
+	NotificationEnabled *bool   `json:"notification_enabled,omitempty"` // false turns push off for every type
 	MorningReminderTime *string `json:"morning_reminder_time,omitempty"`
 	EveningReminderTime *string `json:"evening_reminder_time,omitempty"`
+	QuietHoursStart     *string `json:"quiet_hours_start,omitempty"` // HH:MM, empty to turn quiet hours off
+	QuietHoursEnd       *string `json:"quiet_hours_end,omitempty"`
 	FCMToken            *string `json:"fcm_token,omitempty"`
 }
 
@@ -57,10 +66,13 @@ type UserResponse struct {
 	AvatarURL           *string   `json:"avatar_url,omitempty"`
 	XP                  int       `json:"xp"`
 	Level               int       `json:"level"`
+	StreakFreezes       int       `json:"streak_freezes"`
 	Timezone            string    `json:"timezone"`
 	NotificationEnabled bool      `json:"notification_enabled"`
 	MorningReminderTime string    `json:"morning_reminder_time"`
 	EveningReminderTime string    `json:"evening_reminder_time"`
+	QuietHoursStart     *string   `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd       *string   `json:"quiet_hours_end,omitempty"`
 	CreatedAt           time.Time `json:"created_at"`
 }
 
@@ -73,10 +85,26 @@ func (u *User) ToResponse() *UserResponse {
 		AvatarURL:           u.AvatarURL,
 		XP:                  u.XP,
 		Level:               u.Level,
+		StreakFreezes:       u.StreakFreezes,
 		Timezone:            u.Timezone,
 		NotificationEnabled: u.NotificationEnabled,
 		MorningReminderTime: u.MorningReminderTime,
 		EveningReminderTime: u.EveningReminderTime,
+		QuietHoursStart:     u.QuietHoursStart,
+		QuietHoursEnd:       u.QuietHoursEnd,
 		CreatedAt:           u.CreatedAt,
 	}
 }
+
+// ValidateQuietHours checks that quiet hours are either off or have a valid
+// start and end
+func (u *User) ValidateQuietHours() error {
+	return validateQuietHours(u.QuietHoursStart, u.QuietHoursEnd)
+}
+
+// QuietHoursAt reports whether t falls within the user's quiet hours in loc
+// and, if so, when they end. Quiet hours may run past midnight, e.g. 22:00
+// to 07:00.
+func (u *User) QuietHoursAt(t time.Time, loc *time.Location) (time.Time, bool) {
+	return quietHoursAt(u.QuietHoursStart, u.QuietHoursEnd, t, loc)
+}
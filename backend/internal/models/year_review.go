@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxYearNarrativeLength bounds the AI-written narrative of a year review
+const MaxYearNarrativeLength = 2000
+
+// YearReview is a user's year in review: trends across the year's monthly
+// reports and logs, and an AI-written narrative of them
+type YearReview struct {
+	ID              uuid.UUID       `json:"id"`
+	UserID          uuid.UUID       `json:"user_id"`
+	Year            int             `json:"year"`
+	Content         json.RawMessage `json:"content"` // YearReviewContent
+	Narrative       string          `json:"narrative"`
+	NarrativeSource ReportSource    `json:"narrative_source"`
+	NarrativeModel  string          `json:"narrative_model"`
+	GeneratedAt     time.Time       `json:"generated_at"`
+}
+
+// HabitTrend tells whether a habit's completion rate rose or fell over the year
+type HabitTrend string
+
+const (
+	HabitTrendImproving HabitTrend = "improving"
+	HabitTrendSteady    HabitTrend = "steady"
+	HabitTrendDeclining HabitTrend = "declining"
+)
+
+// YearReviewContent is the data of a year review
+type YearReviewContent struct {
+	Year               int                `json:"year"`
+	MonthsReported     int                `json:"months_reported"`
+	OverallCompletion  float64            `json:"overall_completion"` // average of the monthly averages
+	Months             []YearReviewMonth  `json:"months"`
+	Habits             []*YearReviewHabit `json:"habits"`
+	SkillsLearned      []string           `json:"skills_learned"` // deduplicated across the monthly reports
+	TotalSkillsLearned int                `json:"total_skills_learned"`
+	HabitsStarted      []YearReviewEvent  `json:"habits_started"`
+	HabitsAbandoned    []YearReviewEvent  `json:"habits_abandoned"` // deleted or paused during the year
+}
+
+// YearReviewMonth is a completion rate in one month, YYYY-MM
+type YearReviewMonth struct {
+	Month          string  `json:"month"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+// YearReviewHabit is how one habit went over the year. The best and worst
+// months are only known for habits in at least one monthly report.
+type YearReviewHabit struct {
+	HabitID       uuid.UUID        `json:"habit_id"`
+	Title         string           `json:"title"`
+	Category      string           `json:"category"`
+	Completions   int              `json:"completions"`
+	LongestStreak int              `json:"longest_streak"` // within the year
+	BestMonth     *YearReviewMonth `json:"best_month,omitempty"`
+	WorstMonth    *YearReviewMonth `json:"worst_month,omitempty"`
+	Trend         HabitTrend       `json:"trend,omitempty"`
+}
+
+// YearReviewEvent is a habit started or abandoned on a date, YYYY-MM-DD
+type YearReviewEvent struct {
+	HabitID uuid.UUID `json:"habit_id"`
+	Title   string    `json:"title"`
+	Date    string    `json:"date"`
+}
+
+// HabitActivity is what a habit did within a range of dates, as logged
+type HabitActivity struct {
+	Habit         *Habit
+	Completions   int
+	LongestStreak int
+}
+
+// YearNarrativeGeneration is a freshly written year narrative and how it
+// was made
+type YearNarrativeGeneration struct {
+	Text   string
+	Source ReportSource
+	Model  string
+	Calls  []*LLMCall // model calls made, for usage accounting
+}
+
+// YearReviewResponse is the API response for a year review
+type YearReviewResponse struct {
+	*YearReviewContent
+	Narrative       string       `json:"narrative"`
+	NarrativeSource ReportSource `json:"narrative_source"`
+	GeneratedAt     time.Time    `json:"generated_at"`
+	ShareURL        string       `json:"share_url,omitempty"`
+}
+
+// ToResponse converts YearReview to YearReviewResponse
+func (r *YearReview) ToResponse() (*YearReviewResponse, error) {
+	var content YearReviewContent
+	if err := json.Unmarshal(r.Content, &content); err != nil {
+		return nil, err
+	}
+
+	return &YearReviewResponse{
+		YearReviewContent: &content,
+		Narrative:         r.Narrative,
+		NarrativeSource:   r.NarrativeSource,
+		GeneratedAt:       r.GeneratedAt,
+	}, nil
+}
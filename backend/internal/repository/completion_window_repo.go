@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CompletionWindowRepository handles the learned completion windows of habits
+type CompletionWindowRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewCompletionWindowRepository creates a new CompletionWindowRepository
+func NewCompletionWindowRepository(db *pgxpool.Pool) *CompletionWindowRepository {
+	return &CompletionWindowRepository{db: db}
+}
+
+// Upsert stores a habit's latest completion window, replacing the previous one
+func (r *CompletionWindowRepository) Upsert(ctx context.Context, window *models.CompletionWindow) error {
+	query := `
+		INSERT INTO habit_completion_windows (
+			habit_id, user_id, window_start, window_end, typical_time, nudge_time,
+			sample_size, computed_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+		ON CONFLICT (habit_id) DO UPDATE SET
+			window_start = EXCLUDED.window_start,
+			window_end = EXCLUDED.window_end,
+			typical_time = EXCLUDED.typical_time,
+			nudge_time = EXCLUDED.nudge_time,
+			sample_size = EXCLUDED.sample_size,
+			computed_at = EXCLUDED.computed_at
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		window.HabitID,
+		window.UserID,
+		window.WindowStart,
+		window.WindowEnd,
+		window.TypicalTime,
+		window.NudgeTime,
+		window.SampleSize,
+		window.ComputedAt,
+	)
+
+	return err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DeviceRepository handles push device database operations
+type DeviceRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewDeviceRepository creates a new DeviceRepository
+func NewDeviceRepository(db *pgxpool.Pool) *DeviceRepository {
+	return &DeviceRepository{db: db}
+}
+
+// Upsert registers a device token for a user, refreshing its details if the
+// token is known and moving it over if another user registered it before
+func (r *DeviceRepository) Upsert(ctx context.Context, device *models.UserDevice) error {
+	query := `
+		INSERT INTO user_devices (
+			id, user_id, fcm_token, platform, app_version, locale,
+			created_at, last_registered_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $7
+		)
+		ON CONFLICT (fcm_token) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			platform = COALESCE(EXCLUDED.platform, user_devices.platform),
+			app_version = COALESCE(EXCLUDED.app_version, user_devices.app_version),
+			locale = COALESCE(EXCLUDED.locale, user_devices.locale),
+			last_registered_at = EXCLUDED.last_registered_at
+		RETURNING id, created_at, last_registered_at
+	`
+
+	return r.db.QueryRow(ctx, query,
+		uuid.New(),
+		device.UserID,
+		device.FCMToken,
+		device.Platform,
+		device.AppVersion,
+		device.Locale,
+		time.Now(),
+	).Scan(&device.ID, &device.CreatedAt, &device.LastRegisteredAt)
+}
+
+// GetByUserID retrieves a user's devices, most recently registered first
+func (r *DeviceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.UserDevice, error) {
+	query := `
+		SELECT id, user_id, fcm_token, platform, app_version, locale,
+			created_at, last_registered_at
+		FROM user_devices
+		WHERE user_id = $1
+		ORDER BY last_registered_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []*models.UserDevice
+	for rows.Next() {
+		device := &models.UserDevice{}
+		err := rows.Scan(
+			&device.ID,
+			&device.UserID,
+			&device.FCMToken,
+			&device.Platform,
+			&device.AppVersion,
+			&device.Locale,
+			&device.CreatedAt,
+			&device.LastRegisteredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	return devices, rows.Err()
+}
+
+// DeleteByToken removes a device token, e.g. one FCM reported as dead
+func (r *DeviceRepository) DeleteByToken(ctx context.Context, fcmToken string) error {
+	query := `DELETE FROM user_devices WHERE fcm_token = $1`
+
+	_, err := r.db.Exec(ctx, query, fcmToken)
+	return err
+}
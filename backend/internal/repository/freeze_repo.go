@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNoStreakFreezes  = errors.New("no streak freezes left")
+	ErrAlreadyFrozen    = errors.New("day already frozen")
+	ErrVacationNotFound = errors.New("vacation not found")
+)
+
+// FreezeRepository handles streak freeze and vacation database operations
+type FreezeRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewFreezeRepository creates a new FreezeRepository
+func NewFreezeRepository(db *pgxpool.Pool) *FreezeRepository {
+	return &FreezeRepository{db: db}
+}
+
+// Create spends one of the user's streak freezes on a habit's day and returns
+// the number of freezes left
+func (r *FreezeRepository) Create(ctx context.Context, freeze *models.StreakFreeze) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	spendQuery := `
+		UPDATE users SET streak_freezes = streak_freezes - 1
+		WHERE id = $1 AND streak_freezes > 0 AND deleted_at IS NULL
+		RETURNING streak_freezes
+	`
+
+	var remaining int
+	err = tx.QueryRow(ctx, spendQuery, freeze.UserID).Scan(&remaining)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNoStreakFreezes
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	insertQuery := `
+		INSERT INTO habit_freezes (id, habit_id, user_id, freeze_date, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (habit_id, freeze_date) DO NOTHING
+	`
+
+	freeze.ID = uuid.New()
+	freeze.CreatedAt = time.Now()
+
+	result, err := tx.Exec(ctx, insertQuery,
+		freeze.ID,
+		freeze.HabitID,
+		freeze.UserID,
+		freeze.FreezeDate,
+		freeze.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.RowsAffected() == 0 {
+		return 0, ErrAlreadyFrozen
+	}
+
+	return remaining, tx.Commit(ctx)
+}
+
+// GetByUserAndDateRange retrieves a user's frozen days within a date range
+func (r *FreezeRepository) GetByUserAndDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]*models.StreakFreeze, error) {
+	query := `
+		SELECT id, habit_id, user_id, freeze_date, created_at
+		FROM habit_freezes
+		WHERE user_id = $1 AND freeze_date >= $2 AND freeze_date <= $3
+		ORDER BY freeze_date ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var freezes []*models.StreakFreeze
+	for rows.Next() {
+		freeze := &models.StreakFreeze{}
+		err := rows.Scan(
+			&freeze.ID,
+			&freeze.HabitID,
+			&freeze.UserID,
+			&freeze.FreezeDate,
+			&freeze.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		freeze.FreezeDate = models.DateOf(freeze.FreezeDate, time.UTC)
+		freezes = append(freezes, freeze)
+	}
+
+	return freezes, rows.Err()
+}
+
+// CreateVacation declares a range of rest days for a user
+func (r *FreezeRepository) CreateVacation(ctx context.Context, vacation *models.Vacation) error {
+	query := `
+		INSERT INTO user_vacations (id, user_id, start_date, end_date, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	vacation.ID = uuid.New()
+	vacation.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		vacation.ID,
+		vacation.UserID,
+		vacation.StartDate,
+		vacation.EndDate,
+		vacation.Note,
+		vacation.CreatedAt,
+	)
+
+	return err
+}
+
+// GetVacations retrieves a user's vacations, latest first
+func (r *FreezeRepository) GetVacations(ctx context.Context, userID uuid.UUID) ([]*models.Vacation, error) {
+	query := `
+		SELECT id, user_id, start_date, end_date, note, created_at
+		FROM user_vacations
+		WHERE user_id = $1
+		ORDER BY start_date DESC
+	`
+
+	return r.queryVacations(ctx, query, userID)
+}
+
+// GetVacationsInRange retrieves a user's vacations overlapping a date range
+func (r *FreezeRepository) GetVacationsInRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]*models.Vacation, error) {
+	query := `
+		SELECT id, user_id, start_date, end_date, note, created_at
+		FROM user_vacations
+		WHERE user_id = $1 AND start_date <= $3 AND end_date >= $2
+		ORDER BY start_date ASC
+	`
+
+	return r.queryVacations(ctx, query, userID, startDate, endDate)
+}
+
+// DeleteVacation deletes a user's vacation
+func (r *FreezeRepository) DeleteVacation(ctx context.Context, id, userID uuid.UUID) error {
+	query := `DELETE FROM user_vacations WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrVacationNotFound
+	}
+
+	return nil
+}
+
+// queryVacations runs a query selecting vacation rows
+func (r *FreezeRepository) queryVacations(ctx context.Context, query string, args ...interface{}) ([]*models.Vacation, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vacations []*models.Vacation
+	for rows.Next() {
+		vacation := &models.Vacation{}
+		err := rows.Scan(
+			&vacation.ID,
+			&vacation.UserID,
+			&vacation.StartDate,
+			&vacation.EndDate,
+			&vacation.Note,
+			&vacation.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		vacation.StartDate = models.DateOf(vacation.StartDate, time.UTC)
+		vacation.EndDate = models.DateOf(vacation.EndDate, time.UTC)
+		vacations = append(vacations, vacation)
+	}
+
+	return vacations, rows.Err()
+}
@@ -29,11 +29,11 @@ func NewHabitRepository(db *pgxpool.Pool) *HabitRepository {
 func (r *HabitRepository) Create(ctx context.Context, habit *models.Habit) error {
 	query := `
 		INSERT INTO habits (
-			id, user_id, title, description, category, frequency,
-			is_active, is_learning_habit, color, icon, reminder_time,
-			created_at, updated_at
+			id, user_id, title, description, category, frequency, schedule,
+			target_value, unit, is_active, is_learning_habit, color, icon,
+			reminder_time, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
 		)
 	`
 
@@ -48,6 +48,9 @@ func (r *HabitRepository) Create(ctx context.Context, habit *models.Habit) error
 		habit.Description,
 		habit.Category,
 		habit.Frequency,
+		habit.Schedule,
+		habit.TargetValue,
+		habit.Unit,
 		habit.IsActive,
 		habit.IsLearningHabit,
 		habit.Color,
@@ -74,7 +77,8 @@ func (r *HabitRepository) Create(ctx context.Context, habit *models.Habit) error
 // GetByID retrieves a habit by ID
 func (r *HabitRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Habit, error) {
 	query := `
-		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency,
+		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency, h.schedule,
+			h.target_value, h.unit,
 			h.is_active, h.is_learning_habit, h.color, h.icon, h.reminder_time,
 			h.created_at, h.updated_at, h.deleted_at,
 			COALESCE(s.current_streak, 0), COALESCE(s.longest_streak, 0)
@@ -91,6 +95,9 @@ func (r *HabitRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Ha
 		&habit.Description,
 		&habit.Category,
 		&habit.Frequency,
+		&habit.Schedule,
+		&habit.TargetValue,
+		&habit.Unit,
 		&habit.IsActive,
 		&habit.IsLearningHabit,
 		&habit.Color,
@@ -113,7 +120,8 @@ func (r *HabitRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Ha
 // GetByUserID retrieves all habits for a user
 func (r *HabitRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Habit, error) {
 	query := `
-		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency,
+		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency, h.schedule,
+			h.target_value, h.unit,
 			h.is_active, h.is_learning_habit, h.color, h.icon, h.reminder_time,
 			h.created_at, h.updated_at, h.deleted_at,
 			COALESCE(s.current_streak, 0), COALESCE(s.longest_streak, 0)
@@ -139,6 +147,9 @@ func (r *HabitRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]
 			&habit.Description,
 			&habit.Category,
 			&habit.Frequency,
+			&habit.Schedule,
+			&habit.TargetValue,
+			&habit.Unit,
 			&habit.IsActive,
 			&habit.IsLearningHabit,
 			&habit.Color,
@@ -162,7 +173,8 @@ func (r *HabitRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]
 // GetActiveByUserID retrieves all active habits for a user
 func (r *HabitRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Habit, error) {
 	query := `
-		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency,
+		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency, h.schedule,
+			h.target_value, h.unit,
 			h.is_active, h.is_learning_habit, h.color, h.icon, h.reminder_time,
 			h.created_at, h.updated_at, h.deleted_at,
 			COALESCE(s.current_streak, 0), COALESCE(s.longest_streak, 0)
@@ -188,6 +200,9 @@ func (r *HabitRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUI
 			&habit.Description,
 			&habit.Category,
 			&habit.Frequency,
+			&habit.Schedule,
+			&habit.TargetValue,
+			&habit.Unit,
 			&habit.IsActive,
 			&habit.IsLearningHabit,
 			&habit.Color,
@@ -216,12 +231,15 @@ func (r *HabitRepository) Update(ctx context.Context, habit *models.Habit) error
 			description = $3,
 			category = $4,
 			frequency = $5,
-			is_active = $6,
-			is_learning_habit = $7,
-			color = $8,
-			icon = $9,
-			reminder_time = $10,
-			updated_at = $11
+			schedule = $6,
+			target_value = $7,
+			unit = $8,
+			is_active = $9,
+			is_learning_habit = $10,
+			color = $11,
+			icon = $12,
+			reminder_time = $13,
+			updated_at = $14
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
@@ -233,6 +251,9 @@ func (r *HabitRepository) Update(ctx context.Context, habit *models.Habit) error
 		habit.Description,
 		habit.Category,
 		habit.Frequency,
+		habit.Schedule,
+		habit.TargetValue,
+		habit.Unit,
 		habit.IsActive,
 		habit.IsLearningHabit,
 		habit.Color,
@@ -276,7 +297,8 @@ func (r *HabitRepository) SoftDelete(ctx context.Context, id uuid.UUID) error {
 // GetByIDAndUserID retrieves a habit by ID and user ID (for authorization)
 func (r *HabitRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.UUID) (*models.Habit, error) {
 	query := `
-		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency,
+		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency, h.schedule,
+			h.target_value, h.unit,
 			h.is_active, h.is_learning_habit, h.color, h.icon, h.reminder_time,
 			h.created_at, h.updated_at, h.deleted_at,
 			COALESCE(s.current_streak, 0), COALESCE(s.longest_streak, 0)
@@ -293,6 +315,9 @@ func (r *HabitRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.
 		&habit.Description,
 		&habit.Category,
 		&habit.Frequency,
+		&habit.Schedule,
+		&habit.TargetValue,
+		&habit.Unit,
 		&habit.IsActive,
 		&habit.IsLearningHabit,
 		&habit.Color,
@@ -315,7 +340,8 @@ func (r *HabitRepository) GetByIDAndUserID(ctx context.Context, id, userID uuid.
 // GetUpdatedSince retrieves habits updated since a given time (for sync)
 func (r *HabitRepository) GetUpdatedSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.Habit, error) {
 	query := `
-		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency,
+		SELECT h.id, h.user_id, h.title, h.description, h.category, h.frequency, h.schedule,
+			h.target_value, h.unit,
 			h.is_active, h.is_learning_habit, h.color, h.icon, h.reminder_time,
 			h.created_at, h.updated_at, h.deleted_at,
 			COALESCE(s.current_streak, 0), COALESCE(s.longest_streak, 0)
@@ -341,6 +367,9 @@ func (r *HabitRepository) GetUpdatedSince(ctx context.Context, userID uuid.UUID,
 			&habit.Description,
 			&habit.Category,
 			&habit.Frequency,
+			&habit.Schedule,
+			&habit.TargetValue,
+			&habit.Unit,
 			&habit.IsActive,
 			&habit.IsLearningHabit,
 			&habit.Color,
@@ -360,3 +389,66 @@ func (r *HabitRepository) GetUpdatedSince(ctx context.Context, userID uuid.UUID,
 
 	return habits, rows.Err()
 }
+
+// GetWithReminders retrieves a user's active habits that have a reminder
+// time, a snoozed reminder or a reminder time learned from their history
+func (r *HabitRepository) GetWithReminders(ctx context.Context, userID uuid.UUID) ([]*models.Habit, error) {
+	query := `
+		SELECT h.id, h.user_id, h.title, h.frequency, h.schedule, h.reminder_time,
+			h.reminder_snoozed_until, h.reminder_snoozed_at, to_char(w.nudge_time, 'HH24:MI')
+		FROM habits h
+		LEFT JOIN habit_completion_windows w ON h.id = w.habit_id
+		WHERE h.user_id = $1 AND h.is_active = true AND h.deleted_at IS NULL
+			AND (h.reminder_time IS NOT NULL OR h.reminder_snoozed_until IS NOT NULL
+				OR w.nudge_time IS NOT NULL)
+		ORDER BY h.created_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var habits []*models.Habit
+	for rows.Next() {
+		habit := &models.Habit{IsActive: true}
+		err := rows.Scan(
+			&habit.ID,
+			&habit.UserID,
+			&habit.Title,
+			&habit.Frequency,
+			&habit.Schedule,
+			&habit.ReminderTime,
+			&habit.ReminderSnoozedUntil,
+			&habit.ReminderSnoozedAt,
+			&habit.LearnedReminderTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		habits = append(habits, habit)
+	}
+
+	return habits, rows.Err()
+}
+
+// SnoozeReminder moves a habit's next reminder to until, recording when it
+// was snoozed
+func (r *HabitRepository) SnoozeReminder(ctx context.Context, id uuid.UUID, until time.Time) error {
+	query := `
+		UPDATE habits SET reminder_snoozed_until = $2, reminder_snoozed_at = $3
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, id, until, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrHabitNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrJobRunNotFound = errors.New("job run not found")
+)
+
+// JobRunRepository handles background job run history
+type JobRunRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewJobRunRepository creates a new JobRunRepository
+func NewJobRunRepository(db *pgxpool.Pool) *JobRunRepository {
+	return &JobRunRepository{db: db}
+}
+
+// Create records the start of a job run
+func (r *JobRunRepository) Create(ctx context.Context, run *models.JobRun) error {
+	query := `
+		INSERT INTO job_runs (
+			id, job_name, instance, status, window_start, window_end, started_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+	`
+
+	run.ID = uuid.New()
+	run.Status = models.JobRunRunning
+	run.StartedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		run.ID,
+		run.JobName,
+		run.Instance,
+		run.Status,
+		run.WindowStart,
+		run.WindowEnd,
+		run.StartedAt,
+	)
+
+	return err
+}
+
+// Finish records the outcome of a job run
+func (r *JobRunRepository) Finish(ctx context.Context, run *models.JobRun) error {
+	query := `
+		UPDATE job_runs SET
+			status = $2,
+			items_processed = $3,
+			error = $4,
+			finished_at = $5
+		WHERE id = $1
+	`
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+
+	_, err := r.db.Exec(ctx, query,
+		run.ID,
+		run.Status,
+		run.ItemsProcessed,
+		run.Error,
+		run.FinishedAt,
+	)
+
+	return err
+}
+
+// GetLastSucceeded retrieves the most recent successful run of a job
+func (r *JobRunRepository) GetLastSucceeded(ctx context.Context, jobName string) (*models.JobRun, error) {
+	query := `
+		SELECT id, job_name, instance, status, window_start, window_end,
+			items_processed, error, started_at, finished_at
+		FROM job_runs
+		WHERE job_name = $1 AND status = $2
+		ORDER BY window_end DESC
+		LIMIT 1
+	`
+
+	run := &models.JobRun{}
+	err := r.db.QueryRow(ctx, query, jobName, models.JobRunSucceeded).Scan(
+		&run.ID,
+		&run.JobName,
+		&run.Instance,
+		&run.Status,
+		&run.WindowStart,
+		&run.WindowEnd,
+		&run.ItemsProcessed,
+		&run.Error,
+		&run.StartedAt,
+		&run.FinishedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrJobRunNotFound
+	}
+
+	return run, err
+}
+
+// DeleteOlderThan prunes job run history started before a cutoff
+func (r *JobRunRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM job_runs WHERE started_at < $1`
+
+	result, err := r.db.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LLMCallRepository handles language model usage records
+type LLMCallRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewLLMCallRepository creates a new LLMCallRepository
+func NewLLMCallRepository(db *pgxpool.Pool) *LLMCallRepository {
+	return &LLMCallRepository{db: db}
+}
+
+// Create records a language model call
+func (r *LLMCallRepository) Create(ctx context.Context, call *models.LLMCall) error {
+	query := `
+		INSERT INTO llm_calls (
+			id, user_id, provider, model, purpose, prompt_tokens, response_tokens,
+			latency_ms, error, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)
+	`
+
+	call.ID = uuid.New()
+	call.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		call.ID,
+		call.UserID,
+		call.Provider,
+		call.Model,
+		call.Purpose,
+		call.PromptTokens,
+		call.ResponseTokens,
+		call.LatencyMs,
+		call.Error,
+		call.CreatedAt,
+	)
+
+	return err
+}
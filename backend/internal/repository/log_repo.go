@@ -25,17 +25,19 @@ func NewLogRepository(db *pgxpool.Pool) *LogRepository {
 	return &LogRepository{db: db}
 }
 
-// CreateOrUpdate creates or updates a daily log (upsert)
+// CreateOrUpdate creates or updates a daily log (upsert) and links it to the
+// #tags in its learning note
 func (r *LogRepository) CreateOrUpdate(ctx context.Context, log *models.DailyLog) error {
 	query := `
 		INSERT INTO daily_logs (
-			id, habit_id, user_id, log_date, completed, learning_note,
+			id, habit_id, user_id, log_date, completed, value, learning_note,
 			completed_at, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 		)
 		ON CONFLICT (habit_id, log_date) DO UPDATE SET
 			completed = EXCLUDED.completed,
+			value = EXCLUDED.value,
 			learning_note = EXCLUDED.learning_note,
 			completed_at = CASE
 				WHEN EXCLUDED.completed = true AND daily_logs.completed = false
@@ -57,25 +59,113 @@ func (r *LogRepository) CreateOrUpdate(ctx context.Context, log *models.DailyLog
 		log.CompletedAt = &now
 	}
 
-	err := r.db.QueryRow(ctx, query,
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query,
 		log.ID,
 		log.HabitID,
 		log.UserID,
 		log.LogDate,
 		log.Completed,
+		log.Value,
 		log.LearningNote,
 		log.CompletedAt,
 		log.CreatedAt,
 		log.UpdatedAt,
 	).Scan(&log.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := syncNoteTags(ctx, tx, log); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// syncNoteTags replaces the tags a log is linked to with those in its
+// learning note, creating the user's tags that do not exist yet
+func syncNoteTags(ctx context.Context, tx pgx.Tx, log *models.DailyLog) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM daily_log_tags WHERE log_id = $1`, log.ID); err != nil {
+		return err
+	}
+
+	var tags []string
+	if log.LearningNote != nil {
+		tags = models.ExtractNoteTags(*log.LearningNote)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	createQuery := `
+		INSERT INTO note_tags (user_id, name)
+		SELECT $1, UNNEST($2::text[])
+		ON CONFLICT (user_id, name) DO NOTHING
+	`
+
+	if _, err := tx.Exec(ctx, createQuery, log.UserID, tags); err != nil {
+		return err
+	}
+
+	linkQuery := `
+		INSERT INTO daily_log_tags (log_id, tag_id)
+		SELECT $1, id FROM note_tags
+		WHERE user_id = $2 AND name = ANY($3::text[])
+	`
 
+	_, err := tx.Exec(ctx, linkQuery, log.ID, log.UserID, tags)
 	return err
 }
 
+// IncrementValue atomically adds amount to a log's value, creating the log
+// if needed, and marks it completed once the value reaches target. It
+// returns the log's value and completion after the increment.
+func (r *LogRepository) IncrementValue(ctx context.Context, log *models.DailyLog, amount, target float64) error {
+	query := `
+		INSERT INTO daily_logs (
+			id, habit_id, user_id, log_date, completed, value,
+			completed_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5 >= $6, $5,
+			CASE WHEN $5 >= $6 THEN $7::timestamptz END, $7, $7
+		)
+		ON CONFLICT (habit_id, log_date) DO UPDATE SET
+			value = COALESCE(daily_logs.value, 0) + EXCLUDED.value,
+			completed = COALESCE(daily_logs.value, 0) + EXCLUDED.value >= $6,
+			completed_at = CASE
+				WHEN daily_logs.completed = false AND COALESCE(daily_logs.value, 0) + EXCLUDED.value >= $6
+				THEN EXCLUDED.updated_at
+				ELSE daily_logs.completed_at
+			END,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, value, completed, learning_note, completed_at, created_at
+	`
+
+	now := time.Now()
+	log.ID = uuid.New()
+	log.UpdatedAt = now
+
+	return r.db.QueryRow(ctx, query,
+		log.ID,
+		log.HabitID,
+		log.UserID,
+		log.LogDate,
+		amount,
+		target,
+		now,
+	).Scan(&log.ID, &log.Value, &log.Completed, &log.LearningNote, &log.CompletedAt, &log.CreatedAt)
+}
+
 // GetByID retrieves a daily log by ID
 func (r *LogRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DailyLog, error) {
 	query := `
-		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed,
+		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed, dl.value,
 			dl.learning_note, dl.completed_at, dl.created_at, dl.updated_at,
 			h.title
 		FROM daily_logs dl
@@ -90,6 +180,7 @@ func (r *LogRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Dail
 		&log.UserID,
 		&log.LogDate,
 		&log.Completed,
+		&log.Value,
 		&log.LearningNote,
 		&log.CompletedAt,
 		&log.CreatedAt,
@@ -107,7 +198,7 @@ func (r *LogRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Dail
 // GetByHabitAndDate retrieves a daily log by habit ID and date
 func (r *LogRepository) GetByHabitAndDate(ctx context.Context, habitID uuid.UUID, logDate time.Time) (*models.DailyLog, error) {
 	query := `
-		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed,
+		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed, dl.value,
 			dl.learning_note, dl.completed_at, dl.created_at, dl.updated_at,
 			h.title
 		FROM daily_logs dl
@@ -122,6 +213,7 @@ func (r *LogRepository) GetByHabitAndDate(ctx context.Context, habitID uuid.UUID
 		&log.UserID,
 		&log.LogDate,
 		&log.Completed,
+		&log.Value,
 		&log.LearningNote,
 		&log.CompletedAt,
 		&log.CreatedAt,
@@ -139,7 +231,7 @@ func (r *LogRepository) GetByHabitAndDate(ctx context.Context, habitID uuid.UUID
 // GetByUserAndDateRange retrieves daily logs for a user within a date range
 func (r *LogRepository) GetByUserAndDateRange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) ([]*models.DailyLog, error) {
 	query := `
-		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed,
+		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed, dl.value,
 			dl.learning_note, dl.completed_at, dl.created_at, dl.updated_at,
 			h.title
 		FROM daily_logs dl
@@ -163,6 +255,7 @@ func (r *LogRepository) GetByUserAndDateRange(ctx context.Context, userID uuid.U
 			&log.UserID,
 			&log.LogDate,
 			&log.Completed,
+			&log.Value,
 			&log.LearningNote,
 			&log.CompletedAt,
 			&log.CreatedAt,
@@ -178,16 +271,15 @@ func (r *LogRepository) GetByUserAndDateRange(ctx context.Context, userID uuid.U
 	return logs, rows.Err()
 }
 
-// GetTodayLogs retrieves today's logs for a user
-func (r *LogRepository) GetTodayLogs(ctx context.Context, userID uuid.UUID) ([]*models.DailyLog, error) {
-	today := time.Now().Truncate(24 * time.Hour)
+// GetTodayLogs retrieves a user's logs for their current calendar date
+func (r *LogRepository) GetTodayLogs(ctx context.Context, userID uuid.UUID, today time.Time) ([]*models.DailyLog, error) {
 	return r.GetByUserAndDateRange(ctx, userID, today, today)
 }
 
 // GetByHabit retrieves all logs for a specific habit
 func (r *LogRepository) GetByHabit(ctx context.Context, habitID uuid.UUID, limit, offset int) ([]*models.DailyLog, error) {
 	query := `
-		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed,
+		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed, dl.value,
 			dl.learning_note, dl.completed_at, dl.created_at, dl.updated_at,
 			h.title
 		FROM daily_logs dl
@@ -212,6 +304,7 @@ func (r *LogRepository) GetByHabit(ctx context.Context, habitID uuid.UUID, limit
 			&log.UserID,
 			&log.LogDate,
 			&log.Completed,
+			&log.Value,
 			&log.LearningNote,
 			&log.CompletedAt,
 			&log.CreatedAt,
@@ -227,82 +320,23 @@ func (r *LogRepository) GetByHabit(ctx context.Context, habitID uuid.UUID, limit
 	return logs, rows.Err()
 }
 
-// GetCalendarData retrieves calendar data for a specific month
-func (r *LogRepository) GetCalendarData(ctx context.Context, userID uuid.UUID, year, month int) ([]*models.CalendarDayData, error) {
-	query := `
-		WITH active_habits AS (
-			SELECT COUNT(*) as total
-			FROM habits
-			WHERE user_id = $1 AND is_active = true AND deleted_at IS NULL
-		),
-		daily_stats AS (
-			SELECT
-				dl.log_date,
-				COUNT(*) FILTER (WHERE dl.completed = true) as completed_count
-			FROM daily_logs dl
-			JOIN habits h ON dl.habit_id = h.id
-			WHERE dl.user_id = $1
-				AND EXTRACT(YEAR FROM dl.log_date) = $2
-				AND EXTRACT(MONTH FROM dl.log_date) = $3
-				AND h.is_active = true
-				AND h.deleted_at IS NULL
-			GROUP BY dl.log_date
-		)
-		SELECT
-			ds.log_date::date as date,
-			ah.total as total_habits,
-			ds.completed_count,
-			CASE
-				WHEN ah.total > 0 THEN ROUND((ds.completed_count::float / ah.total) * 100)
-				ELSE 0
-			END as percentage
-		FROM daily_stats ds
-		CROSS JOIN active_habits ah
-		ORDER BY ds.log_date
-	`
-
-	rows, err := r.db.Query(ctx, query, userID, year, month)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var data []*models.CalendarDayData
-	for rows.Next() {
-		day := &models.CalendarDayData{}
-		var date time.Time
-		err := rows.Scan(
-			&date,
-			&day.TotalHabits,
-			&day.CompletedCount,
-			&day.Percentage,
-		)
-		if err != nil {
-			return nil, err
-		}
-		day.Date = date.Format("2006-01-02")
-		data = append(data, day)
-	}
-
-	return data, rows.Err()
-}
-
-// GetLearningNotesByUserAndMonth retrieves learning notes for report generation
-func (r *LogRepository) GetLearningNotesByUserAndMonth(ctx context.Context, userID uuid.UUID, year, month int) (map[uuid.UUID][]string, error) {
+// GetLearningNotesByUserAndRange retrieves the learning notes logged from
+// one date to another, for report generation
+func (r *LogRepository) GetLearningNotesByUserAndRange(ctx context.Context, userID uuid.UUID, from, to time.Time) (map[uuid.UUID][]string, error) {
 	query := `
 		SELECT dl.habit_id, dl.learning_note
 		FROM daily_logs dl
 		JOIN habits h ON dl.habit_id = h.id
 		WHERE dl.user_id = $1
-			AND EXTRACT(YEAR FROM dl.log_date) = $2
-			AND EXTRACT(MONTH FROM dl.log_date) = $3
+			AND dl.log_date >= $2
+			AND dl.log_date <= $3
 			AND dl.learning_note IS NOT NULL
 			AND dl.learning_note != ''
 			AND h.is_learning_habit = true
 		ORDER BY dl.log_date ASC
 	`
 
-	rows, err := r.db.Query(ctx, query, userID, year, month)
+	rows, err := r.db.Query(ctx, query, userID, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -345,7 +379,7 @@ func (r *LogRepository) GetCompletionRateByHabitAndMonth(ctx context.Context, ha
 // GetUpdatedSince retrieves logs updated since a given time (for sync)
 func (r *LogRepository) GetUpdatedSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.DailyLog, error) {
 	query := `
-		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed,
+		SELECT dl.id, dl.habit_id, dl.user_id, dl.log_date, dl.completed, dl.value,
 			dl.learning_note, dl.completed_at, dl.created_at, dl.updated_at,
 			h.title
 		FROM daily_logs dl
@@ -369,6 +403,7 @@ func (r *LogRepository) GetUpdatedSince(ctx context.Context, userID uuid.UUID, s
 			&log.UserID,
 			&log.LogDate,
 			&log.Completed,
+			&log.Value,
 			&log.LearningNote,
 			&log.CompletedAt,
 			&log.CreatedAt,
@@ -384,9 +419,8 @@ func (r *LogRepository) GetUpdatedSince(ctx context.Context, userID uuid.UUID, s
 	return logs, rows.Err()
 }
 
-// CheckTodayCompleted checks if a habit is completed for today
-func (r *LogRepository) CheckTodayCompleted(ctx context.Context, habitID uuid.UUID) (bool, error) {
-	today := time.Now().Truncate(24 * time.Hour)
+// CheckTodayCompleted checks if a habit is completed on the user's current calendar date
+func (r *LogRepository) CheckTodayCompleted(ctx context.Context, habitID uuid.UUID, today time.Time) (bool, error) {
 	query := `
 		SELECT completed FROM daily_logs
 		WHERE habit_id = $1 AND log_date = $2
@@ -401,3 +435,31 @@ func (r *LogRepository) CheckTodayCompleted(ctx context.Context, habitID uuid.UU
 
 	return completed, err
 }
+
+// GetCompletionTimes retrieves the log dates and completion times of a
+// habit's completed logs since startDate
+func (r *LogRepository) GetCompletionTimes(ctx context.Context, habitID uuid.UUID, startDate time.Time) ([]*models.DailyLog, error) {
+	query := `
+		SELECT log_date, completed_at
+		FROM daily_logs
+		WHERE habit_id = $1 AND completed = true AND completed_at IS NOT NULL AND log_date >= $2
+		ORDER BY log_date ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, habitID, startDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []*models.DailyLog
+	for rows.Next() {
+		log := &models.DailyLog{HabitID: habitID, Completed: true}
+		if err := rows.Scan(&log.LogDate, &log.CompletedAt); err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Markers ts_headline wraps around search matches. They are control
+// characters, which survive HTML escaping unchanged, so the markers can be
+// swapped for tags once the rest of the headline is escaped.
+const (
+	NoteHighlightStart = "\x02"
+	NoteHighlightStop  = "\x03"
+)
+
+// noteHeadlineOptions configures the fragments ts_headline picks from a note
+var noteHeadlineOptions = `StartSel="` + NoteHighlightStart + `", StopSel="` + NoteHighlightStop +
+	`", MaxFragments=3, MinWords=5, MaxWords=20, FragmentDelimiter=" … "`
+
+// NoteRepository handles learning note search database operations
+type NoteRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewNoteRepository creates a new NoteRepository
+func NewNoteRepository(db *pgxpool.Pool) *NoteRepository {
+	return &NoteRepository{db: db}
+}
+
+// Search finds a user's learning notes matching a filter, best matches first
+// and newest first among equals. Without a query every note passing the
+// other filters matches, newest first, and its highlight is the whole note.
+// It returns a page of results and how many notes matched in total.
+func (r *NoteRepository) Search(ctx context.Context, userID uuid.UUID, filter *models.NoteSearchFilter) ([]*models.NoteSearchResult, int, error) {
+	query := `
+		WITH search AS (
+			SELECT websearch_to_tsquery('english', $2) AS query
+		)
+		SELECT dl.id, dl.habit_id, h.title, dl.log_date, dl.learning_note,
+			CASE WHEN $2 = '' THEN dl.learning_note
+				ELSE ts_headline('english', dl.learning_note, search.query, $3)
+			END,
+			ARRAY(
+				SELECT t.name FROM daily_log_tags dlt
+				JOIN note_tags t ON t.id = dlt.tag_id
+				WHERE dlt.log_id = dl.id
+				ORDER BY t.name
+			),
+			CASE WHEN $2 = '' THEN 0
+				ELSE ts_rank_cd(dl.learning_note_tsv, search.query)
+			END AS rank,
+			COUNT(*) OVER ()
+		FROM daily_logs dl
+		JOIN habits h ON dl.habit_id = h.id
+		CROSS JOIN search
+		WHERE dl.user_id = $1
+			AND COALESCE(dl.learning_note, '') <> ''
+			AND ($2 = '' OR dl.learning_note_tsv @@ search.query)
+			AND ($4::uuid IS NULL OR dl.habit_id = $4)
+			AND ($5::date IS NULL OR dl.log_date >= $5)
+			AND ($6::date IS NULL OR dl.log_date <= $6)
+			AND ($7 = '' OR EXISTS (
+				SELECT 1 FROM daily_log_tags dlt
+				JOIN note_tags t ON t.id = dlt.tag_id
+				WHERE dlt.log_id = dl.id AND t.name = $7
+			))
+		ORDER BY rank DESC, dl.log_date DESC, dl.id
+		LIMIT $8 OFFSET $9
+	`
+
+	rows, err := r.db.Query(ctx, query,
+		userID,
+		filter.Query,
+		noteHeadlineOptions,
+		filter.HabitID,
+		filter.From,
+		filter.To,
+		filter.Tag,
+		filter.Limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []*models.NoteSearchResult
+	var total int
+	for rows.Next() {
+		result := &models.NoteSearchResult{}
+		var logDate time.Time
+		var rank float32
+		if err := rows.Scan(
+			&result.LogID,
+			&result.HabitID,
+			&result.HabitTitle,
+			&logDate,
+			&result.LearningNote,
+			&result.Highlight,
+			&result.Tags,
+			&rank,
+			&total,
+		); err != nil {
+			return nil, 0, err
+		}
+		result.LogDate = logDate.Format(models.DateLayout)
+		result.Rank = float64(rank)
+		results = append(results, result)
+	}
+
+	return results, total, rows.Err()
+}
+
+// GetTags retrieves the tags in a user's learning notes with how many logs
+// each appears in, most used first
+func (r *NoteRepository) GetTags(ctx context.Context, userID uuid.UUID) ([]*models.NoteTag, error) {
+	query := `
+		SELECT t.name, COUNT(*)
+		FROM note_tags t
+		JOIN daily_log_tags dlt ON dlt.tag_id = t.id
+		WHERE t.user_id = $1
+		GROUP BY t.name
+		ORDER BY COUNT(*) DESC, t.name
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*models.NoteTag
+	for rows.Next() {
+		tag := &models.NoteTag{}
+		if err := rows.Scan(&tag.Name, &tag.LogCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotificationPreferenceNotFound = errors.New("notification preference not found")
+)
+
+// NotificationPreferenceRepository handles per-type notification preferences
+type NotificationPreferenceRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewNotificationPreferenceRepository creates a new NotificationPreferenceRepository
+func NewNotificationPreferenceRepository(db *pgxpool.Pool) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: db}
+}
+
+const notificationPreferenceColumns = `user_id, type, push_enabled, email_enabled, in_app_enabled,
+			to_char(quiet_hours_start, 'HH24:MI'), to_char(quiet_hours_end, 'HH24:MI'),
+			max_per_day, updated_at`
+
+// GetByUserID retrieves the preferences a user has configured
+func (r *NotificationPreferenceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.NotificationPreference, error) {
+	query := `
+		SELECT ` + notificationPreferenceColumns + `
+		FROM notification_preferences
+		WHERE user_id = $1
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var preferences []*models.NotificationPreference
+	for rows.Next() {
+		preference, err := scanNotificationPreference(rows)
+		if err != nil {
+			return nil, err
+		}
+		preferences = append(preferences, preference)
+	}
+
+	return preferences, rows.Err()
+}
+
+// GetByUserAndType retrieves a user's preference for one notification type
+func (r *NotificationPreferenceRepository) GetByUserAndType(ctx context.Context, userID uuid.UUID, notificationType models.NotificationType) (*models.NotificationPreference, error) {
+	query := `
+		SELECT ` + notificationPreferenceColumns + `
+		FROM notification_preferences
+		WHERE user_id = $1 AND type = $2
+	`
+
+	preference, err := scanNotificationPreference(r.db.QueryRow(ctx, query, userID, notificationType))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotificationPreferenceNotFound
+	}
+
+	return preference, err
+}
+
+// UpsertAll stores preferences in a single transaction
+func (r *NotificationPreferenceRepository) UpsertAll(ctx context.Context, preferences []*models.NotificationPreference) error {
+	query := `
+		INSERT INTO notification_preferences (
+			user_id, type, push_enabled, email_enabled, in_app_enabled,
+			quiet_hours_start, quiet_hours_end, max_per_day, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
+		ON CONFLICT (user_id, type) DO UPDATE SET
+			push_enabled = EXCLUDED.push_enabled,
+			email_enabled = EXCLUDED.email_enabled,
+			in_app_enabled = EXCLUDED.in_app_enabled,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			max_per_day = EXCLUDED.max_per_day,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	for _, preference := range preferences {
+		preference.UpdatedAt = now
+		_, err := tx.Exec(ctx, query,
+			preference.UserID,
+			preference.Type,
+			preference.Push,
+			preference.Email,
+			preference.InApp,
+			preference.QuietHoursStart,
+			preference.QuietHoursEnd,
+			preference.MaxPerDay,
+			preference.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// scanNotificationPreference scans a row selecting notificationPreferenceColumns
+func scanNotificationPreference(row pgx.Row) (*models.NotificationPreference, error) {
+	preference := &models.NotificationPreference{}
+	err := row.Scan(
+		&preference.UserID,
+		&preference.Type,
+		&preference.Push,
+		&preference.Email,
+		&preference.InApp,
+		&preference.QuietHoursStart,
+		&preference.QuietHoursEnd,
+		&preference.MaxPerDay,
+		&preference.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return preference, nil
+}
@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+// notificationClaimTimeout is how long a claimed notification may stay in
+// sending before another worker reclaims it
+const notificationClaimTimeout = 10 * time.Minute
+
+// NotificationRepository handles the notification outbox and inbox
+type NotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewNotificationRepository creates a new NotificationRepository
+func NewNotificationRepository(db *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+const notificationColumns = `id, user_id, type, title, body, data, scheduled_for, push, email, in_app,
+			status, attempts, next_attempt_at, last_error, sent_at, read_at, created_at, updated_at,
+			email_status, email_attempts, email_next_attempt_at, email_error, email_sent_at`
+
+// Create writes a notification to the outbox. Notifications created with a
+// status other than pending are never pushed; those addressed to email are
+// queued for it as well. A notification whose occurrence key the user
+// already has is not written again.
+func (r *NotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	query := `
+		INSERT INTO notifications (
+			id, user_id, type, title, body, data, scheduled_for, push, email, in_app,
+			status, attempts, last_error, email_status, occurrence_key, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 0, $12, $13, $14, $15, $15
+		)
+		ON CONFLICT (user_id, occurrence_key) WHERE occurrence_key IS NOT NULL DO NOTHING
+	`
+
+	notification.ID = uuid.New()
+	if notification.Status == "" {
+		notification.Status = models.NotificationPending
+	}
+	if notification.Email && notification.EmailStatus == nil {
+		pending := models.NotificationPending
+		notification.EmailStatus = &pending
+	}
+	notification.CreatedAt = time.Now()
+	notification.UpdatedAt = notification.CreatedAt
+	if notification.ScheduledFor.IsZero() {
+		notification.ScheduledFor = notification.CreatedAt
+	}
+
+	_, err := r.db.Exec(ctx, query,
+		notification.ID,
+		notification.UserID,
+		notification.Type,
+		notification.Title,
+		notification.Body,
+		notification.Data,
+		notification.ScheduledFor,
+		notification.Push,
+		notification.Email,
+		notification.InApp,
+		notification.Status,
+		notification.LastError,
+		notification.EmailStatus,
+		notification.OccurrenceKey,
+		notification.CreatedAt,
+	)
+
+	return err
+}
+
+// ClaimDue marks up to limit due notifications as sending and returns them.
+// Rows locked by another worker are skipped, and rows a crashed worker left
+// in sending are reclaimed after a timeout.
+func (r *NotificationRepository) ClaimDue(ctx context.Context, limit int) ([]*models.Notification, error) {
+	query := `
+		UPDATE notifications SET
+			status = $1,
+			attempts = attempts + 1,
+			updated_at = $2
+		WHERE id IN (
+			SELECT id FROM notifications
+			WHERE (status = $3 AND scheduled_for <= $2
+					AND (next_attempt_at IS NULL OR next_attempt_at <= $2))
+				OR (status = $1 AND updated_at < $4)
+			ORDER BY scheduled_for
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationColumns
+
+	now := time.Now()
+	rows, err := r.db.Query(ctx, query,
+		models.NotificationSending,
+		now,
+		models.NotificationPending,
+		now.Add(-notificationClaimTimeout),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanNotifications(rows)
+}
+
+// UpdateDelivery records the outcome of a delivery attempt
+func (r *NotificationRepository) UpdateDelivery(ctx context.Context, notification *models.Notification) error {
+	query := `
+		UPDATE notifications SET
+			status = $2,
+			next_attempt_at = $3,
+			last_error = $4,
+			sent_at = $5,
+			updated_at = $6
+		WHERE id = $1
+	`
+
+	notification.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		notification.ID,
+		notification.Status,
+		notification.NextAttemptAt,
+		notification.LastError,
+		notification.SentAt,
+		notification.UpdatedAt,
+	)
+
+	return err
+}
+
+// ClaimDueEmails marks up to limit notifications whose email is due as
+// sending and returns them, skipping and reclaiming rows like ClaimDue
+func (r *NotificationRepository) ClaimDueEmails(ctx context.Context, limit int) ([]*models.Notification, error) {
+	query := `
+		UPDATE notifications SET
+			email_status = $1,
+			email_attempts = email_attempts + 1,
+			updated_at = $2
+		WHERE id IN (
+			SELECT id FROM notifications
+			WHERE (email_status = $3 AND scheduled_for <= $2
+					AND (email_next_attempt_at IS NULL OR email_next_attempt_at <= $2))
+				OR (email_status = $1 AND updated_at < $4)
+			ORDER BY scheduled_for
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationColumns
+
+	now := time.Now()
+	rows, err := r.db.Query(ctx, query,
+		models.NotificationSending,
+		now,
+		models.NotificationPending,
+		now.Add(-notificationClaimTimeout),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanNotifications(rows)
+}
+
+// UpdateEmailDelivery records the outcome of an email delivery attempt
+func (r *NotificationRepository) UpdateEmailDelivery(ctx context.Context, notification *models.Notification) error {
+	query := `
+		UPDATE notifications SET
+			email_status = $2,
+			email_next_attempt_at = $3,
+			email_error = $4,
+			email_sent_at = $5,
+			updated_at = $6
+		WHERE id = $1
+	`
+
+	notification.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		notification.ID,
+		notification.EmailStatus,
+		notification.EmailNextAttemptAt,
+		notification.EmailError,
+		notification.EmailSentAt,
+		notification.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetByUserID retrieves a page of a user's inbox, newest first. Notifications
+// scheduled for later are not shown until they are due.
+func (r *NotificationRepository) GetByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) ([]*models.Notification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1 AND in_app = true AND scheduled_for <= $2 AND ($3 = false OR read_at IS NULL)
+		ORDER BY scheduled_for DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, time.Now(), unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanNotifications(rows)
+}
+
+// CountByUserID counts a user's due inbox entries and how many are unread
+func (r *NotificationRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (total, unread int, err error) {
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE read_at IS NULL)
+		FROM notifications
+		WHERE user_id = $1 AND in_app = true AND scheduled_for <= $2
+	`
+
+	err = r.db.QueryRow(ctx, query, userID, time.Now()).Scan(&total, &unread)
+	return total, unread, err
+}
+
+// MarkRead marks one of a user's notifications as read
+func (r *NotificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID) (*models.Notification, error) {
+	query := `
+		UPDATE notifications SET read_at = COALESCE(read_at, $3)
+		WHERE id = $1 AND user_id = $2 AND in_app = true
+		RETURNING ` + notificationColumns
+
+	rows, err := r.db.Query(ctx, query, id, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := scanNotifications(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(notifications) == 0 {
+		return nil, ErrNotificationNotFound
+	}
+
+	return notifications[0], nil
+}
+
+// MarkAllRead marks all of a user's due notifications as read
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := `
+		UPDATE notifications SET read_at = $2
+		WHERE user_id = $1 AND in_app = true AND read_at IS NULL AND scheduled_for <= $2
+	`
+
+	result, err := r.db.Exec(ctx, query, userID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// CountByTypeSince counts the notifications of a type created for a user
+// since a given time
+func (r *NotificationRepository) CountByTypeSince(ctx context.Context, userID uuid.UUID, notificationType models.NotificationType, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM notifications
+		WHERE user_id = $1 AND type = $2 AND created_at >= $3
+	`
+
+	var count int
+	err := r.db.QueryRow(ctx, query, userID, notificationType, since).Scan(&count)
+	return count, err
+}
+
+// scanNotifications scans and closes rows selecting notificationColumns
+func scanNotifications(rows pgx.Rows) ([]*models.Notification, error) {
+	defer rows.Close()
+
+	var notifications []*models.Notification
+	for rows.Next() {
+		notification := &models.Notification{}
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Type,
+			&notification.Title,
+			&notification.Body,
+			&notification.Data,
+			&notification.ScheduledFor,
+			&notification.Push,
+			&notification.Email,
+			&notification.InApp,
+			&notification.Status,
+			&notification.Attempts,
+			&notification.NextAttemptAt,
+			&notification.LastError,
+			&notification.SentAt,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+			&notification.UpdatedAt,
+			&notification.EmailStatus,
+			&notification.EmailAttempts,
+			&notification.EmailNextAttemptAt,
+			&notification.EmailError,
+			&notification.EmailSentAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrReportJobNotFound    = errors.New("report job not found")
+	ErrReportQuotaExhausted = errors.New("report job quota exhausted")
+)
+
+// ReportJobRepository handles the report generation queue
+type ReportJobRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewReportJobRepository creates a new ReportJobRepository
+func NewReportJobRepository(db *pgxpool.Pool) *ReportJobRepository {
+	return &ReportJobRepository{db: db}
+}
+
+const reportJobColumns = `id, user_id, kind, period_type, period_start, status, attempts, report_id, error,
+			created_at, started_at, finished_at, updated_at`
+
+const (
+	enqueueReportJobQuery = `
+		INSERT INTO report_jobs (
+			id, user_id, kind, period_type, period_start, status, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $7
+		)
+		ON CONFLICT (user_id, period_type, period_start, (kind = 'year_review'))
+			WHERE status IN ('queued', 'running') DO NOTHING
+		RETURNING ` + reportJobColumns
+
+	// Year review jobs only share with year review jobs, report jobs of
+	// either kind with each other
+	activeReportJobQuery = `
+		SELECT ` + reportJobColumns + `
+		FROM report_jobs
+		WHERE user_id = $1 AND period_type = $2 AND period_start = $3 AND status IN ('queued', 'running')
+			AND (kind = 'year_review') = ($4 = 'year_review')
+	`
+
+	countReportJobsQuery = `
+		SELECT COUNT(*) FROM report_jobs
+		WHERE user_id = $1 AND kind = $2 AND created_at >= $3 AND status <> $4
+	`
+)
+
+// Enqueue queues a job unless the user already has an unfinished job for
+// the period and target, in which case that job is returned instead. created reports
+// whether a new job was queued.
+func (r *ReportJobRepository) Enqueue(ctx context.Context, job *models.ReportJob) (*models.ReportJob, bool, error) {
+	// The active job can finish between the two statements, so try again
+	for i := 0; i < 3; i++ {
+		queued, err := scanReportJob(r.db.QueryRow(ctx, enqueueReportJobQuery,
+			uuid.New(),
+			job.UserID,
+			job.Kind,
+			job.PeriodType,
+			job.PeriodStart,
+			models.ReportJobQueued,
+			time.Now(),
+		))
+		if err == nil {
+			return queued, true, nil
+		}
+		if err != ErrReportJobNotFound {
+			return nil, false, err
+		}
+
+		existing, err := r.GetActive(ctx, job.UserID, job.Kind, job.Period())
+		if err == nil {
+			return existing, false, nil
+		}
+		if err != ErrReportJobNotFound {
+			return nil, false, err
+		}
+	}
+
+	return nil, false, errors.New("report job kept finishing while being enqueued")
+}
+
+// GetActive retrieves a user's unfinished job for a period that a job of the
+// given kind would share
+func (r *ReportJobRepository) GetActive(ctx context.Context, userID uuid.UUID, kind models.ReportJobKind, period models.ReportPeriod) (*models.ReportJob, error) {
+	return scanReportJob(r.db.QueryRow(ctx, activeReportJobQuery, userID, period.Type, period.Start, kind))
+}
+
+// EnqueueWithinQuota queues a job like Enqueue, unless the user already has
+// limit jobs of its kind created since a time, failed jobs aside, in which
+// case it returns ErrReportQuotaExhausted. Joining an unfinished job for the
+// period is always allowed. The count and the insert run under a per-user
+// lock, so concurrent requests cannot both take the last slot.
+func (r *ReportJobRepository) EnqueueWithinQuota(ctx context.Context, job *models.ReportJob, since time.Time, limit int) (*models.ReportJob, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := `SELECT pg_advisory_xact_lock(hashtext('report_jobs:' || $1::text))`
+	if _, err := tx.Exec(ctx, lockQuery, job.UserID); err != nil {
+		return nil, false, err
+	}
+
+	active, err := scanReportJob(tx.QueryRow(ctx, activeReportJobQuery, job.UserID, job.PeriodType, job.PeriodStart, job.Kind))
+	if err == nil {
+		return active, false, nil
+	}
+	if err != ErrReportJobNotFound {
+		return nil, false, err
+	}
+
+	var used int
+	if err := tx.QueryRow(ctx, countReportJobsQuery, job.UserID, job.Kind, since, models.ReportJobFailed).Scan(&used); err != nil {
+		return nil, false, err
+	}
+	if used >= limit {
+		return nil, false, ErrReportQuotaExhausted
+	}
+
+	queued, err := scanReportJob(tx.QueryRow(ctx, enqueueReportJobQuery,
+		uuid.New(),
+		job.UserID,
+		job.Kind,
+		job.PeriodType,
+		job.PeriodStart,
+		models.ReportJobQueued,
+		time.Now(),
+	))
+	if err == ErrReportJobNotFound {
+		// A job of another kind was queued for the period meanwhile
+		active, err := scanReportJob(tx.QueryRow(ctx, activeReportJobQuery, job.UserID, job.PeriodType, job.PeriodStart, job.Kind))
+		if err != nil {
+			return nil, false, err
+		}
+		return active, false, tx.Commit(ctx)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+
+	return queued, true, nil
+}
+
+// CountSince counts a user's jobs of a kind created since a time, leaving
+// out failed jobs
+func (r *ReportJobRepository) CountSince(ctx context.Context, userID uuid.UUID, kind models.ReportJobKind, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, countReportJobsQuery, userID, kind, since, models.ReportJobFailed).Scan(&count)
+	return count, err
+}
+
+// GetByID retrieves a user's report job
+func (r *ReportJobRepository) GetByID(ctx context.Context, id, userID uuid.UUID) (*models.ReportJob, error) {
+	query := `
+		SELECT ` + reportJobColumns + `
+		FROM report_jobs
+		WHERE id = $1 AND user_id = $2
+	`
+
+	return scanReportJob(r.db.QueryRow(ctx, query, id, userID))
+}
+
+// Claim marks the oldest queued job as running and returns it. Jobs locked
+// by another worker are skipped, and jobs a crashed worker left running
+// since before staleBefore are reclaimed while they have attempts left.
+// Returns ErrReportJobNotFound when the queue is empty.
+func (r *ReportJobRepository) Claim(ctx context.Context, staleBefore time.Time, maxAttempts int) (*models.ReportJob, error) {
+	query := `
+		UPDATE report_jobs SET
+			status = $1,
+			attempts = attempts + 1,
+			started_at = $2,
+			updated_at = $2
+		WHERE id = (
+			SELECT id FROM report_jobs
+			WHERE status = $3
+				OR (status = $1 AND updated_at < $4 AND attempts < $5)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + reportJobColumns
+
+	return scanReportJob(r.db.QueryRow(ctx, query,
+		models.ReportJobRunning,
+		time.Now(),
+		models.ReportJobQueued,
+		staleBefore,
+		maxAttempts,
+	))
+}
+
+// Finish records the outcome of a job
+func (r *ReportJobRepository) Finish(ctx context.Context, job *models.ReportJob) error {
+	query := `
+		UPDATE report_jobs SET
+			status = $2,
+			report_id = $3,
+			error = $4,
+			finished_at = $5,
+			updated_at = $5
+		WHERE id = $1
+	`
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.UpdatedAt = finishedAt
+
+	_, err := r.db.Exec(ctx, query,
+		job.ID,
+		job.Status,
+		job.ReportID,
+		job.Error,
+		job.FinishedAt,
+	)
+
+	return err
+}
+
+// Requeue puts a running job back in the queue, e.g. when its worker is
+// shutting down
+func (r *ReportJobRepository) Requeue(ctx context.Context, job *models.ReportJob) error {
+	query := `
+		UPDATE report_jobs SET
+			status = $2,
+			attempts = GREATEST(attempts - 1, 0),
+			started_at = NULL,
+			updated_at = $3
+		WHERE id = $1 AND status = $4
+	`
+
+	_, err := r.db.Exec(ctx, query, job.ID, models.ReportJobQueued, time.Now(), models.ReportJobRunning)
+	return err
+}
+
+// FailStale fails running jobs that stopped responding after using up
+// their attempts, so they no longer block new jobs for the period
+func (r *ReportJobRepository) FailStale(ctx context.Context, staleBefore time.Time, maxAttempts int) (int64, error) {
+	query := `
+		UPDATE report_jobs SET
+			status = $1,
+			error = 'report generation stopped responding',
+			finished_at = $2,
+			updated_at = $2
+		WHERE status = $3 AND updated_at < $4 AND attempts >= $5
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		models.ReportJobFailed,
+		time.Now(),
+		models.ReportJobRunning,
+		staleBefore,
+		maxAttempts,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// DeleteFinishedBefore prunes jobs that finished before a cutoff. Jobs of
+// the kept kinds created since keepSince stay, as they still count against
+// a quota.
+func (r *ReportJobRepository) DeleteFinishedBefore(ctx context.Context, cutoff time.Time, keepKinds []string, keepSince time.Time) (int64, error) {
+	query := `
+		DELETE FROM report_jobs
+		WHERE finished_at < $1 AND NOT (kind = ANY($2) AND created_at >= $3)
+	`
+
+	result, err := r.db.Exec(ctx, query, cutoff, keepKinds, keepSince)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func scanReportJob(row pgx.Row) (*models.ReportJob, error) {
+	job := &models.ReportJob{}
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Kind,
+		&job.PeriodType,
+		&job.PeriodStart,
+		&job.Status,
+		&job.Attempts,
+		&job.ReportID,
+		&job.Error,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrReportJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,7 +15,7 @@ import (
 
 var (
 	ErrReportNotFound = errors.New("report not found")
-	ErrReportExists   = errors.New("report already exists for this month")
+	ErrReportExists   = errors.New("report already exists for this period")
 )
 
 // ReportRepository handles report database operations
@@ -27,14 +28,19 @@ func NewReportRepository(db *pgxpool.Pool) *ReportRepository {
 	return &ReportRepository{db: db}
 }
 
+const reportColumns = `id, user_id, period_type, period_start, period_end, report_content, skills_learned,
+			habits_completed_percentage, revision_suggestions,
+			generation_source, generation_model, generation_attempts, generated_at`
+
 // Create creates a new report
 func (r *ReportRepository) Create(ctx context.Context, report *models.Report) error {
 	query := `
 		INSERT INTO reports (
-			id, user_id, report_month, report_content, skills_learned,
-			habits_completed_percentage, revision_suggestions, generated_at
+			id, user_id, period_type, period_start, period_end, report_month,
+			report_content, skills_learned, habits_completed_percentage, revision_suggestions,
+			generation_source, generation_model, generation_attempts, generated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
+			$1, $2, $3, $4, $5, $4, $6, $7, $8, $9, $10, $11, $12, $13
 		)
 	`
 
@@ -44,11 +50,16 @@ func (r *ReportRepository) Create(ctx context.Context, report *models.Report) er
 	_, err := r.db.Exec(ctx, query,
 		report.ID,
 		report.UserID,
-		report.ReportMonth,
+		report.PeriodType,
+		report.PeriodStart,
+		report.PeriodEnd,
 		report.ReportContent,
 		report.SkillsLearned,
 		report.HabitsCompletedPercentage,
 		report.RevisionSuggestions,
+		report.GenerationSource,
+		report.GenerationModel,
+		report.GenerationAttempts,
 		report.GeneratedAt,
 	)
 
@@ -58,70 +69,64 @@ func (r *ReportRepository) Create(ctx context.Context, report *models.Report) er
 // GetByID retrieves a report by ID
 func (r *ReportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Report, error) {
 	query := `
-		SELECT id, user_id, report_month, report_content, skills_learned,
-			habits_completed_percentage, revision_suggestions, generated_at
+		SELECT ` + reportColumns + `
 		FROM reports
 		WHERE id = $1
 	`
 
-	report := &models.Report{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&report.ID,
-		&report.UserID,
-		&report.ReportMonth,
-		&report.ReportContent,
-		&report.SkillsLearned,
-		&report.HabitsCompletedPercentage,
-		&report.RevisionSuggestions,
-		&report.GeneratedAt,
-	)
+	return scanReport(r.db.QueryRow(ctx, query, id))
+}
 
-	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, ErrReportNotFound
-	}
+// GetByUserAndPeriod retrieves a user's report of a period
+func (r *ReportRepository) GetByUserAndPeriod(ctx context.Context, userID uuid.UUID, period models.ReportPeriod) (*models.Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM reports
+		WHERE user_id = $1 AND period_type = $2 AND period_start = $3
+	`
 
-	return report, err
+	return scanReport(r.db.QueryRow(ctx, query, userID, period.Type, period.Start))
 }
 
-// GetByUserAndMonth retrieves a report by user ID and month
-func (r *ReportRepository) GetByUserAndMonth(ctx context.Context, userID uuid.UUID, reportMonth time.Time) (*models.Report, error) {
+// GetByUser retrieves a user's reports of a period type, or of every type
+// when periodType is empty, newest first
+func (r *ReportRepository) GetByUser(ctx context.Context, userID uuid.UUID, periodType models.ReportPeriodType) ([]*models.Report, error) {
 	query := `
-		SELECT id, user_id, report_month, report_content, skills_learned,
-			habits_completed_percentage, revision_suggestions, generated_at
+		SELECT ` + reportColumns + `
 		FROM reports
-		WHERE user_id = $1 AND report_month = $2
+		WHERE user_id = $1 AND ($2::text = '' OR period_type = $2::text)
+		ORDER BY period_start DESC, period_end DESC
 	`
 
-	report := &models.Report{}
-	err := r.db.QueryRow(ctx, query, userID, reportMonth).Scan(
-		&report.ID,
-		&report.UserID,
-		&report.ReportMonth,
-		&report.ReportContent,
-		&report.SkillsLearned,
-		&report.HabitsCompletedPercentage,
-		&report.RevisionSuggestions,
-		&report.GeneratedAt,
-	)
+	rows, err := r.db.Query(ctx, query, userID, string(periodType))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, ErrReportNotFound
+	var reports []*models.Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
 	}
 
-	return report, err
+	return reports, rows.Err()
 }
 
-// GetByUser retrieves all reports for a user
-func (r *ReportRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.Report, error) {
+// GetByUserBetween retrieves a user's reports of a period type whose periods
+// start from one date to another, oldest first
+func (r *ReportRepository) GetByUserBetween(ctx context.Context, userID uuid.UUID, periodType models.ReportPeriodType, from, to time.Time) ([]*models.Report, error) {
 	query := `
-		SELECT id, user_id, report_month, report_content, skills_learned,
-			habits_completed_percentage, revision_suggestions, generated_at
+		SELECT ` + reportColumns + `
 		FROM reports
-		WHERE user_id = $1
-		ORDER BY report_month DESC
+		WHERE user_id = $1 AND period_type = $2 AND period_start >= $3 AND period_start <= $4
+		ORDER BY period_start ASC
 	`
 
-	rows, err := r.db.Query(ctx, query, userID)
+	rows, err := r.db.Query(ctx, query, userID, periodType, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -129,17 +134,7 @@ func (r *ReportRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*
 
 	var reports []*models.Report
 	for rows.Next() {
-		report := &models.Report{}
-		err := rows.Scan(
-			&report.ID,
-			&report.UserID,
-			&report.ReportMonth,
-			&report.ReportContent,
-			&report.SkillsLearned,
-			&report.HabitsCompletedPercentage,
-			&report.RevisionSuggestions,
-			&report.GeneratedAt,
-		)
+		report, err := scanReport(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -149,72 +144,363 @@ func (r *ReportRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*
 	return reports, rows.Err()
 }
 
-// Exists checks if a report exists for a user and month
-func (r *ReportRepository) Exists(ctx context.Context, userID uuid.UUID, reportMonth time.Time) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM reports WHERE user_id = $1 AND report_month = $2)`
+// Exists checks if a user has a report of a period
+func (r *ReportRepository) Exists(ctx context.Context, userID uuid.UUID, period models.ReportPeriod) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM reports WHERE user_id = $1 AND period_type = $2 AND period_start = $3)`
 
 	var exists bool
-	err := r.db.QueryRow(ctx, query, userID, reportMonth).Scan(&exists)
+	err := r.db.QueryRow(ctx, query, userID, period.Type, period.Start).Scan(&exists)
 
 	return exists, err
 }
 
-// GetHabitCompletionDataForMonth retrieves habit completion data for report generation
-func (r *ReportRepository) GetHabitCompletionDataForMonth(ctx context.Context, userID uuid.UUID, year, month int) ([]*models.HabitCompletionData, error) {
-	query := `
-		WITH habit_stats AS (
-			SELECT
-				h.id as habit_id,
-				h.title as habit_title,
-				h.category,
-				COALESCE(s.current_streak, 0) as streak,
-				COUNT(dl.id) as total_days,
-				COUNT(dl.id) FILTER (WHERE dl.completed = true) as completed_days
-			FROM habits h
-			LEFT JOIN streaks s ON h.id = s.habit_id
-			LEFT JOIN daily_logs dl ON h.id = dl.habit_id
-				AND EXTRACT(YEAR FROM dl.log_date) = $2
-				AND EXTRACT(MONTH FROM dl.log_date) = $3
-			WHERE h.user_id = $1
-				AND h.is_active = true
-				AND h.deleted_at IS NULL
-			GROUP BY h.id, h.title, h.category, s.current_streak
-		)
-		SELECT
-			habit_id,
-			habit_title,
-			category,
-			streak,
-			CASE
-				WHEN total_days > 0 THEN (completed_days::float / total_days) * 100
-				ELSE 0
-			END as completion_rate
-		FROM habit_stats
+// GetHabitCompletionData retrieves habit completion data for report generation
+// over the dates from start to end. The completion rate is the share of
+// scheduled due days completed, counting days up to the user's today in loc,
+// from the day each habit was created and until the day it was deleted or
+// paused. Habits that existed at any point in the range are included, so a
+// comparison with another period sees the habits that were dropped since,
+// unless they were removed before any of their days came due.
+// The best streak is the longest run within the range.
+func (r *ReportRepository) GetHabitCompletionData(ctx context.Context, userID uuid.UUID, start, end time.Time, loc *time.Location) ([]*models.HabitCompletionData, error) {
+	habitsQuery := `
+		SELECT h.id, h.title, h.category, h.frequency, h.schedule,
+			h.target_value, h.unit, h.is_active, h.created_at, h.updated_at, h.deleted_at,
+			COALESCE(s.current_streak, 0)
+		FROM habits h
+		LEFT JOIN streaks s ON h.id = s.habit_id
+		WHERE h.user_id = $1
+			AND h.created_at < $3
+			AND (h.deleted_at IS NULL OR h.deleted_at >= $2)
+			AND (h.is_active = true OR h.deleted_at IS NOT NULL OR h.updated_at >= $2)
+		ORDER BY h.created_at ASC
 	`
 
-	rows, err := r.db.Query(ctx, query, userID, year, month)
+	rows, err := r.db.Query(ctx, habitsQuery, userID, start, end.AddDate(0, 0, 1))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var data []*models.HabitCompletionData
+	var habits []*models.Habit
 	for rows.Next() {
-		item := &models.HabitCompletionData{}
+		habit := &models.Habit{}
 		err := rows.Scan(
-			&item.HabitID,
-			&item.HabitTitle,
-			&item.Category,
-			&item.Streak,
-			&item.CompletionRate,
+			&habit.ID,
+			&habit.Title,
+			&habit.Category,
+			&habit.Frequency,
+			&habit.Schedule,
+			&habit.TargetValue,
+			&habit.Unit,
+			&habit.IsActive,
+			&habit.CreatedAt,
+			&habit.UpdatedAt,
+			&habit.DeletedAt,
+			&habit.CurrentStreak,
 		)
 		if err != nil {
 			return nil, err
 		}
+		habits = append(habits, habit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if today := models.DateOf(time.Now(), loc); end.After(today) {
+		end = today
+	}
+
+	// Start at the week containing the first day so weekly quotas see earlier completions
+	completed, err := r.getCompletedDates(ctx, userID, models.WeekStart(start), end)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := r.getValueTotals(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	frozen, vacations, err := r.getSkippedDates(ctx, userID, models.WeekStart(start), end)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []*models.HabitCompletionData
+	for _, habit := range habits {
+		from := start
+		if created := models.DateOf(habit.CreatedAt, loc); from.Before(created) {
+			from = created
+		}
+
+		// Nothing is due from the day a habit was deleted or paused; pausing
+		// is the last change to an inactive habit, so updated_at tells when
+		until := end
+		var removed *time.Time
+		if habit.DeletedAt != nil {
+			removed = habit.DeletedAt
+		} else if !habit.IsActive {
+			removed = &habit.UpdatedAt
+		}
+		if removed != nil {
+			if last := models.DateOf(*removed, loc).AddDate(0, 0, -1); last.Before(until) {
+				until = last
+			}
+		}
+
+		// Frozen days and rest days are not due, as in the calendar and digest
+		due := habit.DueDates(from, until, completed[habit.ID], skippedDates(frozen[habit.ID], vacations))
+		if removed != nil && len(due) == 0 {
+			continue
+		}
+		done := 0
+		for _, date := range due {
+			if completed[habit.ID][date] {
+				done++
+			}
+		}
+
+		_, bestStreak := streakWithin(habit, completed[habit.ID], frozen[habit.ID], vacations, from, until)
+
+		item := &models.HabitCompletionData{
+			HabitID:     habit.ID,
+			HabitTitle:  habit.Title,
+			Category:    string(habit.Category),
+			DueDays:     len(due),
+			Completions: done,
+			Streak:      habit.CurrentStreak,
+			BestStreak:  bestStreak,
+		}
+		if len(due) > 0 {
+			item.CompletionRate = float64(done) / float64(len(due)) * 100
+		}
+		if habit.IsQuantitative() {
+			total := totals[habit.ID]
+			average := 0.0
+			if len(due) > 0 {
+				average = total / float64(len(due))
+			}
+			item.Unit = habit.Unit
+			item.TargetValue = habit.TargetValue
+			item.TotalValue = &total
+			item.AverageValue = &average
+		}
 		data = append(data, item)
 	}
 
-	return data, rows.Err()
+	return data, nil
+}
+
+// GetHabitActivity retrieves what each of a user's habits did from start to
+// end: its completions and the longest streak run within the range. Habits
+// deleted before start or created after end are left out; habits deleted
+// within the range are included.
+func (r *ReportRepository) GetHabitActivity(ctx context.Context, userID uuid.UUID, start, end time.Time) ([]*models.HabitActivity, error) {
+	habitsQuery := `
+		SELECT id, user_id, title, category, frequency, schedule, target_value, unit,
+			is_active, created_at, updated_at, deleted_at
+		FROM habits
+		WHERE user_id = $1
+			AND created_at < $3
+			AND (deleted_at IS NULL OR deleted_at >= $2)
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, habitsQuery, userID, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var habits []*models.Habit
+	for rows.Next() {
+		habit := &models.Habit{}
+		err := rows.Scan(
+			&habit.ID,
+			&habit.UserID,
+			&habit.Title,
+			&habit.Category,
+			&habit.Frequency,
+			&habit.Schedule,
+			&habit.TargetValue,
+			&habit.Unit,
+			&habit.IsActive,
+			&habit.CreatedAt,
+			&habit.UpdatedAt,
+			&habit.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		habits = append(habits, habit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	completed, err := r.getCompletedDates(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	frozen, vacations, err := r.getSkippedDates(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var activity []*models.HabitActivity
+	for _, habit := range habits {
+		completions, longest := streakWithin(habit, completed[habit.ID], frozen[habit.ID], vacations, start, end)
+		activity = append(activity, &models.HabitActivity{
+			Habit:         habit,
+			Completions:   completions,
+			LongestStreak: longest,
+		})
+	}
+
+	return activity, nil
+}
+
+// streakWithin counts a habit's completions from one date to another and
+// returns them with the longest streak run within the range, skipping its
+// frozen days and the user's rest days
+func streakWithin(habit *models.Habit, completed, frozen, vacations map[time.Time]bool, from, to time.Time) (completions, longest int) {
+	var dates []time.Time
+	for date := range completed {
+		if !date.Before(from) && !date.After(to) {
+			dates = append(dates, date)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	_, longest, _ = models.ComputeStreak(habit, dates, skippedDates(frozen, vacations), to)
+	return len(dates), longest
+}
+
+// skippedDates merges a habit's frozen dates with the user's rest days
+func skippedDates(frozen, vacations map[time.Time]bool) map[time.Time]bool {
+	skipped := make(map[time.Time]bool, len(vacations)+len(frozen))
+	for date := range vacations {
+		skipped[date] = true
+	}
+	for date := range frozen {
+		skipped[date] = true
+	}
+	return skipped
+}
+
+// getSkippedDates returns the frozen dates of each of a user's habits and the
+// user's rest days within a range
+func (r *ReportRepository) getSkippedDates(ctx context.Context, userID uuid.UUID, from, to time.Time) (map[uuid.UUID]map[time.Time]bool, map[time.Time]bool, error) {
+	freezesQuery := `
+		SELECT habit_id, freeze_date FROM habit_freezes
+		WHERE user_id = $1 AND freeze_date >= $2 AND freeze_date <= $3
+	`
+
+	rows, err := r.db.Query(ctx, freezesQuery, userID, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	frozen := make(map[uuid.UUID]map[time.Time]bool)
+	for rows.Next() {
+		var habitID uuid.UUID
+		var date time.Time
+		if err := rows.Scan(&habitID, &date); err != nil {
+			return nil, nil, err
+		}
+		if frozen[habitID] == nil {
+			frozen[habitID] = make(map[time.Time]bool)
+		}
+		frozen[habitID][models.DateOf(date, time.UTC)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	vacationsQuery := `
+		SELECT day::date FROM user_vacations v,
+			generate_series(GREATEST(v.start_date, $2::date), LEAST(v.end_date, $3::date), interval '1 day') AS day
+		WHERE v.user_id = $1 AND v.start_date <= $3 AND v.end_date >= $2
+	`
+
+	vacationRows, err := r.db.Query(ctx, vacationsQuery, userID, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer vacationRows.Close()
+
+	vacations := make(map[time.Time]bool)
+	for vacationRows.Next() {
+		var date time.Time
+		if err := vacationRows.Scan(&date); err != nil {
+			return nil, nil, err
+		}
+		vacations[models.DateOf(date, time.UTC)] = true
+	}
+
+	return frozen, vacations, vacationRows.Err()
+}
+
+// getValueTotals sums the logged values of each of a user's habits within a range
+func (r *ReportRepository) getValueTotals(ctx context.Context, userID uuid.UUID, from, to time.Time) (map[uuid.UUID]float64, error) {
+	query := `
+		SELECT habit_id, SUM(value) FROM daily_logs
+		WHERE user_id = $1 AND value IS NOT NULL
+			AND log_date >= $2 AND log_date <= $3
+		GROUP BY habit_id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[uuid.UUID]float64)
+	for rows.Next() {
+		var habitID uuid.UUID
+		var total float64
+		if err := rows.Scan(&habitID, &total); err != nil {
+			return nil, err
+		}
+		totals[habitID] = total
+	}
+
+	return totals, rows.Err()
+}
+
+// getCompletedDates returns the completed dates of each of a user's habits within a range
+func (r *ReportRepository) getCompletedDates(ctx context.Context, userID uuid.UUID, from, to time.Time) (map[uuid.UUID]map[time.Time]bool, error) {
+	query := `
+		SELECT habit_id, log_date FROM daily_logs
+		WHERE user_id = $1 AND completed = true
+			AND log_date >= $2 AND log_date <= $3
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	completed := make(map[uuid.UUID]map[time.Time]bool)
+	for rows.Next() {
+		var habitID uuid.UUID
+		var date time.Time
+		if err := rows.Scan(&habitID, &date); err != nil {
+			return nil, err
+		}
+		if completed[habitID] == nil {
+			completed[habitID] = make(map[time.Time]bool)
+		}
+		completed[habitID][models.DateOf(date, time.UTC)] = true
+	}
+
+	return completed, rows.Err()
 }
 
 // Update updates a report
@@ -225,7 +511,10 @@ func (r *ReportRepository) Update(ctx context.Context, report *models.Report) er
 			skills_learned = $3,
 			habits_completed_percentage = $4,
 			revision_suggestions = $5,
-			generated_at = $6
+			generation_source = $6,
+			generation_model = $7,
+			generation_attempts = $8,
+			generated_at = $9
 		WHERE id = $1
 	`
 
@@ -237,6 +526,9 @@ func (r *ReportRepository) Update(ctx context.Context, report *models.Report) er
 		report.SkillsLearned,
 		report.HabitsCompletedPercentage,
 		report.RevisionSuggestions,
+		report.GenerationSource,
+		report.GenerationModel,
+		report.GenerationAttempts,
 		report.GeneratedAt,
 	)
 
@@ -251,8 +543,9 @@ func (r *ReportRepository) Update(ctx context.Context, report *models.Report) er
 	return nil
 }
 
-// CreateRevisionHabitsFromReport creates revision habits from report suggestions
-func (r *ReportRepository) CreateRevisionHabitsFromReport(ctx context.Context, userID uuid.UUID, reportMonth time.Time, suggestions []models.RevisionSuggestion) error {
+// CreateRevisionHabitsFromReport creates revision habits from report suggestions.
+// sourceDate is the first day of the report's period.
+func (r *ReportRepository) CreateRevisionHabitsFromReport(ctx context.Context, userID uuid.UUID, sourceDate time.Time, suggestions []models.RevisionSuggestion) error {
 	for _, suggestion := range suggestions {
 		query := `
 			INSERT INTO revision_habits (
@@ -268,7 +561,7 @@ func (r *ReportRepository) CreateRevisionHabitsFromReport(ctx context.Context, u
 			uuid.New(),
 			userID,
 			suggestion.Skill,
-			reportMonth,
+			sourceDate,
 			suggestion.SuggestedDurationDays,
 			suggestion.DailyMinutes,
 			models.RevisionStatusPending,
@@ -300,3 +593,31 @@ func (r *ReportRepository) GetReportWithSuggestions(ctx context.Context, id uuid
 
 	return report, suggestions, nil
 }
+
+func scanReport(row pgx.Row) (*models.Report, error) {
+	report := &models.Report{}
+	err := row.Scan(
+		&report.ID,
+		&report.UserID,
+		&report.PeriodType,
+		&report.PeriodStart,
+		&report.PeriodEnd,
+		&report.ReportContent,
+		&report.SkillsLearned,
+		&report.HabitsCompletedPercentage,
+		&report.RevisionSuggestions,
+		&report.GenerationSource,
+		&report.GenerationModel,
+		&report.GenerationAttempts,
+		&report.GeneratedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
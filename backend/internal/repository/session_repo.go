@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// SessionRepository handles session database operations
+type SessionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSessionRepository creates a new SessionRepository
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create creates a new session
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	query := `
+		INSERT INTO sessions (
+			id, user_id, device_name, platform, user_agent, last_ip,
+			created_at, last_used_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+	`
+
+	session.ID = uuid.New()
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = session.CreatedAt
+
+	_, err := r.db.Exec(ctx, query,
+		session.ID,
+		session.UserID,
+		session.DeviceName,
+		session.Platform,
+		session.UserAgent,
+		session.LastIP,
+		session.CreatedAt,
+		session.LastUsedAt,
+	)
+
+	return err
+}
+
+// GetByID retrieves a session by ID
+func (r *SessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	query := `
+		SELECT id, user_id, device_name, platform, user_agent, last_ip,
+			created_at, last_used_at, revoked_at
+		FROM sessions
+		WHERE id = $1
+	`
+
+	session := &models.Session{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.DeviceName,
+		&session.Platform,
+		&session.UserAgent,
+		&session.LastIP,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.RevokedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+
+	return session, err
+}
+
+// GetActiveByUser retrieves all unrevoked sessions for a user
+func (r *SessionRepository) GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	query := `
+		SELECT id, user_id, device_name, platform, user_agent, last_ip,
+			created_at, last_used_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session := &models.Session{}
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.DeviceName,
+			&session.Platform,
+			&session.UserAgent,
+			&session.LastIP,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.RevokedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Touch records activity on a session
+func (r *SessionRepository) Touch(ctx context.Context, id uuid.UUID, ip string) error {
+	query := `
+		UPDATE sessions SET last_used_at = $2, last_ip = $3
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := r.db.Exec(ctx, query, id, time.Now(), ip)
+	return err
+}
+
+// Revoke revokes a single session belonging to a user
+func (r *SessionRepository) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	query := `
+		UPDATE sessions SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, id, userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes every session of a user
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE sessions SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := r.db.Exec(ctx, query, userID, time.Now())
+	return err
+}
@@ -169,43 +169,132 @@ func (r *StreakRepository) GetUserStreaks(ctx context.Context, userID uuid.UUID)
 	return streaks, rows.Err()
 }
 
-// UpdateStreakAfterCompletion updates streak after completing a habit
-// This handles the logic of checking if it's consecutive or needs reset
-func (r *StreakRepository) UpdateStreakAfterCompletion(ctx context.Context, habitID uuid.UUID, completionDate time.Time) error {
-	// Get current streak info
-	streak, err := r.GetByHabitID(ctx, habitID)
+// Recompute rebuilds a habit's streak from its completed logs up to today,
+// the user's current calendar date. The streak row is locked for the
+// duration of the transaction so concurrent log writes serialize.
+func (r *StreakRepository) Recompute(ctx context.Context, habit *models.Habit, today time.Time) (*models.Streak, error) {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer tx.Rollback(ctx)
+
+	lockQuery := `
+		INSERT INTO streaks (id, habit_id, user_id, current_streak, longest_streak, updated_at)
+		VALUES ($1, $2, $3, 0, 0, $4)
+		ON CONFLICT (habit_id) DO UPDATE SET habit_id = EXCLUDED.habit_id
+		RETURNING id
+	`
 
-	completionDateOnly := completionDate.Truncate(24 * time.Hour)
+	streak := &models.Streak{
+		HabitID:   habit.ID,
+		UserID:    habit.UserID,
+		UpdatedAt: time.Now(),
+	}
 
-	if streak.LastCompletedDate != nil {
-		lastDate := streak.LastCompletedDate.Truncate(24 * time.Hour)
-		daysDiff := int(completionDateOnly.Sub(lastDate).Hours() / 24)
+	if err := tx.QueryRow(ctx, lockQuery, uuid.New(), habit.ID, habit.UserID, streak.UpdatedAt).Scan(&streak.ID); err != nil {
+		return nil, err
+	}
 
-		switch {
-		case daysDiff == 0:
-			// Same day, no change needed
-			return nil
-		case daysDiff == 1:
-			// Consecutive day, increment streak
-			streak.CurrentStreak++
-		default:
-			// Gap in streak, reset to 1
-			streak.CurrentStreak = 1
-		}
-	} else {
-		// First completion
-		streak.CurrentStreak = 1
+	streak.CurrentStreak, streak.LongestStreak, streak.LastCompletedDate, err = computeStreak(ctx, tx, habit, today)
+	if err != nil {
+		return nil, err
+	}
+
+	updateQuery := `
+		UPDATE streaks SET
+			current_streak = $2,
+			longest_streak = $3,
+			last_completed_date = $4,
+			updated_at = $5
+		WHERE habit_id = $1
+	`
+
+	_, err = tx.Exec(ctx, updateQuery,
+		streak.HabitID,
+		streak.CurrentStreak,
+		streak.LongestStreak,
+		streak.LastCompletedDate,
+		streak.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update longest streak if needed
-	if streak.CurrentStreak > streak.LongestStreak {
-		streak.LongestStreak = streak.CurrentStreak
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
 	}
 
-	streak.LastCompletedDate = &completionDateOnly
+	return streak, nil
+}
+
+// CurrentStreakAsOf computes what a habit's current streak was on a past
+// date, ignoring anything logged after it. The stored streak is left as is.
+func (r *StreakRepository) CurrentStreakAsOf(ctx context.Context, habit *models.Habit, date time.Time) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	current, _, _, err := computeStreak(ctx, tx, habit, date)
+	return current, err
+}
+
+// computeStreak derives a habit's streaks as of today from its completed
+// logs, its frozen days and the user's rest days
+func computeStreak(ctx context.Context, tx pgx.Tx, habit *models.Habit, today time.Time) (current, longest int, last *time.Time, err error) {
+	datesQuery := `
+		SELECT log_date FROM daily_logs
+		WHERE habit_id = $1 AND completed = true AND log_date <= $2
+		ORDER BY log_date ASC
+	`
+
+	dates, err := queryDates(ctx, tx, datesQuery, habit.ID, today)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	// Frozen days of this habit and the user's rest days
+	skippedQuery := `
+		SELECT freeze_date FROM habit_freezes
+		WHERE habit_id = $1 AND freeze_date <= $3
+		UNION
+		SELECT day::date FROM user_vacations v,
+			generate_series(v.start_date, LEAST(v.end_date, $3::date), interval '1 day') AS day
+		WHERE v.user_id = $2 AND v.start_date <= $3
+	`
+
+	skippedDates, err := queryDates(ctx, tx, skippedQuery, habit.ID, habit.UserID, today)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	skipped := make(map[time.Time]bool, len(skippedDates))
+	for _, date := range skippedDates {
+		skipped[date] = true
+	}
+
+	current, longest, last = models.ComputeStreak(habit, dates, skipped, today)
+	return current, longest, last, nil
+}
+
+// queryDates runs a query selecting a single DATE column within a transaction
+func queryDates(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) ([]time.Time, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []time.Time
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		dates = append(dates, models.DateOf(date, time.UTC))
+	}
 
-	return r.Update(ctx, streak)
+	return dates, rows.Err()
 }
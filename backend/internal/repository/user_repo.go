@@ -32,9 +32,9 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 		INSERT INTO users (
 			id, firebase_uid, email, display_name, avatar_url, xp, level, timezone,
 			notification_enabled, morning_reminder_time, evening_reminder_time,
-			fcm_token, created_at, updated_at
+			created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 		)
 	`
 
@@ -54,7 +54,6 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 		user.NotificationEnabled,
 		user.MorningReminderTime,
 		user.EveningReminderTime,
-		user.FCMToken,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
@@ -65,9 +64,9 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	query := `
-		SELECT id, firebase_uid, email, display_name, avatar_url, xp, level, timezone,
+		SELECT id, firebase_uid, email, display_name, avatar_url, xp, level, streak_freezes, timezone,
 			notification_enabled, morning_reminder_time, evening_reminder_time,
-			fcm_token, created_at, updated_at, deleted_at
+			quiet_hours_start, quiet_hours_end, created_at, updated_at, deleted_at
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -81,11 +80,13 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		&user.AvatarURL,
 		&user.XP,
 		&user.Level,
+		&user.StreakFreezes,
 		&user.Timezone,
 		&user.NotificationEnabled,
 		&user.MorningReminderTime,
 		&user.EveningReminderTime,
-		&user.FCMToken,
+		&user.QuietHoursStart,
+		&user.QuietHoursEnd,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
@@ -101,9 +102,9 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 // GetByFirebaseUID retrieves a user by Firebase UID
 func (r *UserRepository) GetByFirebaseUID(ctx context.Context, firebaseUID string) (*models.User, error) {
 	query := `
-		SELECT id, firebase_uid, email, display_name, avatar_url, xp, level, timezone,
+		SELECT id, firebase_uid, email, display_name, avatar_url, xp, level, streak_freezes, timezone,
 			notification_enabled, morning_reminder_time, evening_reminder_time,
-			fcm_token, created_at, updated_at, deleted_at
+			quiet_hours_start, quiet_hours_end, created_at, updated_at, deleted_at
 		FROM users
 		WHERE firebase_uid = $1 AND deleted_at IS NULL
 	`
@@ -117,11 +118,13 @@ func (r *UserRepository) GetByFirebaseUID(ctx context.Context, firebaseUID strin
 		&user.AvatarURL,
 		&user.XP,
 		&user.Level,
+		&user.StreakFreezes,
 		&user.Timezone,
 		&user.NotificationEnabled,
 		&user.MorningReminderTime,
 		&user.EveningReminderTime,
-		&user.FCMToken,
+		&user.QuietHoursStart,
+		&user.QuietHoursEnd,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
@@ -137,9 +140,9 @@ func (r *UserRepository) GetByFirebaseUID(ctx context.Context, firebaseUID strin
 // GetByEmail retrieves a user by email
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, firebase_uid, email, display_name, avatar_url, xp, level, timezone,
+		SELECT id, firebase_uid, email, display_name, avatar_url, xp, level, streak_freezes, timezone,
 			notification_enabled, morning_reminder_time, evening_reminder_time,
-			fcm_token, created_at, updated_at, deleted_at
+			quiet_hours_start, quiet_hours_end, created_at, updated_at, deleted_at
 		FROM users
 		WHERE email = $1 AND deleted_at IS NULL
 	`
@@ -153,11 +156,13 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 		&user.AvatarURL,
 		&user.XP,
 		&user.Level,
+		&user.StreakFreezes,
 		&user.Timezone,
 		&user.NotificationEnabled,
 		&user.MorningReminderTime,
 		&user.EveningReminderTime,
-		&user.FCMToken,
+		&user.QuietHoursStart,
+		&user.QuietHoursEnd,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
@@ -180,8 +185,9 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 			notification_enabled = $5,
 			morning_reminder_time = $6,
 			evening_reminder_time = $7,
-			fcm_token = $8,
-			updated_at = $9
+			quiet_hours_start = $8,
+			quiet_hours_end = $9,
+			updated_at = $10
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
@@ -195,7 +201,8 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 		user.NotificationEnabled,
 		user.MorningReminderTime,
 		user.EveningReminderTime,
-		user.FCMToken,
+		user.QuietHoursStart,
+		user.QuietHoursEnd,
 		user.UpdatedAt,
 	)
 
@@ -210,14 +217,27 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-// UpdateFCMToken updates user's FCM token
-func (r *UserRepository) UpdateFCMToken(ctx context.Context, userID uuid.UUID, fcmToken string) error {
+// AddXP adds XP to a user, raises their level and credits streak freezes
+// earned by leveling up, holding at most maxFreezes. The award is recorded
+// in the user's XP log along with the action that earned it.
+func (r *UserRepository) AddXP(ctx context.Context, userID uuid.UUID, action models.XPAction, amount int, referenceID *uuid.UUID, level, freezes, maxFreezes int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
-		UPDATE users SET fcm_token = $2, updated_at = $3
+		UPDATE users SET
+			xp = xp + $2,
+			level = GREATEST(level, $3),
+			streak_freezes = LEAST(streak_freezes + $4, GREATEST(streak_freezes, $5)),
+			updated_at = $6
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.Exec(ctx, query, userID, fcmToken, time.Now())
+	now := time.Now()
+	result, err := tx.Exec(ctx, query, userID, amount, level, freezes, maxFreezes, now)
 	if err != nil {
 		return err
 	}
@@ -226,7 +246,28 @@ func (r *UserRepository) UpdateFCMToken(ctx context.Context, userID uuid.UUID, f
 		return ErrUserNotFound
 	}
 
-	return nil
+	logQuery := `
+		INSERT INTO xp_logs (id, user_id, action, amount, reference_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if _, err := tx.Exec(ctx, logQuery, uuid.New(), userID, action, amount, referenceID, now); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetXPEarned sums the XP a user earned from since up to, but excluding, until
+func (r *UserRepository) GetXPEarned(ctx context.Context, userID uuid.UUID, since, until time.Time) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0) FROM xp_logs
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+	`
+
+	var earned int
+	err := r.db.QueryRow(ctx, query, userID, since, until).Scan(&earned)
+	return earned, err
 }
 
 // SoftDelete soft deletes a user
@@ -259,3 +300,73 @@ func (r *UserRepository) Exists(ctx context.Context, firebaseUID string) (bool,
 
 	return exists, err
 }
+
+// GetAllIDs retrieves the IDs of all users that have not been deleted
+func (r *UserRepository) GetAllIDs(ctx context.Context) ([]uuid.UUID, error) {
+	query := `SELECT id FROM users WHERE deleted_at IS NULL ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetAll retrieves all users that have not been deleted
+func (r *UserRepository) GetAll(ctx context.Context) ([]*models.User, error) {
+	query := `
+		SELECT id, firebase_uid, email, display_name, avatar_url, xp, level, streak_freezes, timezone,
+			notification_enabled, morning_reminder_time, evening_reminder_time,
+			quiet_hours_start, quiet_hours_end, created_at, updated_at, deleted_at
+		FROM users
+		WHERE deleted_at IS NULL
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		err := rows.Scan(
+			&user.ID,
+			&user.FirebaseUID,
+			&user.Email,
+			&user.DisplayName,
+			&user.AvatarURL,
+			&user.XP,
+			&user.Level,
+			&user.StreakFreezes,
+			&user.Timezone,
+			&user.NotificationEnabled,
+			&user.MorningReminderTime,
+			&user.EveningReminderTime,
+			&user.QuietHoursStart,
+			&user.QuietHoursEnd,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrYearReviewNotFound = errors.New("year review not found")
+)
+
+// YearReviewRepository handles year review database operations
+type YearReviewRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewYearReviewRepository creates a new YearReviewRepository
+func NewYearReviewRepository(db *pgxpool.Pool) *YearReviewRepository {
+	return &YearReviewRepository{db: db}
+}
+
+// Upsert stores a user's review of a year, replacing the previous one
+func (r *YearReviewRepository) Upsert(ctx context.Context, review *models.YearReview) error {
+	query := `
+		INSERT INTO year_reviews (
+			id, user_id, year, content, narrative, narrative_source, narrative_model, generated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+		ON CONFLICT (user_id, year) DO UPDATE SET
+			content = EXCLUDED.content,
+			narrative = EXCLUDED.narrative,
+			narrative_source = EXCLUDED.narrative_source,
+			narrative_model = EXCLUDED.narrative_model,
+			generated_at = EXCLUDED.generated_at
+		RETURNING id
+	`
+
+	review.GeneratedAt = time.Now()
+
+	return r.db.QueryRow(ctx, query,
+		uuid.New(),
+		review.UserID,
+		review.Year,
+		review.Content,
+		review.Narrative,
+		review.NarrativeSource,
+		review.NarrativeModel,
+		review.GeneratedAt,
+	).Scan(&review.ID)
+}
+
+// GetByUserAndYear retrieves a user's review of a year
+func (r *YearReviewRepository) GetByUserAndYear(ctx context.Context, userID uuid.UUID, year int) (*models.YearReview, error) {
+	query := `
+		SELECT id, user_id, year, content, narrative, narrative_source, narrative_model, generated_at
+		FROM year_reviews
+		WHERE user_id = $1 AND year = $2
+	`
+
+	review := &models.YearReview{}
+	err := r.db.QueryRow(ctx, query, userID, year).Scan(
+		&review.ID,
+		&review.UserID,
+		&review.Year,
+		&review.Content,
+		&review.Narrative,
+		&review.NarrativeSource,
+		&review.NarrativeModel,
+		&review.GeneratedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrYearReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
@@ -4,17 +4,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/habittracker/backend/internal/app"
 	"github.com/habittracker/backend/internal/config"
 	"github.com/habittracker/backend/internal/handlers"
 	"github.com/habittracker/backend/internal/middleware"
-	"github.com/habittracker/backend/internal/repository"
-	"github.com/habittracker/backend/internal/services"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 )
 
 // SetupRouter configures all routes and middleware
-func SetupRouter(db *pgxpool.Pool, redis *redis.Client, cfg *config.Config) *gin.Engine {
+func SetupRouter(a *app.App, cfg *config.Config) *gin.Engine {
 	// Set Gin mode
 	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
@@ -28,31 +25,23 @@ func SetupRouter(db *pgxpool.Pool, redis *redis.Client, cfg *config.Config) *gin
 	router.Use(middleware.CORSMiddleware(cfg))
 	router.Use(middleware.RateLimitMiddleware(100, time.Minute)) // 100 req/min
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
-	habitRepo := repository.NewHabitRepository(db)
-	logRepo := repository.NewLogRepository(db)
-	streakRepo := repository.NewStreakRepository(db)
-	reportRepo := repository.NewReportRepository(db)
-	revisionRepo := repository.NewRevisionRepository(db)
-
-	// Initialize services
-	authService := services.NewAuthService(userRepo, cfg)
-	gamificationService := services.NewGamificationService(userRepo)
-	habitService := services.NewHabitService(habitRepo, logRepo, streakRepo)
-	logService := services.NewLogService(logRepo, habitRepo, streakRepo, gamificationService)
-	geminiService := services.NewGeminiService(cfg)
-	reportService := services.NewReportService(reportRepo, habitRepo, logRepo, revisionRepo, geminiService)
-	syncService := services.NewSyncService(habitRepo, logRepo)
+	repos, svcs := a.Repositories, a.Services
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userRepo)
-	habitHandler := handlers.NewHabitHandler(habitService)
-	logHandler := handlers.NewLogHandler(logService, habitService)
-	reportHandler := handlers.NewReportHandler(reportService)
-	revisionHandler := handlers.NewRevisionHandler(revisionRepo, habitRepo)
-	syncHandler := handlers.NewSyncHandler(syncService)
+	authHandler := handlers.NewAuthHandler(svcs.Auth)
+	userHandler := handlers.NewUserHandler(repos.User, repos.Device, repos.NotificationPreference)
+	sessionHandler := handlers.NewSessionHandler(svcs.Auth)
+	habitHandler := handlers.NewHabitHandler(svcs.Habit)
+	logHandler := handlers.NewLogHandler(svcs.Log, svcs.Habit)
+	reportHandler := handlers.NewReportHandler(svcs.Report, svcs.ReportJob, svcs.YearReview)
+	revisionHandler := handlers.NewRevisionHandler(repos.Revision, repos.Habit)
+	syncHandler := handlers.NewSyncHandler(svcs.Sync)
+	freezeHandler := handlers.NewFreezeHandler(svcs.Freeze)
+	notificationHandler := handlers.NewNotificationHandler(repos.Notification)
+	insightHandler := handlers.NewInsightHandler(svcs.Insight)
+	noteHandler := handlers.NewNoteHandler(svcs.Note)
+	emailHandler := handlers.NewEmailHandler(svcs.Unsubscribe)
+	jwksHandler := handlers.NewJWKSHandler(a.KeyManager)
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -63,6 +52,9 @@ func SetupRouter(db *pgxpool.Pool, redis *redis.Client, cfg *config.Config) *gin
 		})
 	})
 
+	// Access token verification keys for other services
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
@@ -75,16 +67,30 @@ func SetupRouter(db *pgxpool.Pool, redis *redis.Client, cfg *config.Config) *gin
 
 			// Protected auth routes
 			authProtected := auth.Group("")
-			authProtected.Use(middleware.AuthMiddleware(authService))
+			authProtected.Use(middleware.AuthMiddleware(svcs.Auth))
 			{
 				authProtected.POST("/logout", authHandler.Logout)
+				authProtected.POST("/logout-all", authHandler.LogoutAll)
 				authProtected.PUT("/fcm-token", authHandler.UpdateFCMToken)
 			}
 		}
 
+		// Email link routes (public, authorized by a signed token)
+		email := v1.Group("/email")
+		{
+			email.GET("/unsubscribe", emailHandler.ConfirmUnsubscribe)
+			email.POST("/unsubscribe", emailHandler.Unsubscribe)
+		}
+
+		// Share link routes (public, authorized by a signed token)
+		share := v1.Group("/share")
+		{
+			share.GET("/year-review", reportHandler.GetSharedYearReview)
+		}
+
 		// Protected routes
 		protected := v1.Group("")
-		protected.Use(middleware.AuthMiddleware(authService))
+		protected.Use(middleware.AuthMiddleware(svcs.Auth))
 		{
 			// User routes
 			user := protected.Group("/user")
@@ -92,7 +98,14 @@ func SetupRouter(db *pgxpool.Pool, redis *redis.Client, cfg *config.Config) *gin
 				user.GET("/profile", userHandler.GetProfile)
 				user.PUT("/profile", userHandler.UpdateProfile)
 				user.PUT("/settings", userHandler.UpdateSettings)
+				user.GET("/settings/notifications", userHandler.GetNotificationSettings)
+				user.PUT("/settings/notifications", userHandler.UpdateNotificationSettings)
 				user.DELETE("/account", userHandler.DeleteAccount)
+				user.GET("/sessions", sessionHandler.GetSessions)
+				user.DELETE("/sessions/:id", sessionHandler.RevokeSession)
+				user.GET("/vacations", freezeHandler.GetVacations)
+				user.POST("/vacations", freezeHandler.CreateVacation)
+				user.DELETE("/vacations/:id", freezeHandler.DeleteVacation)
 			}
 
 			// Habit routes
@@ -104,6 +117,9 @@ func SetupRouter(db *pgxpool.Pool, redis *redis.Client, cfg *config.Config) *gin
 				habits.PUT("/:id", habitHandler.UpdateHabit)
 				habits.DELETE("/:id", habitHandler.DeleteHabit)
 				habits.GET("/:id/streak", habitHandler.GetHabitStreak)
+				habits.POST("/:id/freeze", freezeHandler.FreezeHabit)
+				habits.POST("/:id/snooze", habitHandler.SnoozeReminder)
+				habits.GET("/:id/insights", insightHandler.GetHabitInsights)
 			}
 
 			// Log routes
@@ -115,15 +131,25 @@ func SetupRouter(db *pgxpool.Pool, redis *redis.Client, cfg *config.Config) *gin
 				logs.GET("/habit/:id", logHandler.GetLogsByHabit)
 				logs.GET("/calendar/:month", logHandler.GetCalendarData)
 				logs.POST("/quick-complete/:habit_id", logHandler.QuickComplete)
+				logs.POST("/increment/:habit_id", logHandler.IncrementLog)
+			}
+
+			// Learning note routes
+			notes := protected.Group("/notes")
+			{
+				notes.GET("/search", noteHandler.SearchNotes)
+				notes.GET("/tags", noteHandler.GetTags)
 			}
 
 			// Report routes
 			reports := protected.Group("/reports")
 			{
 				reports.GET("", reportHandler.GetReports)
-				reports.GET("/:month", reportHandler.GetReport)
+				reports.GET("/:period", reportHandler.GetReport)
 				reports.POST("/generate", reportHandler.GenerateReport)
 				reports.POST("/regenerate", reportHandler.RegenerateReport)
+				reports.GET("/jobs/:id", reportHandler.GetReportJob)
+				reports.GET("/year/:year", reportHandler.GetYearReview)
 			}
 
 			// Revision routes
@@ -141,6 +167,15 @@ func SetupRouter(db *pgxpool.Pool, redis *redis.Client, cfg *config.Config) *gin
 				sync.GET("/pull", syncHandler.PullChanges)
 				sync.GET("/status", syncHandler.GetSyncStatus)
 			}
+
+			// Notification inbox routes
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", notificationHandler.GetNotifications)
+				notifications.GET("/unread-count", notificationHandler.GetUnreadCount)
+				notifications.PUT("/read-all", notificationHandler.MarkAllRead)
+				notifications.PUT("/:id/read", notificationHandler.MarkRead)
+			}
 		}
 	}
 
@@ -0,0 +1,628 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+	"github.com/habittracker/backend/internal/services"
+)
+
+const (
+	// streakAlertTime is the local time at-risk streak alerts go out
+	streakAlertTime = "20:00"
+	// monthlyReportTime is the local time on the 1st that last month's
+	// report is generated
+	monthlyReportTime = "00:05"
+	// streakRecomputeTime is the local time streaks are rebuilt after the
+	// previous day is over, so streaks missed yesterday are reset
+	streakRecomputeTime = "00:10"
+	// completionInsightsTime is the local time completion windows are
+	// relearned, after the previous day is over
+	completionInsightsTime = "03:00"
+	// weeklyDigestTime is the local time on Mondays that last week's digest
+	// goes out
+	weeklyDigestTime = "08:00"
+)
+
+// ReminderJob sends the morning and evening reminders at each user's chosen
+// local times
+type ReminderJob struct {
+	userRepo        *repository.UserRepository
+	freezeRepo      *repository.FreezeRepository
+	notificationSvc *services.NotificationService
+	dayResolver     *services.DayResolver
+}
+
+// NewReminderJob creates a new ReminderJob
+func NewReminderJob(
+	userRepo *repository.UserRepository,
+	freezeRepo *repository.FreezeRepository,
+	notificationSvc *services.NotificationService,
+	dayResolver *services.DayResolver,
+) *ReminderJob {
+	return &ReminderJob{
+		userRepo:        userRepo,
+		freezeRepo:      freezeRepo,
+		notificationSvc: notificationSvc,
+		dayResolver:     dayResolver,
+	}
+}
+
+// Name returns the job name
+func (j *ReminderJob) Name() string {
+	return "daily_reminders"
+}
+
+// Run sends the reminders whose local time fell within the window
+func (j *ReminderJob) Run(ctx context.Context, window Window) (int, error) {
+	users, err := j.userRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		loc := j.dayResolver.Location(user.Timezone)
+
+		if dates := occurrences(window, loc, user.MorningReminderTime); len(dates) > 0 {
+			if resting(ctx, j.freezeRepo, user, dates[len(dates)-1]) {
+				continue
+			}
+			if err := j.notificationSvc.SendMorningReminder(ctx, user, dates[len(dates)-1]); err != nil {
+				log.Printf("failed to queue morning reminder for user %s: %v", user.ID, err)
+			} else {
+				sent++
+			}
+		}
+
+		if dates := occurrences(window, loc, user.EveningReminderTime); len(dates) > 0 {
+			if resting(ctx, j.freezeRepo, user, dates[len(dates)-1]) {
+				continue
+			}
+			incomplete, err := j.notificationSvc.CountIncompleteToday(ctx, user)
+			if err != nil {
+				log.Printf("failed to count incomplete habits for user %s: %v", user.ID, err)
+				continue
+			}
+			if err := j.notificationSvc.SendEveningReminder(ctx, user, incomplete, dates[len(dates)-1]); err != nil {
+				log.Printf("failed to queue evening reminder for user %s: %v", user.ID, err)
+			} else if incomplete > 0 {
+				sent++
+			}
+		}
+	}
+
+	return sent, nil
+}
+
+// HabitReminderJob sends each habit's own reminder at its reminder time, or
+// when a snoozed reminder comes due
+type HabitReminderJob struct {
+	userRepo        *repository.UserRepository
+	habitRepo       *repository.HabitRepository
+	freezeRepo      *repository.FreezeRepository
+	notificationSvc *services.NotificationService
+	dayResolver     *services.DayResolver
+}
+
+// NewHabitReminderJob creates a new HabitReminderJob
+func NewHabitReminderJob(
+	userRepo *repository.UserRepository,
+	habitRepo *repository.HabitRepository,
+	freezeRepo *repository.FreezeRepository,
+	notificationSvc *services.NotificationService,
+	dayResolver *services.DayResolver,
+) *HabitReminderJob {
+	return &HabitReminderJob{
+		userRepo:        userRepo,
+		habitRepo:       habitRepo,
+		freezeRepo:      freezeRepo,
+		notificationSvc: notificationSvc,
+		dayResolver:     dayResolver,
+	}
+}
+
+// Name returns the job name
+func (j *HabitReminderJob) Name() string {
+	return "habit_reminders"
+}
+
+// Run sends the habit reminders that came due within the window. Habits
+// already completed, not due today or frozen for today are skipped.
+func (j *HabitReminderJob) Run(ctx context.Context, window Window) (int, error) {
+	users, err := j.userRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		habits, err := j.habitRepo.GetWithReminders(ctx, user.ID)
+		if err != nil {
+			log.Printf("failed to load reminders of user %s: %v", user.ID, err)
+			continue
+		}
+
+		loc := j.dayResolver.Location(user.Timezone)
+
+		due := make(map[uuid.UUID]string)
+		for _, habit := range habits {
+			if occurrence, ok := reminderDue(habit, window, loc); ok {
+				due[habit.ID] = occurrence
+			}
+		}
+		if len(due) == 0 || resting(ctx, j.freezeRepo, user, j.dayResolver.TodayIn(loc)) {
+			continue
+		}
+
+		count, err := j.notificationSvc.SendHabitReminders(ctx, user, due)
+		if err != nil {
+			log.Printf("failed to queue habit reminders for user %s: %v", user.ID, err)
+		}
+		sent += count
+	}
+
+	return sent, nil
+}
+
+// reminderDue reports whether a habit's reminder fell within the window,
+// and which occurrence of it: either a snoozed reminder came due, named by
+// the time the snooze ended, or its reminder time passed, named by its date.
+// A snooze only holds back the reminder of the day it was made on; a later
+// day's reminder goes out as usual and supersedes the snooze. The reminder
+// time the user set takes precedence; without one, the habit is nudged at a
+// time learned from its completion history.
+func reminderDue(habit *models.Habit, window Window, loc *time.Location) (string, bool) {
+	reminderTime := habit.ReminderTime
+	if reminderTime == nil {
+		reminderTime = habit.LearnedReminderTime
+	}
+
+	regular := func(w Window) []time.Time {
+		if reminderTime == nil {
+			return nil
+		}
+		return occurrences(w, loc, *reminderTime)
+	}
+
+	snoozed := habit.ReminderSnoozedUntil
+	if snoozed == nil {
+		if dates := regular(window); len(dates) > 0 {
+			return dates[len(dates)-1].Format(models.DateLayout), true
+		}
+		return "", false
+	}
+
+	snoozedOn := models.DateOf(*snoozed, loc)
+	if habit.ReminderSnoozedAt != nil {
+		snoozedOn = models.DateOf(*habit.ReminderSnoozedAt, loc)
+	}
+
+	if snoozed.After(window.From) && !snoozed.After(window.To) {
+		superseded := false
+		if habit.ReminderSnoozedAt != nil {
+			for _, date := range regular(Window{From: *habit.ReminderSnoozedAt, To: *snoozed}) {
+				if date.After(snoozedOn) {
+					superseded = true
+					break
+				}
+			}
+		}
+		if !superseded {
+			return snoozed.UTC().Format(time.RFC3339), true
+		}
+	}
+
+	dates := regular(window)
+	for i := len(dates) - 1; i >= 0; i-- {
+		if dates[i].After(snoozedOn) || !snoozed.After(window.To) {
+			return dates[i].Format(models.DateLayout), true
+		}
+	}
+	return "", false
+}
+
+// StreakAlertJob warns users in the evening about streaks that break unless
+// they complete the habit today
+type StreakAlertJob struct {
+	userRepo        *repository.UserRepository
+	freezeRepo      *repository.FreezeRepository
+	notificationSvc *services.NotificationService
+	dayResolver     *services.DayResolver
+}
+
+// NewStreakAlertJob creates a new StreakAlertJob
+func NewStreakAlertJob(
+	userRepo *repository.UserRepository,
+	freezeRepo *repository.FreezeRepository,
+	notificationSvc *services.NotificationService,
+	dayResolver *services.DayResolver,
+) *StreakAlertJob {
+	return &StreakAlertJob{
+		userRepo:        userRepo,
+		freezeRepo:      freezeRepo,
+		notificationSvc: notificationSvc,
+		dayResolver:     dayResolver,
+	}
+}
+
+// Name returns the job name
+func (j *StreakAlertJob) Name() string {
+	return "streak_alerts"
+}
+
+// Run sends at-risk streak alerts to users whose alert time fell within the
+// window
+func (j *StreakAlertJob) Run(ctx context.Context, window Window) (int, error) {
+	users, err := j.userRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		dates := occurrences(window, j.dayResolver.Location(user.Timezone), streakAlertTime)
+		if len(dates) == 0 || resting(ctx, j.freezeRepo, user, dates[len(dates)-1]) {
+			continue
+		}
+
+		count, err := j.notificationSvc.CheckAndSendStreakAlerts(ctx, user)
+		if err != nil {
+			log.Printf("failed to queue streak alerts for user %s: %v", user.ID, err)
+		}
+		sent += count
+	}
+
+	return sent, nil
+}
+
+// StreakRecomputeJob rebuilds each user's streaks shortly after their local
+// midnight. Streaks are otherwise only rebuilt on writes, so without it a
+// streak would outlive the day it was missed.
+type StreakRecomputeJob struct {
+	userRepo    *repository.UserRepository
+	streakSvc   *services.StreakService
+	dayResolver *services.DayResolver
+}
+
+// NewStreakRecomputeJob creates a new StreakRecomputeJob
+func NewStreakRecomputeJob(
+	userRepo *repository.UserRepository,
+	streakSvc *services.StreakService,
+	dayResolver *services.DayResolver,
+) *StreakRecomputeJob {
+	return &StreakRecomputeJob{
+		userRepo:    userRepo,
+		streakSvc:   streakSvc,
+		dayResolver: dayResolver,
+	}
+}
+
+// Name returns the job name
+func (j *StreakRecomputeJob) Name() string {
+	return "streak_recompute"
+}
+
+// Run rebuilds the streaks of users whose rebuild time fell within the window
+func (j *StreakRecomputeJob) Run(ctx context.Context, window Window) (int, error) {
+	users, err := j.userRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rebuilt := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return rebuilt, ctx.Err()
+		}
+
+		if len(occurrences(window, j.dayResolver.Location(user.Timezone), streakRecomputeTime)) == 0 {
+			continue
+		}
+
+		count, err := j.streakSvc.RecomputeUser(ctx, user.ID)
+		if err != nil {
+			log.Printf("failed to recompute streaks of user %s: %v", user.ID, err)
+		}
+		rebuilt += count
+	}
+
+	return rebuilt, nil
+}
+
+// CompletionInsightsJob relearns each user's habit completion windows once
+// a day, so reminders follow changes in routine
+type CompletionInsightsJob struct {
+	userRepo    *repository.UserRepository
+	insightSvc  *services.InsightService
+	dayResolver *services.DayResolver
+}
+
+// NewCompletionInsightsJob creates a new CompletionInsightsJob
+func NewCompletionInsightsJob(
+	userRepo *repository.UserRepository,
+	insightSvc *services.InsightService,
+	dayResolver *services.DayResolver,
+) *CompletionInsightsJob {
+	return &CompletionInsightsJob{
+		userRepo:    userRepo,
+		insightSvc:  insightSvc,
+		dayResolver: dayResolver,
+	}
+}
+
+// Name returns the job name
+func (j *CompletionInsightsJob) Name() string {
+	return "completion_insights"
+}
+
+// Run relearns the completion windows of users whose relearn time fell
+// within the window
+func (j *CompletionInsightsJob) Run(ctx context.Context, window Window) (int, error) {
+	users, err := j.userRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return refreshed, ctx.Err()
+		}
+
+		if len(occurrences(window, j.dayResolver.Location(user.Timezone), completionInsightsTime)) == 0 {
+			continue
+		}
+
+		count, err := j.insightSvc.RefreshUser(ctx, user)
+		if err != nil {
+			log.Printf("failed to learn completion windows of user %s: %v", user.ID, err)
+		}
+		refreshed += count
+	}
+
+	return refreshed, nil
+}
+
+// MonthlyReportJob queues each active user's report for the month that just
+// ended, shortly after midnight on the 1st in their timezone. The report
+// workers generate it and notify the user.
+type MonthlyReportJob struct {
+	userRepo     *repository.UserRepository
+	habitRepo    *repository.HabitRepository
+	reportJobSvc *services.ReportJobService
+	dayResolver  *services.DayResolver
+}
+
+// NewMonthlyReportJob creates a new MonthlyReportJob
+func NewMonthlyReportJob(
+	userRepo *repository.UserRepository,
+	habitRepo *repository.HabitRepository,
+	reportJobSvc *services.ReportJobService,
+	dayResolver *services.DayResolver,
+) *MonthlyReportJob {
+	return &MonthlyReportJob{
+		userRepo:     userRepo,
+		habitRepo:    habitRepo,
+		reportJobSvc: reportJobSvc,
+		dayResolver:  dayResolver,
+	}
+}
+
+// Name returns the job name
+func (j *MonthlyReportJob) Name() string {
+	return "monthly_reports"
+}
+
+// Run queues the reports of users whose month ended within the window
+func (j *MonthlyReportJob) Run(ctx context.Context, window Window) (int, error) {
+	users, err := j.userRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return queued, ctx.Err()
+		}
+
+		for _, date := range occurrences(window, j.dayResolver.Location(user.Timezone), monthlyReportTime) {
+			if date.Day() != 1 {
+				continue
+			}
+
+			habits, err := j.habitRepo.GetActiveByUserID(ctx, user.ID)
+			if err != nil {
+				log.Printf("failed to load habits of user %s: %v", user.ID, err)
+				continue
+			}
+			if len(habits) == 0 {
+				continue
+			}
+
+			// A replayed window, or a tap in the app, joins the queued job
+			month := date.AddDate(0, -1, 0)
+			_, created, err := j.reportJobSvc.Enqueue(ctx, user.ID, models.ReportJobGenerate, models.MonthPeriod(month.Year(), int(month.Month())))
+			if err != nil {
+				log.Printf("failed to queue %s report for user %s: %v", month.Format("2006-01"), user.ID, err)
+				continue
+			}
+			if created {
+				queued++
+			}
+		}
+	}
+
+	return queued, nil
+}
+
+// WeeklyDigestJob sends each user with active habits a digest of the week
+// that just ended, on Monday morning in their timezone
+type WeeklyDigestJob struct {
+	userRepo        *repository.UserRepository
+	habitRepo       *repository.HabitRepository
+	digestSvc       *services.DigestService
+	notificationSvc *services.NotificationService
+	dayResolver     *services.DayResolver
+}
+
+// NewWeeklyDigestJob creates a new WeeklyDigestJob
+func NewWeeklyDigestJob(
+	userRepo *repository.UserRepository,
+	habitRepo *repository.HabitRepository,
+	digestSvc *services.DigestService,
+	notificationSvc *services.NotificationService,
+	dayResolver *services.DayResolver,
+) *WeeklyDigestJob {
+	return &WeeklyDigestJob{
+		userRepo:        userRepo,
+		habitRepo:       habitRepo,
+		digestSvc:       digestSvc,
+		notificationSvc: notificationSvc,
+		dayResolver:     dayResolver,
+	}
+}
+
+// Name returns the job name
+func (j *WeeklyDigestJob) Name() string {
+	return "weekly_digests"
+}
+
+// Run queues the digests of users whose Monday digest time fell within the
+// window. Whether a digest is emailed, shown in the inbox or pushed is up to
+// the user's preference for it.
+func (j *WeeklyDigestJob) Run(ctx context.Context, window Window) (int, error) {
+	users, err := j.userRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		for _, date := range occurrences(window, j.dayResolver.Location(user.Timezone), weeklyDigestTime) {
+			if date.Weekday() != time.Monday {
+				continue
+			}
+
+			habits, err := j.habitRepo.GetActiveByUserID(ctx, user.ID)
+			if err != nil {
+				log.Printf("failed to load habits of user %s: %v", user.ID, err)
+				continue
+			}
+			if len(habits) == 0 {
+				continue
+			}
+
+			digest, err := j.digestSvc.BuildWeeklyDigest(ctx, user, date.AddDate(0, 0, -7))
+			if err != nil {
+				log.Printf("failed to build weekly digest for user %s: %v", user.ID, err)
+				continue
+			}
+
+			if err := j.notificationSvc.SendWeeklyDigest(ctx, user, digest); err != nil {
+				log.Printf("failed to queue weekly digest for user %s: %v", user.ID, err)
+				continue
+			}
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// NotificationDeliveryJob pushes the notifications waiting in the outbox
+type NotificationDeliveryJob struct {
+	notificationSvc *services.NotificationService
+}
+
+// NewNotificationDeliveryJob creates a new NotificationDeliveryJob
+func NewNotificationDeliveryJob(notificationSvc *services.NotificationService) *NotificationDeliveryJob {
+	return &NotificationDeliveryJob{
+		notificationSvc: notificationSvc,
+	}
+}
+
+// Name returns the job name
+func (j *NotificationDeliveryJob) Name() string {
+	return "notification_delivery"
+}
+
+// Run delivers every due notification; the outbox, not the window, tracks
+// what is left to send
+func (j *NotificationDeliveryJob) Run(ctx context.Context, window Window) (int, error) {
+	return j.notificationSvc.DeliverPending(ctx)
+}
+
+// EmailDeliveryJob emails the notifications waiting in the outbox that are
+// addressed to email
+type EmailDeliveryJob struct {
+	emailSvc *services.EmailService
+}
+
+// NewEmailDeliveryJob creates a new EmailDeliveryJob
+func NewEmailDeliveryJob(emailSvc *services.EmailService) *EmailDeliveryJob {
+	return &EmailDeliveryJob{
+		emailSvc: emailSvc,
+	}
+}
+
+// Name returns the job name
+func (j *EmailDeliveryJob) Name() string {
+	return "email_delivery"
+}
+
+// Run delivers every due email; like push delivery it ignores the window
+func (j *EmailDeliveryJob) Run(ctx context.Context, window Window) (int, error) {
+	return j.emailSvc.DeliverPending(ctx)
+}
+
+// resting reports whether date is one of the user's rest days
+func resting(ctx context.Context, freezeRepo *repository.FreezeRepository, user *models.User, date time.Time) bool {
+	vacations, err := freezeRepo.GetVacationsInRange(ctx, user.ID, date, date)
+	if err != nil {
+		log.Printf("failed to load vacations of user %s: %v", user.ID, err)
+		return false
+	}
+	return len(vacations) > 0
+}
+
+// occurrences returns the local calendar dates on which the time of day
+// clock (HH:MM or HH:MM:SS) in loc fell within the window
+func occurrences(window Window, loc *time.Location, clock string) []time.Time {
+	at, err := models.ParseClock(clock)
+	if err != nil {
+		return nil
+	}
+
+	var dates []time.Time
+	last := models.DateOf(window.To, loc)
+	for date := models.DateOf(window.From, loc); !date.After(last); date = date.AddDate(0, 0, 1) {
+		moment := models.AtClock(date, at, loc)
+		if moment.After(window.From) && !moment.After(window.To) {
+			dates = append(dates, date)
+		}
+	}
+
+	return dates
+}
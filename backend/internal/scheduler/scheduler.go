@@ -0,0 +1,255 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// leaderLockKey is the Postgres advisory lock held by the replica that runs
+// the jobs
+const leaderLockKey int64 = 7_315_482_910
+
+// jobRunRetention is how long job run history is kept
+const jobRunRetention = 30 * 24 * time.Hour
+
+// Window is the span of time (From, To] a job run is responsible for
+type Window struct {
+	From time.Time
+	To   time.Time
+}
+
+// Job is a unit of scheduled background work. Run handles everything that
+// became due within the window and returns the number of items processed.
+type Job interface {
+	Name() string
+	Run(ctx context.Context, window Window) (int, error)
+}
+
+// registeredJob pairs a job with how far back a missed window is caught up
+type registeredJob struct {
+	job     Job
+	catchUp time.Duration
+}
+
+// Scheduler runs registered jobs on a fixed interval. Only the replica
+// holding the leader advisory lock runs them, so several API replicas can
+// start a scheduler without sending anything twice.
+type Scheduler struct {
+	db         *pgxpool.Pool
+	jobRunRepo *repository.JobRunRepository
+	interval   time.Duration
+	instance   string
+	jobs       []registeredJob
+
+	leader *pgxpool.Conn
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.Mutex
+}
+
+// New creates a new Scheduler that ticks every interval
+func New(db *pgxpool.Pool, jobRunRepo *repository.JobRunRepository, interval time.Duration) *Scheduler {
+	hostname, _ := os.Hostname()
+
+	return &Scheduler{
+		db:         db,
+		jobRunRepo: jobRunRepo,
+		interval:   interval,
+		instance:   fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// Register adds a job. catchUp bounds how far back the first window reaches
+// after the scheduler was down, so stale events are not sent late.
+func (s *Scheduler) Register(job Job, catchUp time.Duration) {
+	s.jobs = append(s.jobs, registeredJob{job: job, catchUp: catchUp})
+}
+
+// Start runs the scheduler loop in the background until Stop is called
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.loop(ctx)
+	log.Printf("Scheduler started with %d jobs (instance %s)", len(s.jobs), s.instance)
+}
+
+// Stop cancels the running jobs and waits for the loop to exit, or for ctx
+// to expire
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+		log.Println("Scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop ticks until ctx is cancelled, running the jobs while leading
+func (s *Scheduler) loop(ctx context.Context) {
+	defer close(s.done)
+	defer s.resign()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if s.elect(ctx) {
+			s.runJobs(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// elect reports whether this instance leads, trying to take the advisory
+// lock if it does not. The lock lives on a dedicated connection, so losing
+// the connection hands leadership to another replica.
+func (s *Scheduler) elect(ctx context.Context) bool {
+	if s.leader != nil {
+		if _, err := s.leader.Exec(ctx, "SELECT 1"); err == nil {
+			return true
+		}
+		log.Printf("Scheduler lost its leader connection")
+		s.leader.Conn().Close(context.Background())
+		s.leader.Release()
+		s.leader = nil
+	}
+
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Scheduler failed to acquire a connection: %v", err)
+		}
+		return false
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockKey).Scan(&acquired); err != nil || !acquired {
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Scheduler failed to take the leader lock: %v", err)
+		}
+		conn.Release()
+		return false
+	}
+
+	log.Printf("Scheduler instance %s is now the leader", s.instance)
+	s.leader = conn
+	return true
+}
+
+// resign releases the leader lock so another replica can take over at once
+func (s *Scheduler) resign() {
+	if s.leader == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.leader.Exec(ctx, "SELECT pg_advisory_unlock($1)", leaderLockKey); err != nil {
+		// Closing the session drops the lock as well
+		s.leader.Conn().Close(ctx)
+	}
+	s.leader.Release()
+	s.leader = nil
+}
+
+// runJobs runs every registered job once, each over the window since its
+// last successful run
+func (s *Scheduler) runJobs(ctx context.Context) {
+	now := time.Now()
+
+	for _, registered := range s.jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		s.runJob(ctx, registered, now)
+	}
+
+	if _, err := s.jobRunRepo.DeleteOlderThan(ctx, now.Add(-jobRunRetention)); err != nil && ctx.Err() == nil {
+		log.Printf("Scheduler failed to prune job runs: %v", err)
+	}
+}
+
+// runJob runs a job and records the run in the job history. A failed run
+// leaves its window to be retried by the next run; notifications are queued
+// once per occurrence, so those queued before the failure are not repeated.
+func (s *Scheduler) runJob(ctx context.Context, registered registeredJob, now time.Time) {
+	name := registered.job.Name()
+
+	from := now.Add(-s.interval)
+	if last, err := s.jobRunRepo.GetLastSucceeded(ctx, name); err == nil {
+		from = last.WindowEnd
+	} else if err != repository.ErrJobRunNotFound {
+		log.Printf("Scheduler failed to load last run of %s: %v", name, err)
+		return
+	}
+
+	if earliest := now.Add(-registered.catchUp); from.Before(earliest) {
+		from = earliest
+	}
+	if !from.Before(now) {
+		return
+	}
+
+	run := &models.JobRun{
+		JobName:     name,
+		Instance:    s.instance,
+		WindowStart: from,
+		WindowEnd:   now,
+	}
+	if err := s.jobRunRepo.Create(ctx, run); err != nil {
+		log.Printf("Scheduler failed to record run of %s: %v", name, err)
+		return
+	}
+
+	count, err := registered.job.Run(ctx, Window{From: from, To: now})
+	run.ItemsProcessed = count
+	run.Status = models.JobRunSucceeded
+	if err != nil {
+		message := err.Error()
+		run.Status = models.JobRunFailed
+		run.Error = &message
+		log.Printf("Job %s failed: %v", name, err)
+	}
+
+	// Record the outcome even when shutting down mid-run
+	finishCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.jobRunRepo.Finish(finishCtx, run); err != nil {
+		log.Printf("Scheduler failed to record outcome of %s: %v", name, err)
+	}
+}
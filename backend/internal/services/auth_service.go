@@ -2,6 +2,10 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -17,32 +21,69 @@ var (
 	ErrTokenExpired     = errors.New("token expired")
 	ErrUserNotFound     = errors.New("user not found")
 	ErrInvalidFirebase  = errors.New("invalid firebase token")
+	ErrEmailRequired    = errors.New("email is required")
+	ErrTokenReused      = errors.New("refresh token reuse detected")
+	ErrSessionRevoked   = errors.New("session revoked")
+)
+
+const (
+	accessTokenIssuer = "habit-tracker"
+	refreshTokenBytes = 32
+
+	// sessionTouchInterval throttles last-used bookkeeping on sessions
+	sessionTouchInterval = time.Minute
 )
 
 // AuthService handles authentication logic
 type AuthService struct {
-	userRepo *repository.UserRepository
-	config   *config.Config
+	userRepo         *repository.UserRepository
+	refreshTokenRepo *repository.RefreshTokenRepository
+	sessionRepo      *repository.SessionRepository
+	deviceRepo       *repository.DeviceRepository
+	firebaseVerifier *FirebaseVerifier
+	keyManager       *KeyManager
+	config           *config.Config
 }
 
 // NewAuthService creates a new AuthService
-func NewAuthService(userRepo *repository.UserRepository, cfg *config.Config) *AuthService {
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	sessionRepo *repository.SessionRepository,
+	deviceRepo *repository.DeviceRepository,
+	firebaseVerifier *FirebaseVerifier,
+	keyManager *KeyManager,
+	cfg *config.Config,
+) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
-		config:   cfg,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		deviceRepo:       deviceRepo,
+		firebaseVerifier: firebaseVerifier,
+		keyManager:       keyManager,
+		config:           cfg,
 	}
 }
 
 // JWTClaims represents JWT claims
 type JWTClaims struct {
 	UserID      uuid.UUID `json:"user_id"`
+	SessionID   uuid.UUID `json:"sid"`
 	FirebaseUID string    `json:"firebase_uid"`
 	Email       string    `json:"email"`
 	jwt.RegisteredClaims
 }
 
+// VerifyFirebaseToken verifies a Firebase ID token and returns the identity it carries
+func (s *AuthService) VerifyFirebaseToken(ctx context.Context, idToken string) (*FirebaseIdentity, error) {
+	return s.firebaseVerifier.Verify(ctx, idToken)
+}
+
 // RegisterUser registers a new user after Firebase authentication
-func (s *AuthService) RegisterUser(ctx context.Context, req *models.AuthRegisterRequest, firebaseUID string) (*models.AuthTokenResponse, error) {
+func (s *AuthService) RegisterUser(ctx context.Context, req *models.AuthRegisterRequest, identity *FirebaseIdentity, device *models.DeviceInfo) (*models.AuthTokenResponse, error) {
+	firebaseUID := identity.UID
+
 	// Check if user already exists
 	exists, err := s.userRepo.Exists(ctx, firebaseUID)
 	if err != nil {
@@ -57,10 +98,19 @@ func (s *AuthService) RegisterUser(ctx context.Context, req *models.AuthRegister
 			return nil, err
 		}
 	} else {
+		// Prefer the email Firebase vouches for over the one in the request body
+		email := identity.Email
+		if email == "" {
+			email = req.Email
+		}
+		if email == "" {
+			return nil, ErrEmailRequired
+		}
+
 		// Create new user
 		user = &models.User{
 			FirebaseUID:         firebaseUID,
-			Email:               req.Email,
+			Email:               email,
 			DisplayName:         req.DisplayName,
 			Timezone:            "UTC",
 			NotificationEnabled: true,
@@ -73,13 +123,12 @@ func (s *AuthService) RegisterUser(ctx context.Context, req *models.AuthRegister
 		}
 	}
 
-	// Generate tokens
-	return s.generateTokenResponse(user)
+	return s.startSession(ctx, user, device)
 }
 
-// Login authenticates a user with Firebase token
-func (s *AuthService) Login(ctx context.Context, firebaseUID string) (*models.AuthTokenResponse, error) {
-	user, err := s.userRepo.GetByFirebaseUID(ctx, firebaseUID)
+// Login authenticates a user with a verified Firebase identity
+func (s *AuthService) Login(ctx context.Context, identity *FirebaseIdentity, device *models.DeviceInfo) (*models.AuthTokenResponse, error) {
+	user, err := s.userRepo.GetByFirebaseUID(ctx, identity.UID)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
 			return nil, ErrUserNotFound
@@ -87,32 +136,159 @@ func (s *AuthService) Login(ctx context.Context, firebaseUID string) (*models.Au
 		return nil, err
 	}
 
-	return s.generateTokenResponse(user)
+	return s.startSession(ctx, user, device)
+}
+
+// startSession records a new device session and issues its first tokens.
+// The session ID is used as the refresh token family ID.
+func (s *AuthService) startSession(ctx context.Context, user *models.User, device *models.DeviceInfo) (*models.AuthTokenResponse, error) {
+	session := &models.Session{
+		UserID:     user.ID,
+		DeviceName: device.Name,
+		Platform:   device.Platform,
+		UserAgent:  &device.UserAgent,
+		LastIP:     &device.IPAddress,
+	}
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return s.generateTokenResponse(ctx, user, session.ID, device.Name)
 }
 
-// RefreshToken refreshes the access token
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*models.AuthTokenResponse, error) {
-	claims, err := s.ValidateToken(refreshToken)
+// RefreshToken rotates a refresh token: the presented token is consumed and a
+// new access/refresh pair in the same family is issued. Presenting a token
+// that was already used revokes the whole family, since either the client or
+// an attacker is replaying a stolen token.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken, ip string) (*models.AuthTokenResponse, error) {
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
 	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return nil, ErrInvalidToken
+		}
 		return nil, err
 	}
 
-	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if stored.RevokedAt != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if stored.UsedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+
+	if stored.IsExpired(time.Now()) {
+		return nil, ErrTokenExpired
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, stored.FamilyID)
 	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return nil, ErrInvalidToken
+		}
 		return nil, err
 	}
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
 
-	return s.generateTokenResponse(user)
+	if err := s.refreshTokenRepo.MarkUsed(ctx, stored.ID); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenConsumed) {
+			// Lost a race with another refresh using the same token
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Touch(ctx, session.ID, ip); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.generateTokenResponse(ctx, user, stored.FamilyID, stored.DeviceLabel)
+}
+
+// Logout revokes the presented refresh token and ends its session
+func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID, refreshToken string) error {
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	if stored.UserID != userID || stored.RevokedAt != nil {
+		return ErrInvalidToken
+	}
+
+	return s.RevokeSession(ctx, userID, stored.FamilyID)
+}
+
+// LogoutAll revokes every session and refresh token of a user
+func (s *AuthService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	if err := s.sessionRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeAllForUser(ctx, userID)
+}
+
+// GetActiveSessions retrieves the active sessions of a user
+func (s *AuthService) GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	return s.sessionRepo.GetActiveByUser(ctx, userID)
+}
+
+// RevokeSession ends a session and revokes its refresh tokens
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := s.sessionRepo.Revoke(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeFamily(ctx, sessionID)
+}
+
+// ValidateSession checks that the session an access token was issued for is
+// still active, recording activity at most once per sessionTouchInterval
+func (s *AuthService) ValidateSession(ctx context.Context, claims *JWTClaims, ip string) error {
+	session, err := s.sessionRepo.GetByID(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+
+	if session.RevokedAt != nil || session.UserID != claims.UserID {
+		return ErrSessionRevoked
+	}
+
+	if time.Since(session.LastUsedAt) > sessionTouchInterval {
+		return s.sessionRepo.Touch(ctx, session.ID, ip)
+	}
+
+	return nil
+}
+
+// revokeReusedFamily revokes a token family and its session after reuse was detected
+func (s *AuthService) revokeReusedFamily(ctx context.Context, token *models.RefreshToken) error {
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+	if err := s.sessionRepo.Revoke(ctx, token.UserID, token.FamilyID); err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+		return err
+	}
+	return ErrTokenReused
 }
 
 // ValidateToken validates a JWT token and returns claims
 func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return []byte(s.config.JWTSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, s.keyManager.Keyfunc,
+		jwt.WithValidMethods(s.keyManager.ValidMethods()),
+	)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -122,7 +298,7 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	}
 
 	claims, ok := token.Claims.(*JWTClaims)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || claims.Issuer != accessTokenIssuer {
 		return nil, ErrInvalidToken
 	}
 
@@ -140,13 +316,13 @@ func (s *AuthService) GetUserFromToken(ctx context.Context, tokenString string)
 }
 
 // generateTokenResponse generates access and refresh tokens
-func (s *AuthService) generateTokenResponse(user *models.User) (*models.AuthTokenResponse, error) {
-	accessToken, err := s.generateAccessToken(user)
+func (s *AuthService) generateTokenResponse(ctx context.Context, user *models.User, sessionID uuid.UUID, deviceLabel *string) (*models.AuthTokenResponse, error) {
+	accessToken, err := s.generateAccessToken(user, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := s.generateRefreshToken(user)
+	refreshToken, err := s.issueRefreshToken(ctx, user, sessionID, deviceLabel)
 	if err != nil {
 		return nil, err
 	}
@@ -161,40 +337,57 @@ func (s *AuthService) generateTokenResponse(user *models.User) (*models.AuthToke
 }
 
 // generateAccessToken generates an access token
-func (s *AuthService) generateAccessToken(user *models.User) (string, error) {
+func (s *AuthService) generateAccessToken(user *models.User, sessionID uuid.UUID) (string, error) {
 	claims := &JWTClaims{
 		UserID:      user.ID,
+		SessionID:   sessionID,
 		FirebaseUID: user.FirebaseUID,
 		Email:       user.Email,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.config.JWTExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "habit-tracker",
+			Issuer:    accessTokenIssuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.JWTSecret))
+	return s.keyManager.Sign(claims)
 }
 
-// generateRefreshToken generates a refresh token
-func (s *AuthService) generateRefreshToken(user *models.User) (string, error) {
-	claims := &JWTClaims{
+// issueRefreshToken generates an opaque refresh token and stores its hash
+func (s *AuthService) issueRefreshToken(ctx context.Context, user *models.User, familyID uuid.UUID, deviceLabel *string) (string, error) {
+	raw := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	err := s.refreshTokenRepo.Create(ctx, &models.RefreshToken{
 		UserID:      user.ID,
-		FirebaseUID: user.FirebaseUID,
-		Email:       user.Email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.config.RefreshExpiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "habit-tracker-refresh",
-		},
+		FamilyID:    familyID,
+		TokenHash:   hashRefreshToken(token),
+		DeviceLabel: deviceLabel,
+		ExpiresAt:   time.Now().Add(s.config.RefreshExpiry),
+	})
+	if err != nil {
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.JWTSecret))
+	return token, nil
 }
 
-// UpdateFCMToken updates user's FCM token
-func (s *AuthService) UpdateFCMToken(ctx context.Context, userID uuid.UUID, fcmToken string) error {
-	return s.userRepo.UpdateFCMToken(ctx, userID, fcmToken)
+// hashRefreshToken returns the hex-encoded SHA-256 of a refresh token
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// UpdateFCMToken registers the FCM token of one of the user's devices
+func (s *AuthService) UpdateFCMToken(ctx context.Context, userID uuid.UUID, req *models.FCMTokenUpdateRequest) error {
+	return s.deviceRepo.Upsert(ctx, &models.UserDevice{
+		UserID:     userID,
+		FCMToken:   req.FCMToken,
+		Platform:   req.Platform,
+		AppVersion: req.AppVersion,
+		Locale:     req.Locale,
+	})
 }
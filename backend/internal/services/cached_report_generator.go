@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// reportCacheVersion is part of every cache key. Bump it when the prompt or
+// schema changes so cached reports from the old one are not served.
+const reportCacheVersion = 3
+
+// CachedReportGenerator serves model-written reports from Redis when the
+// input is identical to an earlier generation, e.g. when regenerating a
+// month whose data has not changed. Fallback and mock reports are never
+// cached, so a model outage is not remembered.
+type CachedReportGenerator struct {
+	next  ReportGenerator
+	model string
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+// NewCachedReportGenerator creates a new CachedReportGenerator in front of
+// the generator of a model
+func NewCachedReportGenerator(next ReportGenerator, model string, redisClient *redis.Client, ttl time.Duration) *CachedReportGenerator {
+	return &CachedReportGenerator{
+		next:  next,
+		model: model,
+		redis: redisClient,
+		ttl:   ttl,
+	}
+}
+
+// Name returns the name of the underlying provider
+func (g *CachedReportGenerator) Name() string {
+	return g.next.Name()
+}
+
+// GenerateReport returns the cached report for the input if there is
+// one and generates it otherwise
+func (g *CachedReportGenerator) GenerateReport(ctx context.Context, input *models.ReportGenerationInput) (*models.ReportGeneration, error) {
+	key, err := g.cacheKey(input)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := g.redis.Get(ctx, key).Bytes()
+	if err == nil {
+		var content models.ReportContent
+		if err := json.Unmarshal(cached, &content); err == nil {
+			return &models.ReportGeneration{
+				Content: &content,
+				Source:  models.ReportSourceCache,
+				Model:   g.model,
+			}, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		log.Printf("Failed to read report cache: %v", err)
+	}
+
+	generation, err := g.next.GenerateReport(ctx, input)
+	if err != nil || generation.Source != models.ReportSourceModel {
+		return generation, err
+	}
+
+	if content, err := json.Marshal(generation.Content); err == nil {
+		if err := g.redis.Set(ctx, key, content, g.ttl).Err(); err != nil {
+			log.Printf("Failed to write report cache: %v", err)
+		}
+	}
+
+	return generation, nil
+}
+
+// WriteYearNarrative writes a year narrative without caching, as year
+// reviews are stored with their narrative
+func (g *CachedReportGenerator) WriteYearNarrative(ctx context.Context, userID uuid.UUID, content *models.YearReviewContent) (*models.YearNarrativeGeneration, error) {
+	return g.next.WriteYearNarrative(ctx, userID, content)
+}
+
+// cacheKey hashes everything that determines the model's report
+func (g *CachedReportGenerator) cacheKey(input *models.ReportGenerationInput) (string, error) {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	json.NewEncoder(hash).Encode([]interface{}{reportCacheVersion, g.next.Name(), g.model})
+	hash.Write(inputJSON)
+
+	return "report_generation:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
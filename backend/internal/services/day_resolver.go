@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+var (
+	ErrInvalidTimezone = errors.New("invalid timezone")
+)
+
+// DayResolver resolves calendar days in a user's local timezone, so that
+// "today" flips at the user's midnight rather than the server's
+type DayResolver struct {
+	userRepo *repository.UserRepository
+	now      func() time.Time
+
+	locations sync.Map // timezone name -> *time.Location
+}
+
+// NewDayResolver creates a new DayResolver
+func NewDayResolver(userRepo *repository.UserRepository) *DayResolver {
+	return &DayResolver{
+		userRepo: userRepo,
+		now:      time.Now,
+	}
+}
+
+// LoadTimezone parses an IANA timezone name such as "Asia/Kolkata".
+// "Local" is rejected because it means the server's zone, not the user's.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	return loc, nil
+}
+
+// Location returns the location for a timezone name, falling back to UTC for
+// values stored before timezones were validated
+func (r *DayResolver) Location(timezone string) *time.Location {
+	if cached, ok := r.locations.Load(timezone); ok {
+		return cached.(*time.Location)
+	}
+
+	loc, err := LoadTimezone(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	r.locations.Store(timezone, loc)
+	return loc
+}
+
+// TodayFor returns the current calendar date in a user's timezone
+func (r *DayResolver) TodayFor(user *models.User) time.Time {
+	return r.TodayIn(r.Location(user.Timezone))
+}
+
+// TodayIn returns the current calendar date in a location
+func (r *DayResolver) TodayIn(loc *time.Location) time.Time {
+	return models.DateOf(r.now(), loc)
+}
+
+// UserLocation looks up a user and returns their timezone's location
+func (r *DayResolver) UserLocation(ctx context.Context, userID uuid.UUID) (*time.Location, error) {
+	user, err := r.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Location(user.Timezone), nil
+}
+
+// Today looks up a user and returns their current calendar date
+func (r *DayResolver) Today(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	loc, err := r.UserLocation(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return r.TodayIn(loc), nil
+}
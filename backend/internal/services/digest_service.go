@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+// DigestService summarizes a user's past week for the weekly digest
+type DigestService struct {
+	userRepo    *repository.UserRepository
+	habitRepo   *repository.HabitRepository
+	logRepo     *repository.LogRepository
+	streakRepo  *repository.StreakRepository
+	freezeRepo  *repository.FreezeRepository
+	dayResolver *DayResolver
+}
+
+// NewDigestService creates a new DigestService
+func NewDigestService(
+	userRepo *repository.UserRepository,
+	habitRepo *repository.HabitRepository,
+	logRepo *repository.LogRepository,
+	streakRepo *repository.StreakRepository,
+	freezeRepo *repository.FreezeRepository,
+	dayResolver *DayResolver,
+) *DigestService {
+	return &DigestService{
+		userRepo:    userRepo,
+		habitRepo:   habitRepo,
+		logRepo:     logRepo,
+		streakRepo:  streakRepo,
+		freezeRepo:  freezeRepo,
+		dayResolver: dayResolver,
+	}
+}
+
+// BuildWeeklyDigest summarizes the Monday-Sunday week starting at weekStart
+// for each of the user's active habits: due days completed, frozen and rest
+// days aside, and how the streak moved. XP is counted over the week's local
+// days.
+func (s *DigestService) BuildWeeklyDigest(ctx context.Context, user *models.User, weekStart time.Time) (*models.WeeklyDigest, error) {
+	loc := s.dayResolver.Location(user.Timezone)
+	weekEnd := weekStart.AddDate(0, 0, 6)
+
+	habits, err := s.habitRepo.GetActiveByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := s.logRepo.GetByUserAndDateRange(ctx, user.ID, weekStart, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[uuid.UUID]map[time.Time]bool)
+	for _, log := range logs {
+		if !log.Completed {
+			continue
+		}
+		if completed[log.HabitID] == nil {
+			completed[log.HabitID] = make(map[time.Time]bool)
+		}
+		completed[log.HabitID][models.DateOf(log.LogDate, time.UTC)] = true
+	}
+
+	freezes, err := s.freezeRepo.GetByUserAndDateRange(ctx, user.ID, weekStart, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	vacations, err := s.freezeRepo.GetVacationsInRange(ctx, user.ID, weekStart, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+	rest := models.RestDays(vacations, weekStart, weekEnd)
+
+	digest := &models.WeeklyDigest{
+		UserID:    user.ID,
+		WeekStart: weekStart,
+		WeekEnd:   weekEnd,
+	}
+
+	for _, habit := range habits {
+		from := weekStart
+		if created := models.DateOf(habit.CreatedAt, loc); from.Before(created) {
+			from = created
+		}
+		if from.After(weekEnd) {
+			continue
+		}
+
+		skipped := make(map[time.Time]bool, len(rest))
+		for date := range rest {
+			skipped[date] = true
+		}
+		for _, freeze := range freezes {
+			if freeze.HabitID == habit.ID {
+				skipped[models.DateOf(freeze.FreezeDate, time.UTC)] = true
+			}
+		}
+
+		item := &models.HabitDigest{
+			HabitID:    habit.ID,
+			HabitTitle: habit.Title,
+		}
+		for _, date := range habit.DueDates(from, weekEnd, completed[habit.ID], skipped) {
+			item.DueDays++
+			if completed[habit.ID][date] {
+				item.CompletedDays++
+			}
+		}
+
+		if item.StreakBefore, err = s.streakRepo.CurrentStreakAsOf(ctx, habit, weekStart.AddDate(0, 0, -1)); err != nil {
+			return nil, err
+		}
+		if item.StreakAfter, err = s.streakRepo.CurrentStreakAsOf(ctx, habit, weekEnd); err != nil {
+			return nil, err
+		}
+
+		digest.Habits = append(digest.Habits, item)
+		digest.CompletedDays += item.CompletedDays
+		digest.DueDays += item.DueDays
+	}
+
+	since := models.AtClock(weekStart, time.Time{}, loc)
+	until := models.AtClock(weekEnd.AddDate(0, 0, 1), time.Time{}, loc)
+	if digest.XPEarned, err = s.userRepo.GetXPEarned(ctx, user.ID, since, until); err != nil {
+		return nil, err
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"strconv"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+const (
+	// emailMaxAttempts bounds email delivery attempts per notification
+	emailMaxAttempts = 3
+	// emailRetryBackoff is the delay before the first retry, doubled after
+	// each further failure
+	emailRetryBackoff = 5 * time.Minute
+)
+
+var (
+	ErrNoEmailAddress = errors.New("user has no email address")
+	// ErrEmailDisabled is recorded on emails whose type the user stopped
+	// wanting by email after they were queued
+	ErrEmailDisabled = errors.New("email disabled for this notification type")
+)
+
+//go:embed templates/email
+var emailTemplateFS embed.FS
+
+// emailTemplate is the HTML and plain text version of one kind of email
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// emailTemplateFuncs are available in every email template
+var emailTemplateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string {
+		return t.Format("Mon, Jan 2")
+	},
+	"signed": func(n int) string {
+		if n > 0 {
+			return "+" + strconv.Itoa(n)
+		}
+		return strconv.Itoa(n)
+	},
+}
+
+// emailTemplates holds the templates of the notification types that have
+// their own email; all others use the generic notification one
+var emailTemplates = map[string]*emailTemplate{
+	"notification":  mustParseEmailTemplate("notification"),
+	"weekly_digest": mustParseEmailTemplate("weekly_digest"),
+	"report_ready":  mustParseEmailTemplate("report_ready"),
+}
+
+// mustParseEmailTemplate parses an email's content into the shared layout
+func mustParseEmailTemplate(name string) *emailTemplate {
+	return &emailTemplate{
+		html: htmltemplate.Must(htmltemplate.New("layout.html").Funcs(emailTemplateFuncs).
+			ParseFS(emailTemplateFS, "templates/email/layout.html", "templates/email/"+name+".html")),
+		text: texttemplate.Must(texttemplate.New("layout.txt").Funcs(emailTemplateFuncs).
+			ParseFS(emailTemplateFS, "templates/email/layout.txt", "templates/email/"+name+".txt")),
+	}
+}
+
+// emailData is what email templates render
+type emailData struct {
+	Subject           string
+	Name              string
+	Title             string
+	Body              string
+	Month             string               // report_ready only, e.g. March 2026
+	Digest            *models.WeeklyDigest // weekly_digest only
+	UnsubscribeURL    string
+	UnsubscribeAllURL string
+}
+
+// EmailService delivers the notifications addressed to email
+type EmailService struct {
+	userRepo       *repository.UserRepository
+	outboxRepo     *repository.NotificationRepository
+	prefRepo       *repository.NotificationPreferenceRepository
+	digestSvc      *DigestService
+	unsubscribeSvc *UnsubscribeService
+	sender         EmailSender
+}
+
+// NewEmailService creates a new EmailService. Without a sender emails are
+// only logged.
+func NewEmailService(
+	userRepo *repository.UserRepository,
+	outboxRepo *repository.NotificationRepository,
+	prefRepo *repository.NotificationPreferenceRepository,
+	digestSvc *DigestService,
+	unsubscribeSvc *UnsubscribeService,
+	sender EmailSender,
+) *EmailService {
+	return &EmailService{
+		userRepo:       userRepo,
+		outboxRepo:     outboxRepo,
+		prefRepo:       prefRepo,
+		digestSvc:      digestSvc,
+		unsubscribeSvc: unsubscribeSvc,
+		sender:         sender,
+	}
+}
+
+// DeliverPending emails the due notifications in the outbox and returns how
+// many were sent. Failed sends are retried with exponential backoff.
+func (s *EmailService) DeliverPending(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		notifications, err := s.outboxRepo.ClaimDueEmails(ctx, notificationBatchSize)
+		if err != nil {
+			return delivered, err
+		}
+
+		for _, notification := range notifications {
+			s.deliver(ctx, notification)
+			if *notification.EmailStatus == models.NotificationSent {
+				delivered++
+			}
+
+			if err := s.outboxRepo.UpdateEmailDelivery(context.WithoutCancel(ctx), notification); err != nil {
+				log.Printf("failed to record email delivery of notification %s: %v", notification.ID, err)
+			}
+		}
+
+		if len(notifications) < notificationBatchSize || ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+	}
+}
+
+// deliver makes one email attempt for a claimed notification and sets its
+// resulting email status
+func (s *EmailService) deliver(ctx context.Context, notification *models.Notification) {
+	notification.EmailNextAttemptAt = nil
+
+	if time.Since(notification.ScheduledFor) > notificationMaxAge {
+		s.finishEmail(notification, models.NotificationFailed, errors.New("expired before it could be delivered"))
+		return
+	}
+
+	user, err := s.userRepo.GetByID(ctx, notification.UserID)
+	if err == repository.ErrUserNotFound {
+		s.finishEmail(notification, models.NotificationSkipped, err)
+		return
+	}
+	if err != nil {
+		s.retryEmail(notification, err)
+		return
+	}
+
+	if user.Email == "" {
+		s.finishEmail(notification, models.NotificationSkipped, ErrNoEmailAddress)
+		return
+	}
+
+	// The user may have unsubscribed since the email was queued
+	preference, err := s.prefRepo.GetByUserAndType(ctx, user.ID, notification.Type)
+	if err == repository.ErrNotificationPreferenceNotFound {
+		preference, err = models.DefaultNotificationPreference(user.ID, notification.Type), nil
+	}
+	if err != nil {
+		s.retryEmail(notification, err)
+		return
+	}
+	if !preference.Email {
+		s.finishEmail(notification, models.NotificationSkipped, ErrEmailDisabled)
+		return
+	}
+
+	message, err := s.compose(ctx, user, notification)
+	if err != nil {
+		s.retryEmail(notification, err)
+		return
+	}
+
+	err = s.send(ctx, message)
+	switch {
+	case err == nil:
+		now := time.Now()
+		notification.EmailSentAt = &now
+		s.finishEmail(notification, models.NotificationSent, nil)
+	case errors.Is(err, ErrInvalidEmailAddress):
+		s.finishEmail(notification, models.NotificationSkipped, err)
+	default:
+		s.retryEmail(notification, err)
+	}
+}
+
+// retryEmail queues an email again after a failed attempt, or gives up once
+// it ran out of attempts
+func (s *EmailService) retryEmail(notification *models.Notification, err error) {
+	if notification.EmailAttempts >= emailMaxAttempts {
+		s.finishEmail(notification, models.NotificationFailed, err)
+		return
+	}
+
+	next := time.Now().Add(emailRetryBackoff << (notification.EmailAttempts - 1))
+	notification.EmailNextAttemptAt = &next
+	s.finishEmail(notification, models.NotificationPending, err)
+}
+
+// finishEmail sets a notification's email status and error
+func (s *EmailService) finishEmail(notification *models.Notification, status models.NotificationStatus, err error) {
+	notification.EmailStatus = &status
+	notification.EmailError = nil
+	if err != nil {
+		message := err.Error()
+		notification.EmailError = &message
+	}
+}
+
+// compose renders a notification as an email using its type's template.
+// The digest is rebuilt from the week it covers, so it is always complete.
+func (s *EmailService) compose(ctx context.Context, user *models.User, notification *models.Notification) (*EmailMessage, error) {
+	data := &emailData{
+		Subject:           notification.Title,
+		Name:              user.Email,
+		Title:             notification.Title,
+		Body:              notification.Body,
+		UnsubscribeURL:    s.unsubscribeSvc.URL(user.ID, string(notification.Type)),
+		UnsubscribeAllURL: s.unsubscribeSvc.URL(user.ID, UnsubscribeAll),
+	}
+	if user.DisplayName != nil && *user.DisplayName != "" {
+		data.Name = *user.DisplayName
+	}
+
+	tmpl := emailTemplates["notification"]
+	switch notification.Type {
+	case models.NotificationTypeWeeklyDigest:
+		weekStart, err := time.Parse(models.DateLayout, notification.Data["week_start"])
+		if err != nil {
+			return nil, fmt.Errorf("weekly digest without a valid week_start: %w", err)
+		}
+
+		data.Digest, err = s.digestSvc.BuildWeeklyDigest(ctx, user, weekStart)
+		if err != nil {
+			return nil, err
+		}
+		data.Subject = fmt.Sprintf("Your week in habits: %d%% completed, %d XP earned",
+			data.Digest.CompletionPercent(), data.Digest.XPEarned)
+		tmpl = emailTemplates["weekly_digest"]
+	case models.NotificationTypeReportReady:
+		month, err := time.Parse("2006-01", notification.Data["month"])
+		if err != nil {
+			return nil, fmt.Errorf("report notification without a valid month: %w", err)
+		}
+
+		data.Month = month.Format("January 2006")
+		data.Subject = fmt.Sprintf("Your %s progress report is ready", data.Month)
+		tmpl = emailTemplates["report_ready"]
+	}
+
+	var html, text bytes.Buffer
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	return &EmailMessage{
+		To:      user.Email,
+		Subject: data.Subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			// One-click unsubscribe from the mail client (RFC 8058)
+			"List-Unsubscribe":      "<" + data.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
+
+// send delivers an email. Without a configured sender it is only logged.
+func (s *EmailService) send(ctx context.Context, message *EmailMessage) error {
+	if s.sender == nil {
+		log.Printf("SMTP not configured, skipping email %q", message.Subject)
+		return nil
+	}
+
+	return s.sender.Send(ctx, message)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+)
+
+func TestEmailServiceCompose(t *testing.T) {
+	unsubscribeSvc := NewUnsubscribeService(nil, "secret", "https://api.example.com")
+	svc := NewEmailService(nil, nil, nil, nil, unsubscribeSvc, nil)
+
+	name := "Ada"
+	user := &models.User{ID: uuid.New(), Email: "ada@example.com", DisplayName: &name}
+
+	tests := []struct {
+		name         string
+		notification *models.Notification
+		subject      string
+		contains     []string
+	}{
+		{
+			name: "generic notification",
+			notification: &models.Notification{
+				Type:  models.NotificationTypeStreakAlert,
+				Title: "Your streak is at risk",
+				Body:  "Log <today> to keep it going.",
+			},
+			subject:  "Your streak is at risk",
+			contains: []string{"Ada", "Log &lt;today&gt; to keep it going."},
+		},
+		{
+			name: "report ready",
+			notification: &models.Notification{
+				Type:  models.NotificationTypeReportReady,
+				Title: "Report ready",
+				Data:  map[string]string{"month": "2026-03"},
+			},
+			subject:  "Your March 2026 progress report is ready",
+			contains: []string{"March 2026"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := svc.compose(context.Background(), user, tt.notification)
+			if err != nil {
+				t.Fatalf("compose: %v", err)
+			}
+
+			if msg.To != user.Email {
+				t.Errorf("To = %q; want %q", msg.To, user.Email)
+			}
+			if msg.Subject != tt.subject {
+				t.Errorf("Subject = %q; want %q", msg.Subject, tt.subject)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(msg.HTML, want) {
+					t.Errorf("HTML does not contain %q", want)
+				}
+			}
+
+			unsubscribeURL := unsubscribeSvc.URL(user.ID, string(tt.notification.Type))
+			if got := msg.Headers["List-Unsubscribe"]; got != "<"+unsubscribeURL+">" {
+				t.Errorf("List-Unsubscribe = %q; want <%s>", got, unsubscribeURL)
+			}
+			if got := msg.Headers["List-Unsubscribe-Post"]; got != "List-Unsubscribe=One-Click" {
+				t.Errorf("List-Unsubscribe-Post = %q", got)
+			}
+			if !strings.Contains(msg.Text, unsubscribeURL) {
+				t.Error("Text does not contain the unsubscribe link")
+			}
+			if !strings.Contains(msg.Text, unsubscribeSvc.URL(user.ID, UnsubscribeAll)) {
+				t.Error("Text does not contain the unsubscribe-all link")
+			}
+		})
+	}
+}
+
+func TestEmailServiceComposeRejectsBadData(t *testing.T) {
+	svc := NewEmailService(nil, nil, nil, nil, NewUnsubscribeService(nil, "secret", ""), nil)
+	user := &models.User{ID: uuid.New(), Email: "ada@example.com"}
+
+	for _, notification := range []*models.Notification{
+		{Type: models.NotificationTypeReportReady, Data: map[string]string{"month": "March"}},
+		{Type: models.NotificationTypeWeeklyDigest, Data: map[string]string{}},
+	} {
+		if _, err := svc.compose(context.Background(), user, notification); err == nil {
+			t.Errorf("compose(%s with %v) succeeded; want an error", notification.Type, notification.Data)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// FCMEndpoint is the base URL of the FCM HTTP v1 API
+	FCMEndpoint = "https://fcm.googleapis.com"
+	// fcmScope is the OAuth2 scope needed to send messages
+	fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+	// googleTokenURL is used when the service account names no token_uri
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+
+	// fcmMaxRetries is how many times a throttled or failed send is retried
+	fcmMaxRetries = 3
+	// fcmInitialBackoff is the delay before the first retry, doubled each time
+	fcmInitialBackoff = 500 * time.Millisecond
+	// accessTokenRefreshMargin refreshes cached access tokens before they expire
+	accessTokenRefreshMargin = time.Minute
+)
+
+var (
+	ErrInvalidServiceAccount = errors.New("invalid firebase service account credentials")
+	// ErrFCMTokenInvalid means the registration token will never work again
+	// (UNREGISTERED or INVALID_ARGUMENT) and should be forgotten
+	ErrFCMTokenInvalid = errors.New("fcm registration token is invalid")
+)
+
+// FCMError is an error response from the FCM HTTP v1 API
+type FCMError struct {
+	StatusCode int
+	Status     string // e.g. NOT_FOUND, INVALID_ARGUMENT
+	ErrorCode  string // FCM specific code, e.g. UNREGISTERED
+	Message    string
+}
+
+// Error implements the error interface
+func (e *FCMError) Error() string {
+	return fmt.Sprintf("fcm send failed (%d %s %s): %s", e.StatusCode, e.Status, e.ErrorCode, e.Message)
+}
+
+// Is reports whether the error means the registration token is dead
+func (e *FCMError) Is(target error) bool {
+	return target == ErrFCMTokenInvalid && (e.ErrorCode == "UNREGISTERED" || e.ErrorCode == "INVALID_ARGUMENT" ||
+		(e.ErrorCode == "" && e.Status == "INVALID_ARGUMENT"))
+}
+
+// serviceAccount holds the fields of a Google service account key file
+// needed for the JWT-bearer token exchange
+type serviceAccount struct {
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// parseServiceAccount decodes a service account key given as raw or
+// base64-encoded JSON
+func parseServiceAccount(credentials string) (*serviceAccount, error) {
+	data := []byte(strings.TrimSpace(credentials))
+	if len(data) > 0 && data[0] != '{' {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidServiceAccount, err)
+		}
+		data = decoded
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServiceAccount, err)
+	}
+
+	if account.ProjectID == "" || account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("%w: project_id, client_email and private_key are required", ErrInvalidServiceAccount)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = googleTokenURL
+	}
+
+	return &account, nil
+}
+
+// FCMClient sends messages through the FCM HTTP v1 API, authenticating with
+// OAuth2 access tokens obtained for a service account
+type FCMClient struct {
+	account    *serviceAccount
+	signingKey interface{}
+	sendURL    string
+	httpClient *http.Client
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) error
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMClient creates a new FCMClient from service account credentials.
+// endpoint overrides the FCM base URL, e.g. to point at a local fake server;
+// the token exchange goes to the token_uri named in the credentials.
+func NewFCMClient(credentials, endpoint string) (*FCMClient, error) {
+	account, err := parseServiceAccount(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidServiceAccount, err)
+	}
+
+	if endpoint == "" {
+		endpoint = FCMEndpoint
+	}
+
+	return &FCMClient{
+		account:    account,
+		signingKey: signingKey,
+		sendURL:    strings.TrimRight(endpoint, "/") + "/v1/projects/" + url.PathEscape(account.ProjectID) + "/messages:send",
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		now:   time.Now,
+		sleep: sleepContext,
+	}, nil
+}
+
+// Send delivers a message, retrying with exponential backoff on throttling
+// (429) and server errors (5xx). It returns the message name assigned by FCM.
+func (c *FCMClient) Send(ctx context.Context, message *FCMMessage) (string, error) {
+	body, err := json.Marshal(map[string]*FCMMessage{"message": message})
+	if err != nil {
+		return "", err
+	}
+
+	backoff := fcmInitialBackoff
+	for attempt := 0; ; attempt++ {
+		name, retryAfter, err := c.send(ctx, body)
+		if err == nil {
+			return name, nil
+		}
+
+		var fcmErr *FCMError
+		isFCMErr := errors.As(err, &fcmErr)
+
+		// A revoked access token is worth one attempt with a fresh one
+		if isFCMErr && fcmErr.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			c.invalidateAccessToken()
+			continue
+		}
+
+		// Network errors, throttling and server errors are transient
+		retryable := !isFCMErr || fcmErr.StatusCode == http.StatusTooManyRequests || fcmErr.StatusCode >= 500
+		if !retryable || attempt >= fcmMaxRetries || ctx.Err() != nil {
+			return "", err
+		}
+
+		delay := backoff
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			return "", err
+		}
+		backoff *= 2
+	}
+}
+
+// send makes a single send request, returning the server's Retry-After hint
+// on failure
+func (c *FCMClient) send(ctx context.Context, body []byte) (string, time.Duration, error) {
+	accessToken, err := c.token(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.sendURL, bytes.NewReader(body))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", parseRetryAfter(resp.Header.Get("Retry-After")), parseFCMError(resp.StatusCode, respBody)
+	}
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", 0, fmt.Errorf("decoding fcm response: %w", err)
+	}
+
+	return result.Name, 0, nil
+}
+
+// parseFCMError extracts the status and FCM error code from an error body
+func parseFCMError(statusCode int, body []byte) error {
+	var payload struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+			Details []struct {
+				Type      string `json:"@type"`
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+
+	fcmErr := &FCMError{StatusCode: statusCode}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		fcmErr.Message = strings.TrimSpace(string(body))
+		return fcmErr
+	}
+
+	fcmErr.Status = payload.Error.Status
+	fcmErr.Message = payload.Error.Message
+	for _, detail := range payload.Error.Details {
+		if strings.HasSuffix(detail.Type, "google.firebase.fcm.v1.FcmError") {
+			fcmErr.ErrorCode = detail.ErrorCode
+		}
+	}
+
+	return fcmErr
+}
+
+// token returns a cached access token, exchanging a freshly signed JWT
+// assertion for a new one when it is about to expire
+func (c *FCMClient) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.accessToken != "" && now.Add(accessTokenRefreshMargin).Before(c.expiresAt) {
+		return c.accessToken, nil
+	}
+
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   c.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   c.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if c.account.PrivateKeyID != "" {
+		assertion.Header["kid"] = c.account.PrivateKeyID
+	}
+
+	signed, err := assertion.SignedString(c.signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("exchanging service account assertion: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("decoding access token: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("exchanging service account assertion: no access token returned")
+	}
+
+	c.accessToken = result.AccessToken
+	c.expiresAt = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+
+	return c.accessToken, nil
+}
+
+// invalidateAccessToken drops the cached access token
+func (c *FCMClient) invalidateAccessToken() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.accessToken = ""
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,454 @@
+package services
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/habittracker/backend/internal/models"
+)
+
+const (
+	testFCMProject     = "habit-tracker-test"
+	testFCMClientEmail = "fcm@habit-tracker-test.iam.gserviceaccount.com"
+	testFCMSendPath    = "/v1/projects/" + testFCMProject + "/messages:send"
+)
+
+// fakeFCMResponse is a canned response of the fake send endpoint
+type fakeFCMResponse struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+// fakeFCMServer stands in for both the OAuth2 token endpoint and the FCM
+// send endpoint, recording what it receives
+type fakeFCMServer struct {
+	t *testing.T
+
+	mu          sync.Mutex
+	responses   []fakeFCMResponse // served in order, then 200
+	exchanges   int
+	assertions  []string
+	sends       int
+	authHeaders []string
+	messages    []json.RawMessage
+}
+
+func (f *fakeFCMServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/token":
+		if err := r.ParseForm(); err != nil {
+			f.t.Errorf("parsing token request: %v", err)
+		}
+		if grant := r.PostForm.Get("grant_type"); grant != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			f.t.Errorf("grant_type = %q", grant)
+		}
+		f.exchanges++
+		f.assertions = append(f.assertions, r.PostForm.Get("assertion"))
+		fmt.Fprintf(w, `{"access_token":"access-%d","expires_in":3600,"token_type":"Bearer"}`, f.exchanges)
+
+	case testFCMSendPath:
+		body, _ := io.ReadAll(r.Body)
+		var payload struct {
+			Message json.RawMessage `json:"message"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			f.t.Errorf("decoding send request: %v", err)
+		}
+		f.sends++
+		f.authHeaders = append(f.authHeaders, r.Header.Get("Authorization"))
+		f.messages = append(f.messages, payload.Message)
+
+		if len(f.responses) > 0 {
+			resp := f.responses[0]
+			f.responses = f.responses[1:]
+			if resp.retryAfter != "" {
+				w.Header().Set("Retry-After", resp.retryAfter)
+			}
+			w.WriteHeader(resp.status)
+			io.WriteString(w, resp.body)
+			return
+		}
+		fmt.Fprintf(w, `{"name":"projects/%s/messages/%d"}`, testFCMProject, f.sends)
+
+	default:
+		f.t.Errorf("unexpected request to %s", r.URL.Path)
+		http.NotFound(w, r)
+	}
+}
+
+// newTestFCMClient returns a client talking to a fake server. Sleeps are
+// recorded instead of waited out, and the clock stands still at *now.
+func newTestFCMClient(t *testing.T, now *time.Time, responses ...fakeFCMResponse) (*FCMClient, *fakeFCMServer, *[]time.Duration) {
+	t.Helper()
+
+	fake := &fakeFCMServer{t: t, responses: responses}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(firebaseTestKey(t)),
+	})
+	credentials, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     testFCMProject,
+		"private_key_id": "service-key-1",
+		"private_key":    string(keyPEM),
+		"client_email":   testFCMClientEmail,
+		"token_uri":      server.URL + "/token",
+	})
+	if err != nil {
+		t.Fatalf("encoding credentials: %v", err)
+	}
+
+	client, err := NewFCMClient(string(credentials), server.URL)
+	if err != nil {
+		t.Fatalf("NewFCMClient: %v", err)
+	}
+
+	var sleeps []time.Duration
+	client.now = func() time.Time { return *now }
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+
+	return client, fake, &sleeps
+}
+
+func testFCMMessage() *FCMMessage {
+	return newFCMMessage("device-token", models.NotificationTypeMorningReminder, "Good morning", "3 habits today", map[string]string{"type": "morning_reminder"})
+}
+
+func TestFCMClientTokenExchange(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+	client, fake, _ := newTestFCMClient(t, &now)
+
+	name, err := client.Send(context.Background(), testFCMMessage())
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if name != "projects/"+testFCMProject+"/messages/1" {
+		t.Errorf("name = %q", name)
+	}
+	if fake.exchanges != 1 {
+		t.Fatalf("token exchanges = %d; want 1", fake.exchanges)
+	}
+	if fake.authHeaders[0] != "Bearer access-1" {
+		t.Errorf("Authorization = %q; want Bearer access-1", fake.authHeaders[0])
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(fake.assertions[0], claims, func(token *jwt.Token) (interface{}, error) {
+		return &firebaseTestKey(t).PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithTimeFunc(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("parsing assertion: %v", err)
+	}
+	if kid := token.Header["kid"]; kid != "service-key-1" {
+		t.Errorf("assertion kid = %v; want service-key-1", kid)
+	}
+	if claims["iss"] != testFCMClientEmail {
+		t.Errorf("assertion iss = %v", claims["iss"])
+	}
+	if claims["scope"] != fcmScope {
+		t.Errorf("assertion scope = %v", claims["scope"])
+	}
+	if aud, _ := claims.GetAudience(); len(aud) != 1 || aud[0] != client.account.TokenURI {
+		t.Errorf("assertion aud = %v; want %s", aud, client.account.TokenURI)
+	}
+	if exp, _ := claims.GetExpirationTime(); exp == nil || !exp.Time.Equal(now.Add(time.Hour)) {
+		t.Errorf("assertion exp = %v; want %v", exp, now.Add(time.Hour))
+	}
+}
+
+func TestFCMClientAccessTokenCaching(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+	client, fake, _ := newTestFCMClient(t, &now)
+	ctx := context.Background()
+
+	send := func() {
+		t.Helper()
+		if _, err := client.Send(ctx, testFCMMessage()); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	send()
+	now = now.Add(30 * time.Minute)
+	send()
+	if fake.exchanges != 1 {
+		t.Errorf("token exchanges within the token's lifetime = %d; want 1", fake.exchanges)
+	}
+
+	// Tokens are refreshed a margin before they expire
+	now = now.Add(30*time.Minute - accessTokenRefreshMargin)
+	send()
+	if fake.exchanges != 2 {
+		t.Errorf("token exchanges near expiry = %d; want 2", fake.exchanges)
+	}
+
+	want := []string{"Bearer access-1", "Bearer access-1", "Bearer access-2"}
+	if !reflect.DeepEqual(fake.authHeaders, want) {
+		t.Errorf("Authorization headers = %v; want %v", fake.authHeaders, want)
+	}
+}
+
+func TestFCMClientRevokedAccessToken(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+	client, fake, sleeps := newTestFCMClient(t, &now,
+		fakeFCMResponse{status: http.StatusUnauthorized, body: `{"error":{"code":401,"status":"UNAUTHENTICATED"}}`},
+	)
+
+	if _, err := client.Send(context.Background(), testFCMMessage()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if fake.exchanges != 2 {
+		t.Errorf("token exchanges = %d; want 2", fake.exchanges)
+	}
+	want := []string{"Bearer access-1", "Bearer access-2"}
+	if !reflect.DeepEqual(fake.authHeaders, want) {
+		t.Errorf("Authorization headers = %v; want %v", fake.authHeaders, want)
+	}
+	if len(*sleeps) != 0 {
+		t.Errorf("sleeps = %v; want none", *sleeps)
+	}
+}
+
+func TestFCMClientRetries(t *testing.T) {
+	throttled := fakeFCMResponse{status: http.StatusTooManyRequests, body: `{"error":{"code":429,"status":"RESOURCE_EXHAUSTED"}}`}
+	unavailable := fakeFCMResponse{status: http.StatusServiceUnavailable, body: `{"error":{"code":503,"status":"UNAVAILABLE"}}`}
+	internal := fakeFCMResponse{status: http.StatusInternalServerError, body: "upstream exploded"}
+	invalid := fakeFCMResponse{status: http.StatusBadRequest, body: `{"error":{"code":400,"status":"FAILED_PRECONDITION"}}`}
+
+	tests := []struct {
+		name       string
+		responses  []fakeFCMResponse
+		wantSends  int
+		wantSleeps []time.Duration
+		wantStatus int // 0 when the send succeeds
+	}{
+		{
+			name:       "throttled then delivered",
+			responses:  []fakeFCMResponse{throttled, throttled},
+			wantSends:  3,
+			wantSleeps: []time.Duration{500 * time.Millisecond, time.Second},
+		},
+		{
+			name:       "server errors then delivered",
+			responses:  []fakeFCMResponse{unavailable, internal},
+			wantSends:  3,
+			wantSleeps: []time.Duration{500 * time.Millisecond, time.Second},
+		},
+		{
+			name: "retry-after longer than the backoff",
+			responses: []fakeFCMResponse{
+				{status: http.StatusTooManyRequests, retryAfter: "7"},
+				{status: http.StatusTooManyRequests, retryAfter: "0"},
+			},
+			wantSends:  3,
+			wantSleeps: []time.Duration{7 * time.Second, time.Second},
+		},
+		{
+			name:       "gives up after the last retry",
+			responses:  []fakeFCMResponse{unavailable, unavailable, unavailable, unavailable, unavailable},
+			wantSends:  fcmMaxRetries + 1,
+			wantSleeps: []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "client errors are not retried",
+			responses:  []fakeFCMResponse{invalid},
+			wantSends:  1,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+			client, fake, sleeps := newTestFCMClient(t, &now, tt.responses...)
+
+			_, err := client.Send(context.Background(), testFCMMessage())
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("Send: %v", err)
+				}
+			} else {
+				var fcmErr *FCMError
+				if !errors.As(err, &fcmErr) || fcmErr.StatusCode != tt.wantStatus {
+					t.Fatalf("Send error = %v; want an FCMError with status %d", err, tt.wantStatus)
+				}
+			}
+
+			if fake.sends != tt.wantSends {
+				t.Errorf("sends = %d; want %d", fake.sends, tt.wantSends)
+			}
+			if len(*sleeps) != len(tt.wantSleeps) || (len(tt.wantSleeps) > 0 && !reflect.DeepEqual(*sleeps, tt.wantSleeps)) {
+				t.Errorf("sleeps = %v; want %v", *sleeps, tt.wantSleeps)
+			}
+			if fake.exchanges != 1 {
+				t.Errorf("token exchanges = %d; want 1", fake.exchanges)
+			}
+		})
+	}
+}
+
+func TestFCMClientDeadTokens(t *testing.T) {
+	fcmDetail := func(code int, status, errorCode string) string {
+		return `{"error":{"code":` + strconv.Itoa(code) + `,"message":"failed","status":"` + status + `",` +
+			`"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"` + errorCode + `"}]}}`
+	}
+
+	tests := []struct {
+		name     string
+		response fakeFCMResponse
+		wantDead bool
+	}{
+		{
+			name:     "unregistered",
+			response: fakeFCMResponse{status: http.StatusNotFound, body: fcmDetail(404, "NOT_FOUND", "UNREGISTERED")},
+			wantDead: true,
+		},
+		{
+			name:     "invalid argument",
+			response: fakeFCMResponse{status: http.StatusBadRequest, body: fcmDetail(400, "INVALID_ARGUMENT", "INVALID_ARGUMENT")},
+			wantDead: true,
+		},
+		{
+			name:     "invalid argument without fcm details",
+			response: fakeFCMResponse{status: http.StatusBadRequest, body: `{"error":{"code":400,"status":"INVALID_ARGUMENT"}}`},
+			wantDead: true,
+		},
+		{
+			name:     "sender id mismatch",
+			response: fakeFCMResponse{status: http.StatusForbidden, body: fcmDetail(403, "PERMISSION_DENIED", "SENDER_ID_MISMATCH")},
+		},
+		{
+			name:     "third party auth error",
+			response: fakeFCMResponse{status: http.StatusUnauthorized, body: fcmDetail(401, "UNAUTHENTICATED", "THIRD_PARTY_AUTH_ERROR")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+			responses := []fakeFCMResponse{tt.response, tt.response}
+			client, _, _ := newTestFCMClient(t, &now, responses...)
+
+			_, err := client.Send(context.Background(), testFCMMessage())
+			if err == nil {
+				t.Fatal("Send succeeded; want an error")
+			}
+			if dead := errors.Is(err, ErrFCMTokenInvalid); dead != tt.wantDead {
+				t.Errorf("errors.Is(%v, ErrFCMTokenInvalid) = %v; want %v", err, dead, tt.wantDead)
+			}
+		})
+	}
+}
+
+func TestFCMClientMessageSerialization(t *testing.T) {
+	tests := []struct {
+		name             string
+		notificationType models.NotificationType
+		want             string
+	}{
+		{
+			name:             "reminder",
+			notificationType: models.NotificationTypeMorningReminder,
+			want: `{
+				"token": "device-token",
+				"notification": {"title": "Title", "body": "Body"},
+				"data": {"type": "morning_reminder"},
+				"android": {
+					"priority": "normal",
+					"ttl": "86400s",
+					"collapse_key": "morning_reminder",
+					"notification": {"channel_id": "reminders", "tag": "morning_reminder", "sound": "default"}
+				},
+				"apns": {
+					"headers": {"apns-priority": "5", "apns-collapse-id": "morning_reminder"},
+					"payload": {"aps": {"sound": "default", "thread-id": "morning_reminder"}}
+				}
+			}`,
+		},
+		{
+			name:             "streak alert",
+			notificationType: models.NotificationTypeStreakAlert,
+			want: `{
+				"token": "device-token",
+				"notification": {"title": "Title", "body": "Body"},
+				"data": {"type": "streak_alert"},
+				"android": {
+					"priority": "high",
+					"ttl": "86400s",
+					"collapse_key": "streak_alert",
+					"notification": {"channel_id": "streaks", "tag": "streak_alert", "sound": "default"}
+				},
+				"apns": {
+					"headers": {"apns-priority": "10", "apns-collapse-id": "streak_alert"},
+					"payload": {"aps": {"sound": "default", "thread-id": "streak_alert"}}
+				}
+			}`,
+		},
+		{
+			name:             "weekly digest",
+			notificationType: models.NotificationTypeWeeklyDigest,
+			want: `{
+				"token": "device-token",
+				"notification": {"title": "Title", "body": "Body"},
+				"data": {"type": "weekly_digest"},
+				"android": {
+					"priority": "normal",
+					"ttl": "86400s",
+					"collapse_key": "weekly_digest",
+					"notification": {"channel_id": "insights", "tag": "weekly_digest", "sound": "default"}
+				},
+				"apns": {
+					"headers": {"apns-priority": "5", "apns-collapse-id": "weekly_digest"},
+					"payload": {"aps": {"sound": "default", "thread-id": "weekly_digest"}}
+				}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+			client, fake, _ := newTestFCMClient(t, &now)
+
+			message := newFCMMessage("device-token", tt.notificationType, "Title", "Body", map[string]string{"type": string(tt.notificationType)})
+			if _, err := client.Send(context.Background(), message); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(fake.messages[0], &got); err != nil {
+				t.Fatalf("decoding sent message: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("decoding expected message: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("sent message = %s", fake.messages[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// GoogleSecureTokenCertsURL is where Google publishes the x509 certificates
+// used to sign Firebase ID tokens
+const GoogleSecureTokenCertsURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+
+const (
+	// defaultKeyCacheTTL is used when the key source does not report a max-age
+	defaultKeyCacheTTL = time.Hour
+	// minKeyRefetchInterval is how often a token with an unknown key ID may
+	// trigger a fetch while the cached keys are fresh, so tokens with made-up
+	// key IDs cannot make the server fetch the certificates on every request
+	minKeyRefetchInterval = time.Minute
+)
+
+// FirebaseIdentity is the verified identity extracted from a Firebase ID token
+type FirebaseIdentity struct {
+	UID           string
+	Email         string
+	EmailVerified bool
+	AuthTime      time.Time
+}
+
+// FirebaseKeySource provides the public keys used to verify Firebase ID tokens.
+// It returns the keys indexed by key ID and how long they may be cached.
+type FirebaseKeySource interface {
+	FetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error)
+}
+
+// HTTPKeySource fetches PEM-encoded x509 certificates from a URL in the format
+// served by Google ({"kid": "-----BEGIN CERTIFICATE-----..."})
+type HTTPKeySource struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewHTTPKeySource creates a new HTTPKeySource for the given certificates URL
+func NewHTTPKeySource(url string) *HTTPKeySource {
+	if url == "" {
+		url = GoogleSecureTokenCertsURL
+	}
+
+	return &HTTPKeySource{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// FetchKeys downloads and parses the current certificate set
+func (s *HTTPKeySource) FetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("fetching firebase certificates: unexpected status %d", resp.StatusCode)
+	}
+
+	var certs map[string]string
+	if err := json.Unmarshal(body, &certs); err != nil {
+		return nil, 0, fmt.Errorf("decoding firebase certificates: %w", err)
+	}
+
+	keys, err := parseCertificateKeys(certs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return keys, parseMaxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// StaticKeySource serves a fixed set of keys. It is intended for local
+// development and tests where tokens are signed with a stand-in key.
+type StaticKeySource struct {
+	Keys map[string]*rsa.PublicKey
+	TTL  time.Duration
+}
+
+// FetchKeys returns the configured keys
+func (s *StaticKeySource) FetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	return s.Keys, s.TTL, nil
+}
+
+// FirebaseVerifier verifies Firebase ID tokens and caches signing keys
+type FirebaseVerifier struct {
+	projectID string
+	source    FirebaseKeySource
+	now       func() time.Time
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	expiresAt time.Time
+	fetchedAt time.Time // last fetch attempt, successful or not
+
+	refreshMu sync.Mutex // one fetch at a time
+}
+
+// NewFirebaseVerifier creates a new FirebaseVerifier for a Firebase project
+func NewFirebaseVerifier(projectID string, source FirebaseKeySource) *FirebaseVerifier {
+	return &FirebaseVerifier{
+		projectID: projectID,
+		source:    source,
+		now:       time.Now,
+	}
+}
+
+// firebaseClaims represents the claims carried by a Firebase ID token
+type firebaseClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	AuthTime      int64  `json:"auth_time"`
+	jwt.RegisteredClaims
+}
+
+// Verify validates a Firebase ID token and returns the identity it carries
+func (v *FirebaseVerifier) Verify(ctx context.Context, idToken string) (*FirebaseIdentity, error) {
+	if v.projectID == "" {
+		return nil, fmt.Errorf("%w: firebase project id is not configured", ErrInvalidFirebase)
+	}
+
+	claims := &firebaseClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("missing kid header")
+		}
+		return v.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithAudience(v.projectID),
+		jwt.WithIssuer("https://securetoken.google.com/"+v.projectID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(v.now),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFirebase, err)
+	}
+
+	if claims.Subject == "" || len(claims.Subject) > 128 {
+		return nil, fmt.Errorf("%w: invalid subject", ErrInvalidFirebase)
+	}
+
+	authTime := time.Unix(claims.AuthTime, 0)
+	if claims.AuthTime == 0 || authTime.After(v.now()) {
+		return nil, fmt.Errorf("%w: invalid auth_time", ErrInvalidFirebase)
+	}
+
+	return &FirebaseIdentity{
+		UID:           claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		AuthTime:      authTime,
+	}, nil
+}
+
+// publicKey returns the key for a key ID, refreshing the cache when it has
+// expired or when the key ID is unknown (Google may have rotated early).
+// Unknown key IDs refetch at most once every minKeyRefetchInterval.
+func (v *FirebaseVerifier) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, done := v.cachedKey(kid); done {
+		return knownKey(key, kid)
+	}
+
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	// Another request may have refreshed the keys while this one waited
+	if key, done := v.cachedKey(kid); done {
+		return knownKey(key, kid)
+	}
+
+	if err := v.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return knownKey(v.keys[kid], kid)
+}
+
+// cachedKey looks a key ID up in the cache. It reports done when the cache
+// has the answer: the key is cached and fresh, or the keys are fresh and were
+// fetched too recently to fetch them again for an unknown key ID.
+func (v *FirebaseVerifier) cachedKey(kid string) (*rsa.PublicKey, bool) {
+	now := v.now()
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	key, ok := v.keys[kid]
+	fresh := now.Before(v.expiresAt)
+	if ok && fresh {
+		return key, true
+	}
+	return nil, fresh && now.Sub(v.fetchedAt) < minKeyRefetchInterval
+}
+
+// knownKey returns key, or an error naming kid when there is none
+func knownKey(key *rsa.PublicKey, kid string) (*rsa.PublicKey, error) {
+	if key == nil {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// refreshKeys reloads keys from the source
+func (v *FirebaseVerifier) refreshKeys(ctx context.Context) error {
+	v.mu.Lock()
+	v.fetchedAt = v.now()
+	v.mu.Unlock()
+
+	keys, ttl, err := v.source.FetchKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ttl <= 0 {
+		ttl = defaultKeyCacheTTL
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.expiresAt = v.now().Add(ttl)
+	v.mu.Unlock()
+
+	return nil
+}
+
+// parseCertificateKeys extracts RSA public keys from PEM-encoded certificates
+func parseCertificateKeys(certs map[string]string) (map[string]*rsa.PublicKey, error) {
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for kid, certPEM := range certs {
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil {
+			return nil, fmt.Errorf("certificate %q is not valid PEM", kid)
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing certificate %q: %w", kid, err)
+		}
+
+		key, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("certificate %q does not hold an RSA key", kid)
+		}
+		keys[kid] = key
+	}
+
+	return keys, nil
+}
+
+// parseMaxAge extracts the max-age directive from a Cache-Control header
+func parseMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+		if err != nil || seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	return 0
+}
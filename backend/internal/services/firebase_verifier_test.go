@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testFirebaseProject = "habit-tracker-test"
+
+var (
+	testFirebaseKeyOnce sync.Once
+	testFirebaseKey     *rsa.PrivateKey
+)
+
+// firebaseTestKey returns an RSA key shared by the tests, generated once as
+// generating keys is slow
+func firebaseTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testFirebaseKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		testFirebaseKey = key
+	})
+	return testFirebaseKey
+}
+
+// countingKeySource counts how often the verifier fetches its keys
+type countingKeySource struct {
+	StaticKeySource
+	fetches int
+}
+
+func (s *countingKeySource) FetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, time.Duration, error) {
+	s.fetches++
+	return s.StaticKeySource.FetchKeys(ctx)
+}
+
+// newTestFirebaseVerifier returns a verifier trusting the test key as kid
+// "key-1", with its clock at now
+func newTestFirebaseVerifier(t *testing.T, now time.Time) (*FirebaseVerifier, *countingKeySource) {
+	source := &countingKeySource{StaticKeySource: StaticKeySource{
+		Keys: map[string]*rsa.PublicKey{"key-1": &firebaseTestKey(t).PublicKey},
+		TTL:  time.Hour,
+	}}
+	verifier := NewFirebaseVerifier(testFirebaseProject, source)
+	verifier.now = func() time.Time { return now }
+	return verifier, source
+}
+
+// firebaseTestClaims returns the claims of a valid token issued at now
+func firebaseTestClaims(now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            "https://securetoken.google.com/" + testFirebaseProject,
+		"aud":            testFirebaseProject,
+		"sub":            "firebase-uid-1",
+		"iat":            now.Add(-time.Minute).Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"auth_time":      now.Add(-time.Hour).Unix(),
+		"email":          "ada@example.com",
+		"email_verified": true,
+	}
+}
+
+func signFirebaseTestToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return signed
+}
+
+func TestFirebaseVerifierVerify(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+	key := firebaseTestKey(t)
+
+	tests := []struct {
+		name    string
+		method  jwt.SigningMethod
+		key     interface{}
+		kid     string
+		modify  func(claims jwt.MapClaims)
+		wantErr error
+	}{
+		{name: "valid"},
+		{
+			name:    "wrong audience",
+			modify:  func(claims jwt.MapClaims) { claims["aud"] = "other-project" },
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "wrong issuer",
+			modify:  func(claims jwt.MapClaims) { claims["iss"] = "https://securetoken.google.com/other-project" },
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "expired",
+			modify:  func(claims jwt.MapClaims) { claims["exp"] = now.Add(-time.Second).Unix() },
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:    "missing expiry",
+			modify:  func(claims jwt.MapClaims) { delete(claims, "exp") },
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "issued in the future",
+			modify:  func(claims jwt.MapClaims) { claims["iat"] = now.Add(time.Minute).Unix() },
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "missing auth_time",
+			modify:  func(claims jwt.MapClaims) { delete(claims, "auth_time") },
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "auth_time in the future",
+			modify:  func(claims jwt.MapClaims) { claims["auth_time"] = now.Add(time.Minute).Unix() },
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "missing subject",
+			modify:  func(claims jwt.MapClaims) { delete(claims, "sub") },
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "unknown key id",
+			kid:     "key-2",
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "missing key id",
+			kid:     "-",
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "RS512",
+			method:  jwt.SigningMethodRS512,
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "HS256 with the public key as secret",
+			method:  jwt.SigningMethodHS256,
+			key:     x509.MarshalPKCS1PublicKey(&key.PublicKey),
+			wantErr: ErrInvalidFirebase,
+		},
+		{
+			name:    "signed with another key",
+			key:     mustGenerateRSAKey(t),
+			wantErr: ErrInvalidFirebase,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, signingKey, kid := tt.method, tt.key, tt.kid
+			if method == nil {
+				method = jwt.SigningMethodRS256
+			}
+			if signingKey == nil {
+				signingKey = key
+			}
+			switch kid {
+			case "":
+				kid = "key-1"
+			case "-":
+				kid = ""
+			}
+
+			claims := firebaseTestClaims(now)
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+
+			verifier, _ := newTestFirebaseVerifier(t, now)
+			identity, err := verifier.Verify(context.Background(), signFirebaseTestToken(t, method, signingKey, kid, claims))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify error = %v; want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+
+			want := FirebaseIdentity{
+				UID:           "firebase-uid-1",
+				Email:         "ada@example.com",
+				EmailVerified: true,
+				AuthTime:      time.Unix(now.Add(-time.Hour).Unix(), 0),
+			}
+			if *identity != want {
+				t.Errorf("identity = %+v; want %+v", *identity, want)
+			}
+		})
+	}
+}
+
+func TestFirebaseVerifierWithoutProject(t *testing.T) {
+	verifier := NewFirebaseVerifier("", &StaticKeySource{})
+	if _, err := verifier.Verify(context.Background(), "token"); !errors.Is(err, ErrInvalidFirebase) {
+		t.Errorf("Verify error = %v; want ErrInvalidFirebase", err)
+	}
+}
+
+func TestFirebaseVerifierUnknownKeyRefetchLimit(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+	verifier, source := newTestFirebaseVerifier(t, now)
+	verifier.now = func() time.Time { return now }
+	ctx := context.Background()
+	key := firebaseTestKey(t)
+
+	verify := func(kid string) error {
+		_, err := verifier.Verify(ctx, signFirebaseTestToken(t, jwt.SigningMethodRS256, key, kid, firebaseTestClaims(now)))
+		return err
+	}
+
+	if err := verify("key-1"); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if source.fetches != 1 {
+		t.Fatalf("fetches after the first token = %d; want 1", source.fetches)
+	}
+
+	// A known key is served from the cache
+	if err := verify("key-1"); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if source.fetches != 1 {
+		t.Errorf("fetches after a cached key = %d; want 1", source.fetches)
+	}
+
+	// Unknown key IDs do not refetch within a minute of the last fetch
+	for i := 0; i < 5; i++ {
+		if err := verify("made-up"); !errors.Is(err, ErrInvalidFirebase) {
+			t.Fatalf("Verify error = %v; want ErrInvalidFirebase", err)
+		}
+	}
+	if source.fetches != 1 {
+		t.Errorf("fetches after unknown keys within a minute = %d; want 1", source.fetches)
+	}
+
+	// A minute later an unknown key ID refetches once, picking up a rotation
+	now = now.Add(minKeyRefetchInterval)
+	source.Keys = map[string]*rsa.PublicKey{"key-1": &key.PublicKey, "key-2": &key.PublicKey}
+	if err := verify("key-2"); err != nil {
+		t.Fatalf("Verify with a rotated key: %v", err)
+	}
+	if err := verify("made-up"); !errors.Is(err, ErrInvalidFirebase) {
+		t.Fatalf("Verify error = %v; want ErrInvalidFirebase", err)
+	}
+	if source.fetches != 2 {
+		t.Errorf("fetches after a minute = %d; want 2", source.fetches)
+	}
+
+	// Expired keys are refetched even for a known key ID
+	now = now.Add(time.Hour)
+	if err := verify("key-1"); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if source.fetches != 3 {
+		t.Errorf("fetches after the keys expired = %d; want 3", source.fetches)
+	}
+}
+
+func TestHTTPKeySource(t *testing.T) {
+	key := firebaseTestKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "securetoken.system.gserviceaccount.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=19302, must-revalidate, no-transform")
+		json.NewEncoder(w).Encode(map[string]string{"key-1": certPEM})
+	}))
+	defer server.Close()
+
+	keys, ttl, err := NewHTTPKeySource(server.URL).FetchKeys(context.Background())
+	if err != nil {
+		t.Fatalf("FetchKeys: %v", err)
+	}
+	if ttl != 19302*time.Second {
+		t.Errorf("ttl = %v; want 19302s", ttl)
+	}
+	if got := keys["key-1"]; got == nil || !got.Equal(&key.PublicKey) {
+		t.Errorf("keys = %v; want key-1 holding the test key", keys)
+	}
+}
+
+func mustGenerateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	return key
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+const (
+	// maxFreezeBackfillDays is how far back a missed day can still be frozen
+	maxFreezeBackfillDays = 7
+	// maxVacationDays is the longest range of rest days a vacation may cover
+	maxVacationDays = 90
+)
+
+var (
+	ErrInvalidFreezeDate = errors.New("freeze date must be today or within the last week")
+	ErrDayCompleted      = errors.New("habit already completed on this day")
+	ErrInvalidVacation   = errors.New("invalid vacation dates")
+)
+
+// FreezeService handles streak freezes and rest days
+type FreezeService struct {
+	habitRepo   *repository.HabitRepository
+	logRepo     *repository.LogRepository
+	freezeRepo  *repository.FreezeRepository
+	streakSvc   *StreakService
+	dayResolver *DayResolver
+}
+
+// NewFreezeService creates a new FreezeService
+func NewFreezeService(
+	habitRepo *repository.HabitRepository,
+	logRepo *repository.LogRepository,
+	freezeRepo *repository.FreezeRepository,
+	streakSvc *StreakService,
+	dayResolver *DayResolver,
+) *FreezeService {
+	return &FreezeService{
+		habitRepo:   habitRepo,
+		logRepo:     logRepo,
+		freezeRepo:  freezeRepo,
+		streakSvc:   streakSvc,
+		dayResolver: dayResolver,
+	}
+}
+
+// FreezeDay spends a streak freeze so a habit's missed day does not break its
+// streak. The day defaults to the user's today and may be up to a week back.
+func (s *FreezeService) FreezeDay(ctx context.Context, userID, habitID uuid.UUID, req *models.StreakFreezeRequest) (*models.StreakFreezeResponse, error) {
+	habit, err := s.habitRepo.GetByIDAndUserID(ctx, habitID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	today, err := s.dayResolver.Today(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	date := today
+	if req.Date != nil {
+		date, err = time.Parse(models.DateLayout, *req.Date)
+		if err != nil {
+			return nil, ErrInvalidFreezeDate
+		}
+	}
+
+	if days := models.DaysBetween(date, today); days < 0 || days > maxFreezeBackfillDays {
+		return nil, ErrInvalidFreezeDate
+	}
+
+	if existing, err := s.logRepo.GetByHabitAndDate(ctx, habitID, date); err == nil && existing.Completed {
+		return nil, ErrDayCompleted
+	}
+
+	freeze := &models.StreakFreeze{
+		HabitID:    habitID,
+		UserID:     userID,
+		FreezeDate: date,
+	}
+
+	remaining, err := s.freezeRepo.Create(ctx, freeze)
+	if err != nil {
+		return nil, err
+	}
+
+	streak, err := s.streakSvc.RecomputeHabit(ctx, habit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StreakFreezeResponse{
+		HabitID:          habitID,
+		FreezeDate:       date.Format(models.DateLayout),
+		FreezesRemaining: remaining,
+		Streak:           streak.ToResponse(),
+	}, nil
+}
+
+// GetVacations retrieves a user's declared rest days
+func (s *FreezeService) GetVacations(ctx context.Context, userID uuid.UUID) ([]*models.Vacation, error) {
+	return s.freezeRepo.GetVacations(ctx, userID)
+}
+
+// CreateVacation declares a range of rest days, starting at most a week
+// back, and rebuilds the user's streaks around them
+func (s *FreezeService) CreateVacation(ctx context.Context, userID uuid.UUID, req *models.VacationCreateRequest) (*models.Vacation, error) {
+	start, err := time.Parse(models.DateLayout, req.StartDate)
+	if err != nil {
+		return nil, ErrInvalidVacation
+	}
+
+	end, err := time.Parse(models.DateLayout, req.EndDate)
+	if err != nil {
+		return nil, ErrInvalidVacation
+	}
+
+	if days := models.DaysBetween(start, end); days < 0 || days >= maxVacationDays {
+		return nil, ErrInvalidVacation
+	}
+
+	// Rest days may reach back no further than a freeze, or a vacation
+	// could excuse any past misses without spending freezes
+	today, err := s.dayResolver.Today(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if models.DaysBetween(start, today) > maxFreezeBackfillDays {
+		return nil, ErrInvalidVacation
+	}
+
+	vacation := &models.Vacation{
+		UserID:    userID,
+		StartDate: start,
+		EndDate:   end,
+		Note:      req.Note,
+	}
+
+	if err := s.freezeRepo.CreateVacation(ctx, vacation); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.streakSvc.RecomputeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return vacation, nil
+}
+
+// DeleteVacation removes a user's rest days and rebuilds their streaks
+func (s *FreezeService) DeleteVacation(ctx context.Context, userID, vacationID uuid.UUID) error {
+	if err := s.freezeRepo.DeleteVacation(ctx, vacationID, userID); err != nil {
+		return err
+	}
+
+	_, err := s.streakSvc.RecomputeUser(ctx, userID)
+	return err
+}
@@ -29,16 +29,15 @@ func (s *GamificationService) AwardXP(ctx context.Context, userID uuid.UUID, act
 		return err
 	}
 
-	user.XP += amount
-	
 	// Check for level up
-	newLevel := s.CalculateLevel(user.XP)
+	newLevel := s.CalculateLevel(user.XP + amount)
+	freezes := 0
 	if newLevel > user.Level {
-		user.Level = newLevel
-		// Trigger level up notification or badge award in the future
+		// Every level gained earns a streak freeze
+		freezes = newLevel - user.Level
 	}
 
-	return s.userRepo.Update(ctx, user)
+	return s.userRepo.AddXP(ctx, userID, action, amount, referenceID, newLevel, freezes, models.MaxStreakFreezes)
 }
 
 // CalculateLevel calculates level based on total XP
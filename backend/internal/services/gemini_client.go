@@ -0,0 +1,191 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GeminiEndpoint is the base URL of the Gemini API
+const GeminiEndpoint = "https://generativelanguage.googleapis.com/v1beta"
+
+// GeminiClient calls the Gemini generateContent API. The API key travels in
+// the x-goog-api-key header so it stays out of URLs and access logs.
+type GeminiClient struct {
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewGeminiClient creates a new GeminiClient for a model such as
+// gemini-2.5-flash
+func NewGeminiClient(apiKey, model string, timeout time.Duration) *GeminiClient {
+	return &GeminiClient{
+		apiKey: apiKey,
+		model:  model,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// GeminiRequest represents the request to Gemini API
+type GeminiRequest struct {
+	Contents         []GeminiContent         `json:"contents"`
+	GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// GeminiContent represents content in Gemini request
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"` // user or model
+	Parts []GeminiPart `json:"parts"`
+}
+
+// GeminiPart represents a part of content
+type GeminiPart struct {
+	Text string `json:"text"`
+}
+
+// GeminiGenerationConfig constrains the generated output
+type GeminiGenerationConfig struct {
+	ResponseMimeType string        `json:"responseMimeType,omitempty"`
+	ResponseSchema   *GeminiSchema `json:"responseSchema,omitempty"`
+}
+
+// GeminiSchema is the OpenAPI schema subset accepted as responseSchema. It
+// spells types in upper case and has no additionalProperties.
+type GeminiSchema struct {
+	Type        string                   `json:"type"`
+	Description string                   `json:"description,omitempty"`
+	Properties  map[string]*GeminiSchema `json:"properties,omitempty"`
+	Required    []string                 `json:"required,omitempty"`
+	Items       *GeminiSchema            `json:"items,omitempty"`
+	MinItems    *int                     `json:"minItems,omitempty"`
+	MaxItems    *int                     `json:"maxItems,omitempty"`
+	Minimum     *int                     `json:"minimum,omitempty"`
+	Maximum     *int                     `json:"maximum,omitempty"`
+}
+
+// GeminiResponse represents the response from Gemini API
+type GeminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// Name returns the provider name
+func (c *GeminiClient) Name() string {
+	return "gemini"
+}
+
+// Model returns the model name
+func (c *GeminiClient) Model() string {
+	return c.model
+}
+
+// Complete sends the conversation and returns the first candidate's text
+func (c *GeminiClient) Complete(ctx context.Context, messages []LLMMessage, schema *JSONSchema) (*LLMCompletion, error) {
+	endpoint := fmt.Sprintf("%s/models/%s:generateContent", GeminiEndpoint, url.PathEscape(c.model))
+
+	reqBody := GeminiRequest{}
+	for _, message := range messages {
+		role := message.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		reqBody.Contents = append(reqBody.Contents, GeminiContent{
+			Role:  role,
+			Parts: []GeminiPart{{Text: message.Content}},
+		})
+	}
+	if schema != nil {
+		reqBody.GenerationConfig = &GeminiGenerationConfig{
+			ResponseMimeType: "application/json",
+			ResponseSchema:   newGeminiSchema(schema),
+		}
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gemini API error (%d): %s", resp.StatusCode, string(body))
+	}
+
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return nil, err
+	}
+
+	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
+		return nil, errors.New("empty response from Gemini")
+	}
+
+	return &LLMCompletion{
+		Text:           geminiResp.Candidates[0].Content.Parts[0].Text,
+		PromptTokens:   geminiResp.UsageMetadata.PromptTokenCount,
+		ResponseTokens: geminiResp.UsageMetadata.CandidatesTokenCount,
+	}, nil
+}
+
+// newGeminiSchema converts a JSON schema to Gemini's schema format
+func newGeminiSchema(schema *JSONSchema) *GeminiSchema {
+	if schema == nil {
+		return nil
+	}
+
+	converted := &GeminiSchema{
+		Type:        strings.ToUpper(schema.Type),
+		Description: schema.Description,
+		Required:    schema.Required,
+		Items:       newGeminiSchema(schema.Items),
+		MinItems:    schema.MinItems,
+		MaxItems:    schema.MaxItems,
+		Minimum:     schema.Minimum,
+		Maximum:     schema.Maximum,
+	}
+	if len(schema.Properties) > 0 {
+		converted.Properties = make(map[string]*GeminiSchema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			converted.Properties[name] = newGeminiSchema(property)
+		}
+	}
+
+	return converted
+}
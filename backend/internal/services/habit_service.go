@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,11 +10,27 @@ import (
 	"github.com/habittracker/backend/internal/repository"
 )
 
+const (
+	// DefaultSnoozeMinutes is how long a reminder is snoozed when the client
+	// does not say
+	DefaultSnoozeMinutes = 10
+	// maxSnoozeMinutes is the longest a reminder can be snoozed
+	maxSnoozeMinutes = 12 * 60
+)
+
+var (
+	ErrInvalidReminderTime = errors.New("reminder time must be HH:MM")
+	ErrInvalidSnooze       = errors.New("snooze must be between 1 and 720 minutes")
+)
+
 // HabitService handles habit business logic
 type HabitService struct {
-	habitRepo  *repository.HabitRepository
-	logRepo    *repository.LogRepository
-	streakRepo *repository.StreakRepository
+	habitRepo   *repository.HabitRepository
+	logRepo     *repository.LogRepository
+	streakRepo  *repository.StreakRepository
+	freezeRepo  *repository.FreezeRepository
+	streakSvc   *StreakService
+	dayResolver *DayResolver
 }
 
 // NewHabitService creates a new HabitService
@@ -21,11 +38,17 @@ func NewHabitService(
 	habitRepo *repository.HabitRepository,
 	logRepo *repository.LogRepository,
 	streakRepo *repository.StreakRepository,
+	freezeRepo *repository.FreezeRepository,
+	streakSvc *StreakService,
+	dayResolver *DayResolver,
 ) *HabitService {
 	return &HabitService{
-		habitRepo:  habitRepo,
-		logRepo:    logRepo,
-		streakRepo: streakRepo,
+		habitRepo:   habitRepo,
+		logRepo:     logRepo,
+		streakRepo:  streakRepo,
+		freezeRepo:  freezeRepo,
+		streakSvc:   streakSvc,
+		dayResolver: dayResolver,
 	}
 }
 
@@ -37,6 +60,9 @@ func (s *HabitService) CreateHabit(ctx context.Context, userID uuid.UUID, req *m
 		Description:     req.Description,
 		Category:        req.Category,
 		Frequency:       req.Frequency,
+		Schedule:        req.Schedule,
+		TargetValue:     req.TargetValue,
+		Unit:            req.Unit,
 		IsActive:        true,
 		IsLearningHabit: req.IsLearningHabit,
 		Color:           req.Color,
@@ -44,6 +70,10 @@ func (s *HabitService) CreateHabit(ctx context.Context, userID uuid.UUID, req *m
 		ReminderTime:    req.ReminderTime,
 	}
 
+	if err := normalizeReminderTime(habit); err != nil {
+		return nil, err
+	}
+
 	// Set defaults
 	if habit.Category == "" {
 		habit.Category = models.CategoryPersonal
@@ -58,6 +88,10 @@ func (s *HabitService) CreateHabit(ctx context.Context, userID uuid.UUID, req *m
 		habit.Icon = "check"
 	}
 
+	if err := s.normalizeSchedule(ctx, userID, habit); err != nil {
+		return nil, err
+	}
+
 	if err := s.habitRepo.Create(ctx, habit); err != nil {
 		return nil, err
 	}
@@ -73,7 +107,12 @@ func (s *HabitService) GetHabit(ctx context.Context, userID, habitID uuid.UUID)
 	}
 
 	// Check if completed today
-	completed, err := s.logRepo.CheckTodayCompleted(ctx, habitID)
+	today, err := s.dayResolver.Today(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := s.logRepo.CheckTodayCompleted(ctx, habitID, today)
 	if err == nil {
 		habit.TodayCompleted = completed
 	}
@@ -89,8 +128,13 @@ func (s *HabitService) GetUserHabits(ctx context.Context, userID uuid.UUID) ([]*
 	}
 
 	// Check today's completion for each habit
+	today, err := s.dayResolver.Today(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, habit := range habits {
-		completed, err := s.logRepo.CheckTodayCompleted(ctx, habit.ID)
+		completed, err := s.logRepo.CheckTodayCompleted(ctx, habit.ID, today)
 		if err == nil {
 			habit.TodayCompleted = completed
 		}
@@ -107,8 +151,13 @@ func (s *HabitService) GetActiveUserHabits(ctx context.Context, userID uuid.UUID
 	}
 
 	// Check today's completion for each habit
+	today, err := s.dayResolver.Today(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, habit := range habits {
-		completed, err := s.logRepo.CheckTodayCompleted(ctx, habit.ID)
+		completed, err := s.logRepo.CheckTodayCompleted(ctx, habit.ID, today)
 		if err == nil {
 			habit.TodayCompleted = completed
 		}
@@ -137,6 +186,15 @@ func (s *HabitService) UpdateHabit(ctx context.Context, userID, habitID uuid.UUI
 	if req.Frequency != nil {
 		habit.Frequency = *req.Frequency
 	}
+	if req.Schedule != nil {
+		habit.Schedule = req.Schedule
+	}
+	if req.TargetValue != nil {
+		habit.TargetValue = req.TargetValue
+	}
+	if req.Unit != nil {
+		habit.Unit = req.Unit
+	}
 	if req.IsActive != nil {
 		habit.IsActive = *req.IsActive
 	}
@@ -151,15 +209,93 @@ func (s *HabitService) UpdateHabit(ctx context.Context, userID, habitID uuid.UUI
 	}
 	if req.ReminderTime != nil {
 		habit.ReminderTime = req.ReminderTime
+		if err := normalizeReminderTime(habit); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Frequency != nil || req.Schedule != nil {
+		if err := s.normalizeSchedule(ctx, userID, habit); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := s.habitRepo.Update(ctx, habit); err != nil {
 		return nil, err
 	}
 
+	// A new schedule changes which days the streak needs
+	if req.Frequency != nil || req.Schedule != nil {
+		if _, err := s.streakSvc.RecomputeHabit(ctx, habit); err != nil {
+			return nil, err
+		}
+	}
+
 	return habit, nil
 }
 
+// normalizeSchedule validates a habit's schedule against its frequency, drops
+// schedules on frequencies that take none and anchors every_n_days habits
+// to the user's today
+func (s *HabitService) normalizeSchedule(ctx context.Context, userID uuid.UUID, habit *models.Habit) error {
+	if err := habit.Schedule.Validate(habit.Frequency); err != nil {
+		return err
+	}
+
+	switch habit.Frequency {
+	case models.FrequencyDaily, models.FrequencyWeekly:
+		habit.Schedule = nil
+	case models.FrequencyEveryNDays:
+		if habit.Schedule.StartDate == "" {
+			today, err := s.dayResolver.Today(ctx, userID)
+			if err != nil {
+				return err
+			}
+			habit.Schedule.StartDate = today.Format(models.DateLayout)
+		}
+	}
+
+	return nil
+}
+
+// normalizeReminderTime validates a habit's reminder time, treating an empty
+// one as no reminder
+func normalizeReminderTime(habit *models.Habit) error {
+	if habit.ReminderTime == nil {
+		return nil
+	}
+	if *habit.ReminderTime == "" {
+		habit.ReminderTime = nil
+		return nil
+	}
+	if _, err := models.ParseClock(*habit.ReminderTime); err != nil {
+		return ErrInvalidReminderTime
+	}
+	return nil
+}
+
+// SnoozeReminder pushes a habit's reminder back by the given minutes from now
+func (s *HabitService) SnoozeReminder(ctx context.Context, userID, habitID uuid.UUID, minutes int) (*models.HabitSnoozeResponse, error) {
+	if minutes < 1 || minutes > maxSnoozeMinutes {
+		return nil, ErrInvalidSnooze
+	}
+
+	// Verify ownership
+	if _, err := s.habitRepo.GetByIDAndUserID(ctx, habitID, userID); err != nil {
+		return nil, err
+	}
+
+	until := time.Now().Add(time.Duration(minutes) * time.Minute).Truncate(time.Second)
+	if err := s.habitRepo.SnoozeReminder(ctx, habitID, until); err != nil {
+		return nil, err
+	}
+
+	return &models.HabitSnoozeResponse{
+		HabitID:      habitID,
+		SnoozedUntil: until,
+	}, nil
+}
+
 // DeleteHabit soft deletes a habit
 func (s *HabitService) DeleteHabit(ctx context.Context, userID, habitID uuid.UUID) error {
 	// Verify ownership
@@ -189,8 +325,12 @@ func (s *HabitService) GetTodayHabitsStatus(ctx context.Context, userID uuid.UUI
 		return nil, err
 	}
 
-	today := time.Now()
-	todayLogs, err := s.logRepo.GetTodayLogs(ctx, userID)
+	today, err := s.dayResolver.Today(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	todayLogs, err := s.logRepo.GetTodayLogs(ctx, userID, today)
 	if err != nil {
 		return nil, err
 	}
@@ -201,19 +341,60 @@ func (s *HabitService) GetTodayHabitsStatus(ctx context.Context, userID uuid.UUI
 		logMap[log.HabitID] = log
 	}
 
+	// Count completions earlier this week for weekly quota habits
+	weekLogs, err := s.logRepo.GetByUserAndDateRange(ctx, userID, models.WeekStart(today), today.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	weekCounts := make(map[uuid.UUID]int)
+	for _, log := range weekLogs {
+		if log.Completed {
+			weekCounts[log.HabitID]++
+		}
+	}
+
+	// Frozen days and rest days this week are not due and shrink weekly quotas
+	weekStart, weekEnd := models.WeekStart(today), models.WeekStart(today).AddDate(0, 0, 6)
+	vacations, err := s.freezeRepo.GetVacationsInRange(ctx, userID, weekStart, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+	rest := models.RestDays(vacations, weekStart, weekEnd)
+
+	freezes, err := s.freezeRepo.GetByUserAndDateRange(ctx, userID, weekStart, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+
 	var habitStatuses []*models.TodayHabitStatus
 	for _, habit := range habits {
+		skipped := make(map[time.Time]bool, len(rest))
+		for date := range rest {
+			skipped[date] = true
+		}
+		for _, freeze := range freezes {
+			if freeze.HabitID == habit.ID {
+				skipped[freeze.FreezeDate] = true
+			}
+		}
+
 		status := &models.TodayHabitStatus{
 			HabitID:       habit.ID,
 			HabitTitle:    habit.Title,
 			Category:      string(habit.Category),
 			IsLearning:    habit.IsLearningHabit,
+			Frequency:     string(habit.Frequency),
+			IsDue:         habit.IsDueOn(today, weekCounts[habit.ID], skipped),
 			Completed:     false,
+			TargetValue:   habit.TargetValue,
+			Unit:          habit.Unit,
 			CurrentStreak: habit.CurrentStreak,
 		}
 
 		if log, ok := logMap[habit.ID]; ok {
 			status.Completed = log.Completed
+			status.Value = log.Value
 			status.LearningNote = log.LearningNote
 		}
 
@@ -221,7 +402,7 @@ func (s *HabitService) GetTodayHabitsStatus(ctx context.Context, userID uuid.UUI
 	}
 
 	return &models.TodayLogsResponse{
-		Date:   today.Format("2006-01-02"),
+		Date:   today.Format(models.DateLayout),
 		Habits: habitStatuses,
 	}, nil
 }
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+// InsightService learns when users typically complete their habits
+type InsightService struct {
+	habitRepo   *repository.HabitRepository
+	logRepo     *repository.LogRepository
+	windowRepo  *repository.CompletionWindowRepository
+	dayResolver *DayResolver
+}
+
+// NewInsightService creates a new InsightService
+func NewInsightService(
+	habitRepo *repository.HabitRepository,
+	logRepo *repository.LogRepository,
+	windowRepo *repository.CompletionWindowRepository,
+	dayResolver *DayResolver,
+) *InsightService {
+	return &InsightService{
+		habitRepo:   habitRepo,
+		logRepo:     logRepo,
+		windowRepo:  windowRepo,
+		dayResolver: dayResolver,
+	}
+}
+
+// RefreshHabit relearns a habit's completion window from its recent history
+// and stores it for reminder scheduling. Only completions logged on the day
+// itself count; the time a past day was backfilled says nothing about when
+// the habit gets done.
+func (s *InsightService) RefreshHabit(ctx context.Context, habit *models.Habit) (*models.CompletionWindow, error) {
+	loc, err := s.dayResolver.UserLocation(ctx, habit.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.refresh(ctx, habit, loc)
+}
+
+// refresh relearns a habit's completion window in the user's location
+func (s *InsightService) refresh(ctx context.Context, habit *models.Habit, loc *time.Location) (*models.CompletionWindow, error) {
+	today := s.dayResolver.TodayIn(loc)
+	logs, err := s.logRepo.GetCompletionTimes(ctx, habit.ID, today.AddDate(0, 0, -models.CompletionLookbackDays))
+	if err != nil {
+		return nil, err
+	}
+
+	var minutes []int
+	for _, log := range logs {
+		if !models.DateOf(*log.CompletedAt, loc).Equal(log.LogDate) {
+			continue
+		}
+		minutes = append(minutes, models.MinuteOfDay(*log.CompletedAt, loc))
+	}
+
+	window := models.NewCompletionWindow(habit.ID, habit.UserID, minutes)
+	if err := s.windowRepo.Upsert(ctx, window); err != nil {
+		return nil, err
+	}
+
+	return window, nil
+}
+
+// RefreshUser relearns the completion windows of all of a user's active
+// habits and returns how many were refreshed
+func (s *InsightService) RefreshUser(ctx context.Context, user *models.User) (int, error) {
+	habits, err := s.habitRepo.GetActiveByUserID(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	loc := s.dayResolver.Location(user.Timezone)
+	for i, habit := range habits {
+		if _, err := s.refresh(ctx, habit, loc); err != nil {
+			return i, err
+		}
+	}
+
+	return len(habits), nil
+}
+
+// GetHabitInsights returns a habit's freshly learned completion window and
+// explains when its reminder goes out
+func (s *InsightService) GetHabitInsights(ctx context.Context, userID, habitID uuid.UUID) (*models.HabitInsightsResponse, error) {
+	habit, err := s.habitRepo.GetByIDAndUserID(ctx, habitID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	window, err := s.RefreshHabit(ctx, habit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.HabitInsightsResponse{
+		HabitID:      habit.ID,
+		SampleSize:   window.SampleSize,
+		MinSamples:   models.MinCompletionSamples,
+		LookbackDays: models.CompletionLookbackDays,
+		ComputedAt:   window.ComputedAt,
+	}
+
+	switch {
+	case habit.ReminderTime != nil:
+		reminder := formatClock(*habit.ReminderTime)
+		resp.ReminderTime = &reminder
+		resp.ReminderSource = models.ReminderSourceConfigured
+		resp.Explanation = fmt.Sprintf("We remind you at %s, the time you set.", reminder)
+		if window.NudgeTime != nil {
+			resp.CompletionWindow = &models.CompletionWindowResponse{
+				Start:   *window.WindowStart,
+				End:     *window.WindowEnd,
+				Typical: *window.TypicalTime,
+			}
+			resp.Explanation += fmt.Sprintf(
+				" You usually complete this habit between %s and %s; clear your reminder time and we remind you at %s, %d minutes before.",
+				*window.WindowStart, *window.WindowEnd, *window.NudgeTime, models.NudgeLeadMinutes)
+		}
+	case window.NudgeTime != nil:
+		resp.CompletionWindow = &models.CompletionWindowResponse{
+			Start:   *window.WindowStart,
+			End:     *window.WindowEnd,
+			Typical: *window.TypicalTime,
+		}
+		resp.ReminderTime = window.NudgeTime
+		resp.ReminderSource = models.ReminderSourceLearned
+		resp.Explanation = fmt.Sprintf(
+			"You usually complete this habit between %s and %s, so we remind you at %s, %d minutes before.",
+			*window.WindowStart, *window.WindowEnd, *window.NudgeTime, models.NudgeLeadMinutes)
+	default:
+		resp.ReminderSource = models.ReminderSourceNone
+		resp.Explanation = fmt.Sprintf(
+			"This habit has no reminder yet. After %d completions within the last %d days we learn when you usually do it and remind you shortly before.",
+			models.MinCompletionSamples, models.CompletionLookbackDays)
+	}
+
+	return resp, nil
+}
+
+// formatClock shortens a stored time of day to HH:MM
+func formatClock(clock string) string {
+	t, err := models.ParseClock(clock)
+	if err != nil {
+		return clock
+	}
+	return t.Format(models.ClockLayout)
+}
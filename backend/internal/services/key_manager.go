@@ -0,0 +1,551 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/habittracker/backend/internal/config"
+)
+
+const (
+	rsaKeyBits = 2048
+
+	// keyReloadInterval is how often the key directory is re-read so replicas
+	// sharing a directory pick up keys rotated by their peers
+	keyReloadInterval = time.Minute
+
+	// JWKSMaxAge is how long verifiers may cache the published key set
+	JWKSMaxAge = 5 * time.Minute
+
+	// keyActivationDelay is how long a new key is published before it signs,
+	// so verifiers caching the key set and replicas reloading the key
+	// directory know it by the time tokens signed with it reach them
+	keyActivationDelay = JWKSMaxAge + keyReloadInterval
+)
+
+// signingKey is a key pair (or a public key alone) used for access tokens
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	public    crypto.PublicKey
+	createdAt time.Time
+	path      string
+}
+
+// KeyManager signs access tokens and exposes the verification key set.
+// Keys come from configuration (JWT_PRIVATE_KEYS), a key directory
+// (JWT_KEY_DIR) or, as a development fallback, are generated in memory.
+// Generated keys are rotated on JWT_KEY_ROTATION_INTERVAL: the next key is
+// published keyActivationDelay ahead of signing with it, and retired keys are
+// kept for verification until tokens signed with them have expired.
+type KeyManager struct {
+	algorithm        string
+	hmacSecret       []byte
+	dir              string
+	rotationInterval time.Duration
+	retention        time.Duration
+	now              func() time.Time
+
+	mu         sync.RWMutex
+	active     *signingKey
+	configured []*signingKey
+	managed    []*signingKey
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewKeyManager creates a KeyManager from configuration and starts scheduled
+// rotation when the manager owns its keys
+func NewKeyManager(cfg *config.Config) (*KeyManager, error) {
+	km := &KeyManager{
+		algorithm:        cfg.JWTSigningAlgorithm,
+		hmacSecret:       []byte(cfg.JWTSecret),
+		dir:              cfg.JWTKeyDir,
+		rotationInterval: cfg.JWTKeyRotationInterval,
+		retention:        cfg.JWTExpiry,
+		now:              time.Now,
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+
+	if km.algorithm == jwt.SigningMethodHS256.Alg() {
+		close(km.done)
+		return km, nil
+	}
+
+	if km.algorithm != jwt.SigningMethodRS256.Alg() && km.algorithm != jwt.SigningMethodEdDSA.Alg() {
+		return nil, fmt.Errorf("unsupported JWT signing algorithm %q", km.algorithm)
+	}
+
+	configured, err := parsePEMKeys([]byte(cfg.JWTPrivateKeys), "")
+	if err != nil {
+		return nil, fmt.Errorf("parsing JWT_PRIVATE_KEYS: %w", err)
+	}
+	km.configured = configured
+
+	if err := km.refresh(); err != nil {
+		return nil, err
+	}
+
+	if km.ownsKeys() && km.rotationInterval > 0 {
+		go km.rotationLoop()
+	} else {
+		close(km.done)
+	}
+
+	return km, nil
+}
+
+// Close stops scheduled rotation
+func (km *KeyManager) Close() {
+	select {
+	case <-km.stop:
+	default:
+		close(km.stop)
+	}
+	<-km.done
+}
+
+// Sign signs claims with the active key, setting the kid header
+func (km *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	if km.algorithm == jwt.SigningMethodHS256.Alg() {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(km.hmacSecret)
+	}
+
+	km.mu.RLock()
+	key := km.active
+	km.mu.RUnlock()
+
+	if key == nil || key.private == nil {
+		return "", errors.New("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// Keyfunc resolves the verification key for a token by its kid header
+func (km *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if km.algorithm == jwt.SigningMethodHS256.Alg() {
+		return km.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range km.verificationKeys() {
+		if key.kid == kid {
+			if key.method.Alg() != token.Method.Alg() {
+				return nil, ErrInvalidToken
+			}
+			return key.public, nil
+		}
+	}
+
+	return nil, ErrInvalidToken
+}
+
+// ValidMethods returns the signing algorithms accepted for access tokens
+func (km *KeyManager) ValidMethods() []string {
+	if km.algorithm == jwt.SigningMethodHS256.Alg() {
+		return []string{km.algorithm}
+	}
+	return []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+}
+
+// JWK is a single public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public verification keys. It is empty for HS256, whose
+// secret must never be published.
+func (km *KeyManager) JWKS() *JWKSet {
+	set := &JWKSet{Keys: []JWK{}}
+	for _, key := range km.verificationKeys() {
+		set.Keys = append(set.Keys, toJWK(key))
+	}
+	return set
+}
+
+// verificationKeys returns every key tokens may currently be signed with
+func (km *KeyManager) verificationKeys() []*signingKey {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	keys := make([]*signingKey, 0, len(km.configured)+len(km.managed))
+	keys = append(keys, km.managed...)
+	keys = append(keys, km.configured...)
+	return keys
+}
+
+// ownsKeys reports whether the manager generates its own keys, either in the
+// key directory or in memory when nothing was configured
+func (km *KeyManager) ownsKeys() bool {
+	return km.dir != "" || !hasPrivateKey(km.configured)
+}
+
+// rotationLoop periodically reloads and rotates managed keys
+func (km *KeyManager) rotationLoop() {
+	defer close(km.done)
+
+	// Ticks often enough to activate published keys on time even when keys
+	// are kept in memory
+	ticker := time.NewTicker(keyReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-km.stop:
+			return
+		case <-ticker.C:
+			if err := km.refresh(); err != nil {
+				log.Printf("JWT key rotation failed: %v", err)
+			}
+		}
+	}
+}
+
+// refresh reloads managed keys, generates the next one when the newest key
+// is due to be replaced within the activation delay, drops keys retired for
+// longer than the retention period and selects the active signing key
+func (km *KeyManager) refresh() error {
+	if !km.ownsKeys() {
+		km.mu.Lock()
+		km.active = newestPrivateKey(km.configured)
+		km.mu.Unlock()
+		return nil
+	}
+
+	km.mu.RLock()
+	managed := km.managed
+	km.mu.RUnlock()
+
+	if km.dir != "" {
+		loaded, err := km.loadDir()
+		if err != nil {
+			return err
+		}
+		managed = loaded
+	}
+
+	now := km.now()
+	newest := newestPrivateKey(managed)
+	if newest == nil || (km.rotationInterval > 0 && now.Sub(newest.createdAt) >= km.rotationInterval-km.rotationLead()) {
+		key, err := km.generateKey()
+		if err != nil {
+			return err
+		}
+		managed = append(managed, key)
+
+		if km.dir == "" && len(km.managed) == 0 {
+			log.Println("Warning: no JWT signing keys configured, using an in-memory key. Tokens will not survive a restart.")
+		}
+	}
+
+	km.mu.Lock()
+	km.managed = km.pruneRetired(managed, now)
+	km.active = activeKey(km.managed, now)
+	km.mu.Unlock()
+
+	return nil
+}
+
+// rotationLead is how long before the active key is due to be replaced the
+// next one is generated, capped so rotation intervals shorter than the
+// activation delay do not generate a key on every refresh
+func (km *KeyManager) rotationLead() time.Duration {
+	if lead := km.rotationInterval / 2; lead < keyActivationDelay {
+		return lead
+	}
+	return keyActivationDelay
+}
+
+// activeKey returns the newest key that has been published for the
+// activation delay. Until there is one, e.g. on first start, the newest key
+// signs, as no verifier can hold an older key set.
+func activeKey(keys []*signingKey, now time.Time) *signingKey {
+	var published []*signingKey
+	for _, key := range keys {
+		if now.Sub(key.createdAt) >= keyActivationDelay {
+			published = append(published, key)
+		}
+	}
+	if active := newestPrivateKey(published); active != nil {
+		return active
+	}
+	return newestPrivateKey(keys)
+}
+
+// pruneRetired drops keys that were superseded more than the retention
+// period ago, removing their files from the key directory. A key is
+// superseded once its successor has been published for the activation delay.
+func (km *KeyManager) pruneRetired(keys []*signingKey, now time.Time) []*signingKey {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].createdAt.Before(keys[j].createdAt)
+	})
+
+	kept := keys[:0]
+	for i, key := range keys {
+		if i < len(keys)-1 && now.Sub(keys[i+1].createdAt) > keyActivationDelay+km.retention {
+			if key.path != "" {
+				if err := os.Remove(key.path); err != nil && !os.IsNotExist(err) {
+					log.Printf("failed to remove retired JWT key %s: %v", key.path, err)
+				}
+			}
+			continue
+		}
+		kept = append(kept, key)
+	}
+
+	return kept
+}
+
+// loadDir reads every PEM key file from the key directory. A key's creation
+// time is its file modification time.
+func (km *KeyManager) loadDir() ([]*signingKey, error) {
+	if err := os.MkdirAll(km.dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(km.dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*signingKey
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := parsePEMKeys(data, path)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+
+		for _, key := range parsed {
+			key.createdAt = info.ModTime()
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// generateKey creates a new key for the configured algorithm, persisting it
+// to the key directory when one is configured
+func (km *KeyManager) generateKey() (*signingKey, error) {
+	var private crypto.Signer
+	switch km.algorithm {
+	case jwt.SigningMethodEdDSA.Alg():
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		private = key
+	default:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		private = key
+	}
+
+	key, err := newSigningKey(private, private.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.createdAt = km.now()
+
+	if km.dir == "" {
+		return key, nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	key.path = filepath.Join(km.dir, key.kid+".pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(key.path, data, 0o600); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Generated new JWT signing key %s", key.kid)
+	return key, nil
+}
+
+// parsePEMKeys parses every private or public key block in PEM data.
+// Public-only keys are accepted for verification of tokens signed by keys
+// whose private half has been destroyed.
+func parsePEMKeys(data []byte, path string) ([]*signingKey, error) {
+	var keys []*signingKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var (
+			private crypto.Signer
+			public  crypto.PublicKey
+		)
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := parsed.(crypto.Signer)
+			if !ok {
+				return nil, errors.New("unsupported private key type")
+			}
+			private, public = signer, signer.Public()
+		case "RSA PRIVATE KEY":
+			parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			private, public = parsed, parsed.Public()
+		case "PUBLIC KEY":
+			parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			public = parsed
+		default:
+			continue
+		}
+
+		key, err := newSigningKey(private, public)
+		if err != nil {
+			return nil, err
+		}
+		key.path = path
+		keys = append(keys, key)
+	}
+
+	if len(strings.TrimSpace(string(data))) > 0 {
+		return nil, errors.New("trailing data is not valid PEM")
+	}
+
+	return keys, nil
+}
+
+// newSigningKey wraps a key pair, deriving its algorithm and kid
+func newSigningKey(private crypto.Signer, public crypto.PublicKey) (*signingKey, error) {
+	key := &signingKey{private: private, public: public}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	key.kid = thumbprint(toJWK(key))
+	return key, nil
+}
+
+// toJWK converts a key to its public JWK representation
+func toJWK(key *signingKey) JWK {
+	jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return jwk
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint used as the key ID
+func thumbprint(jwk JWK) string {
+	var members interface{}
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// hasPrivateKey reports whether any key can sign
+func hasPrivateKey(keys []*signingKey) bool {
+	return newestPrivateKey(keys) != nil
+}
+
+// newestPrivateKey returns the most recently created key that can sign.
+// For keys without a creation time the last one listed wins.
+func newestPrivateKey(keys []*signingKey) *signingKey {
+	var newest *signingKey
+	for _, key := range keys {
+		if key.private == nil {
+			continue
+		}
+		if newest == nil || !key.createdAt.Before(newest.createdAt) {
+			newest = key
+		}
+	}
+	return newest
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"log"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,26 +12,36 @@ import (
 	"github.com/habittracker/backend/internal/repository"
 )
 
+var (
+	ErrNotQuantitative = errors.New("habit has no target value")
+)
+
 // LogService handles daily log business logic
 type LogService struct {
 	logRepo         *repository.LogRepository
 	habitRepo       *repository.HabitRepository
-	streakRepo      *repository.StreakRepository
+	freezeRepo      *repository.FreezeRepository
+	streakSvc       *StreakService
 	gamificationSvc *GamificationService
+	dayResolver     *DayResolver
 }
 
 // NewLogService creates a new LogService
 func NewLogService(
 	logRepo *repository.LogRepository,
 	habitRepo *repository.HabitRepository,
-	streakRepo *repository.StreakRepository,
+	freezeRepo *repository.FreezeRepository,
+	streakSvc *StreakService,
 	gamificationSvc *GamificationService,
+	dayResolver *DayResolver,
 ) *LogService {
 	return &LogService{
 		logRepo:         logRepo,
 		habitRepo:       habitRepo,
-		streakRepo:      streakRepo,
+		freezeRepo:      freezeRepo,
+		streakSvc:       streakSvc,
 		gamificationSvc: gamificationSvc,
+		dayResolver:     dayResolver,
 	}
 }
 
@@ -42,7 +54,7 @@ func (s *LogService) CreateOrUpdateLog(ctx context.Context, userID uuid.UUID, re
 	}
 
 	// Parse log date
-	logDate, err := time.Parse("2006-01-02", req.LogDate)
+	logDate, err := time.Parse(models.DateLayout, req.LogDate)
 	if err != nil {
 		return nil, err
 	}
@@ -56,31 +68,39 @@ func (s *LogService) CreateOrUpdateLog(ctx context.Context, userID uuid.UUID, re
 		UserID:       userID,
 		LogDate:      logDate,
 		Completed:    req.Completed,
+		Value:        req.Value,
 		LearningNote: req.LearningNote,
 		HabitTitle:   habit.Title,
 	}
 
 	if existingLog != nil {
 		dailyLog.ID = existingLog.ID
-	}
 
-	if err := s.logRepo.CreateOrUpdate(ctx, dailyLog); err != nil {
-		return nil, err
+		// Keep progress recorded earlier when the request carries no value
+		if dailyLog.Value == nil {
+			dailyLog.Value = existingLog.Value
+		}
 	}
 
-	// Update streak if completed status changed
-	if req.Completed && !wasCompletedBefore {
-		// Habit was completed, update streak
-		if err := s.streakRepo.UpdateStreakAfterCompletion(ctx, req.HabitID, logDate); err != nil {
-			log.Printf("failed to update streak for habit %s: %v", req.HabitID, err)
+	// Quantitative habits are completed once the value reaches the target;
+	// marking one completed without a value fills it up to the target, and
+	// un-completing one clears a value that would still reach it
+	if habit.IsQuantitative() {
+		if req.Value != nil {
+			dailyLog.Completed = *req.Value >= *habit.TargetValue
+		} else if req.Completed && (dailyLog.Value == nil || *dailyLog.Value < *habit.TargetValue) {
+			dailyLog.Value = habit.TargetValue
+		} else if !req.Completed && dailyLog.Value != nil && *dailyLog.Value >= *habit.TargetValue {
+			dailyLog.Value = nil
 		}
+	}
 
-		// Award XP
-		if err := s.gamificationSvc.AwardHabitCompletionXP(ctx, userID, req.HabitID, false); err != nil {
-			log.Printf("failed to award habit completion XP to user %s: %v", userID, err)
-		}
+	if err := s.logRepo.CreateOrUpdate(ctx, dailyLog); err != nil {
+		return nil, err
 	}
 
+	s.onLogWritten(ctx, userID, habit, dailyLog, wasCompletedBefore)
+
 	// Award XP for learning note if new
 	if req.LearningNote != nil && *req.LearningNote != "" && (existingLog == nil || existingLog.LearningNote == nil || *existingLog.LearningNote == "") {
 		if err := s.gamificationSvc.AwardLearningNoteXP(ctx, userID, dailyLog.ID); err != nil {
@@ -91,6 +111,61 @@ func (s *LogService) CreateOrUpdateLog(ctx context.Context, userID uuid.UUID, re
 	return dailyLog, nil
 }
 
+// IncrementLog adds partial progress to a quantitative habit's log, completing
+// it once the day's value reaches the habit's target
+func (s *LogService) IncrementLog(ctx context.Context, userID, habitID uuid.UUID, req *models.DailyLogIncrementRequest) (*models.DailyLog, error) {
+	habit, err := s.habitRepo.GetByIDAndUserID(ctx, habitID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !habit.IsQuantitative() {
+		return nil, ErrNotQuantitative
+	}
+
+	var logDate time.Time
+	if req.LogDate != nil {
+		logDate, err = time.Parse(models.DateLayout, *req.LogDate)
+	} else {
+		logDate, err = s.dayResolver.Today(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dailyLog := &models.DailyLog{
+		HabitID:    habitID,
+		UserID:     userID,
+		LogDate:    logDate,
+		HabitTitle: habit.Title,
+	}
+
+	if err := s.logRepo.IncrementValue(ctx, dailyLog, req.Amount, *habit.TargetValue); err != nil {
+		return nil, err
+	}
+
+	// The value before this increment tells whether it crossed the target
+	wasCompletedBefore := *dailyLog.Value-req.Amount >= *habit.TargetValue
+	s.onLogWritten(ctx, userID, habit, dailyLog, wasCompletedBefore)
+
+	return dailyLog, nil
+}
+
+// onLogWritten rebuilds the habit's streak from its log history and awards
+// XP when the log became completed
+func (s *LogService) onLogWritten(ctx context.Context, userID uuid.UUID, habit *models.Habit, dailyLog *models.DailyLog, wasCompletedBefore bool) {
+	// Any write can change the streak, including un-completing or backfilling a day
+	if _, err := s.streakSvc.RecomputeHabit(ctx, habit); err != nil {
+		log.Printf("failed to recompute streak for habit %s: %v", habit.ID, err)
+	}
+
+	if dailyLog.Completed && !wasCompletedBefore {
+		if err := s.gamificationSvc.AwardHabitCompletionXP(ctx, userID, habit.ID, false); err != nil {
+			log.Printf("failed to award habit completion XP to user %s: %v", userID, err)
+		}
+	}
+}
+
 // GetLog retrieves a daily log by ID
 func (s *LogService) GetLog(ctx context.Context, userID, logID uuid.UUID) (*models.DailyLog, error) {
 	dailyLog, err := s.logRepo.GetByID(ctx, logID)
@@ -106,9 +181,15 @@ func (s *LogService) GetLog(ctx context.Context, userID, logID uuid.UUID) (*mode
 	return dailyLog, nil
 }
 
-// GetTodayLogs retrieves today's logs for all habits
+// GetTodayLogs retrieves today's logs for all habits, where today is the
+// user's local calendar date
 func (s *LogService) GetTodayLogs(ctx context.Context, userID uuid.UUID) ([]*models.DailyLog, error) {
-	return s.logRepo.GetTodayLogs(ctx, userID)
+	today, err := s.dayResolver.Today(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.logRepo.GetTodayLogs(ctx, userID, today)
 }
 
 // GetLogsByDateRange retrieves logs within a date range
@@ -137,44 +218,178 @@ func (s *LogService) GetLogsByHabit(ctx context.Context, userID, habitID uuid.UU
 	return s.logRepo.GetByHabit(ctx, habitID, limit, offset)
 }
 
-// GetCalendarData retrieves calendar data for a month
+// GetCalendarData retrieves calendar data for a month. Each day counts the
+// habits whose schedule made them due that day, up to the user's today.
+// Frozen habits and rest days are not due and are reported separately.
 func (s *LogService) GetCalendarData(ctx context.Context, userID uuid.UUID, year, month int) (*models.CalendarMonthResponse, error) {
-	data, err := s.logRepo.GetCalendarData(ctx, userID, year, month)
+	loc, err := s.dayResolver.UserLocation(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	habits, err := s.habitRepo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+	if today := s.dayResolver.TodayIn(loc); end.After(today) {
+		end = today
+	}
+
+	// Start at the week containing the 1st so weekly quotas see earlier completions
+	logs, err := s.logRepo.GetByUserAndDateRange(ctx, userID, models.WeekStart(start), end)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[uuid.UUID]map[time.Time]bool)
+	totals := make(map[uuid.UUID]float64)
+	for _, log := range logs {
+		if log.Value != nil && !log.LogDate.Before(start) {
+			totals[log.HabitID] += *log.Value
+		}
+		if !log.Completed {
+			continue
+		}
+		if completed[log.HabitID] == nil {
+			completed[log.HabitID] = make(map[time.Time]bool)
+		}
+		completed[log.HabitID][models.DateOf(log.LogDate, time.UTC)] = true
+	}
+
+	vacations, err := s.freezeRepo.GetVacationsInRange(ctx, userID, models.WeekStart(start), end)
+	if err != nil {
+		return nil, err
+	}
+	rest := models.RestDays(vacations, models.WeekStart(start), end)
+
+	freezes, err := s.freezeRepo.GetByUserAndDateRange(ctx, userID, models.WeekStart(start), end)
+	if err != nil {
+		return nil, err
+	}
+
+	frozen := make(map[uuid.UUID]map[time.Time]bool)
+	for _, freeze := range freezes {
+		if frozen[freeze.HabitID] == nil {
+			frozen[freeze.HabitID] = make(map[time.Time]bool)
+		}
+		frozen[freeze.HabitID][freeze.FreezeDate] = true
+	}
+
+	days := make(map[time.Time]*models.CalendarDayData)
+	dayData := func(date time.Time) *models.CalendarDayData {
+		day, ok := days[date]
+		if !ok {
+			day = &models.CalendarDayData{Date: date.Format(models.DateLayout)}
+			days[date] = day
+		}
+		return day
+	}
+
+	for date := range rest {
+		if !date.Before(start) {
+			dayData(date).IsRestDay = true
+		}
+	}
+	var quantities []*models.HabitQuantitySummary
+	for _, habit := range habits {
+		from := start
+		if created := models.DateOf(habit.CreatedAt, loc); from.Before(created) {
+			from = created
+		}
+
+		skipped := make(map[time.Time]bool, len(rest)+len(frozen[habit.ID]))
+		for date := range rest {
+			skipped[date] = true
+		}
+		for date := range frozen[habit.ID] {
+			skipped[date] = true
+			if !date.Before(from) && !completed[habit.ID][date] {
+				dayData(date).FrozenCount++
+			}
+		}
+
+		due := habit.DueDates(from, end, completed[habit.ID], skipped)
+		if habit.IsQuantitative() {
+			quantities = append(quantities, newQuantitySummary(habit, totals[habit.ID], len(due)))
+		}
+
+		for _, date := range due {
+			day := dayData(date)
+			day.TotalHabits++
+			if completed[habit.ID][date] {
+				day.CompletedCount++
+			}
+		}
+	}
+
+	data := []*models.CalendarDayData{}
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		day, ok := days[date]
+		if !ok {
+			continue
+		}
+		if day.TotalHabits > 0 {
+			day.Percentage = int(math.Round(float64(day.CompletedCount) / float64(day.TotalHabits) * 100))
+		}
+		data = append(data, day)
+	}
+
 	return &models.CalendarMonthResponse{
-		Month: time.Month(month).String(),
-		Year:  year,
-		Days:  data,
+		Month:      time.Month(month).String(),
+		Year:       year,
+		Days:       data,
+		Quantities: quantities,
 	}, nil
 }
 
+// newQuantitySummary totals a quantitative habit's values over its due days
+func newQuantitySummary(habit *models.Habit, total float64, dueDays int) *models.HabitQuantitySummary {
+	summary := &models.HabitQuantitySummary{
+		HabitID:     habit.ID,
+		HabitTitle:  habit.Title,
+		Unit:        habit.Unit,
+		TargetValue: *habit.TargetValue,
+		TotalValue:  total,
+	}
+	if dueDays > 0 {
+		summary.AverageValue = total / float64(dueDays)
+	}
+	return summary
+}
+
 // GetLogsUpdatedSince retrieves logs updated since a given time (for sync)
 func (s *LogService) GetLogsUpdatedSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.DailyLog, error) {
 	return s.logRepo.GetUpdatedSince(ctx, userID, since)
 }
 
-// QuickComplete quickly marks a habit as completed for today
+// QuickComplete quickly marks a habit as completed for the user's today
 func (s *LogService) QuickComplete(ctx context.Context, userID, habitID uuid.UUID) (*models.DailyLog, error) {
-	today := time.Now().Format("2006-01-02")
+	today, err := s.dayResolver.Today(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
 	completed := true
 
 	return s.CreateOrUpdateLog(ctx, userID, &models.DailyLogCreateRequest{
 		HabitID:   habitID,
-		LogDate:   today,
+		LogDate:   today.Format(models.DateLayout),
 		Completed: completed,
 	})
 }
 
-// AddLearningNote adds a learning note to today's log
+// AddLearningNote adds a learning note to the user's today log
 func (s *LogService) AddLearningNote(ctx context.Context, userID, habitID uuid.UUID, note string) (*models.DailyLog, error) {
-	today := time.Now().Format("2006-01-02")
+	today, err := s.dayResolver.Today(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
 
 	return s.CreateOrUpdateLog(ctx, userID, &models.DailyLogCreateRequest{
 		HabitID:      habitID,
-		LogDate:      today,
+		LogDate:      today.Format(models.DateLayout),
 		Completed:    true,
 		LearningNote: &note,
 	})
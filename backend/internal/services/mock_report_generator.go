@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+)
+
+// MockReportGenerator builds reports from the habit data alone, without a
+// language model. The same input always yields the same report, which makes
+// it suitable for development, tests and as a fallback.
+type MockReportGenerator struct{}
+
+// NewMockReportGenerator creates a new MockReportGenerator
+func NewMockReportGenerator() *MockReportGenerator {
+	return &MockReportGenerator{}
+}
+
+// Name returns the provider name
+func (g *MockReportGenerator) Name() string {
+	return "mock"
+}
+
+// GenerateReport generates a report from templates
+func (g *MockReportGenerator) GenerateReport(ctx context.Context, input *models.ReportGenerationInput) (*models.ReportGeneration, error) {
+	return &models.ReportGeneration{
+		Content: mockReportContent(input),
+		Source:  models.ReportSourceMock,
+		Model:   g.Name(),
+	}, nil
+}
+
+// mockReportContent fills the report sections from the habit data
+func mockReportContent(input *models.ReportGenerationInput) *models.ReportContent {
+	// Extract skills from learning notes
+	var skillsLearned []string
+	skillSet := make(map[string]bool)
+
+	for _, habit := range input.Habits {
+		for _, note := range habit.LearningNotes {
+			if note != "" && !skillSet[note] {
+				skillSet[note] = true
+				if len(skillsLearned) < 5 {
+					skillsLearned = append(skillsLearned, note)
+				}
+			}
+		}
+	}
+
+	if len(skillsLearned) == 0 {
+		skillsLearned = []string{"Consistent practice", "Building good habits"}
+	}
+
+	comparison := input.Comparison
+	if comparison == nil {
+		comparison = &models.ReportComparison{}
+	}
+	period := string(input.Period.Type)
+
+	// Generate improvements, starting with the changes since the previous period
+	var improvements []string
+	mentioned := make(map[uuid.UUID]bool)
+	for _, change := range comparison.Improved[:min(2, len(comparison.Improved))] {
+		improvements = append(improvements, fmt.Sprintf("Improved %s from %.0f%% to %.0f%%", change.HabitTitle, change.PreviousRate, change.CurrentRate))
+		mentioned[change.HabitID] = true
+	}
+	for _, change := range comparison.New[:min(1, len(comparison.New))] {
+		improvements = append(improvements, fmt.Sprintf("Started %s with %.0f%% completion", change.HabitTitle, change.CurrentRate))
+		mentioned[change.HabitID] = true
+	}
+	for _, habit := range input.Habits {
+		if len(improvements) >= 3 {
+			break
+		}
+		if mentioned[habit.HabitID] {
+			continue
+		}
+		if habit.TotalValue != nil && *habit.TotalValue > 0 {
+			improvements = append(improvements, fmt.Sprintf("Logged %s for %s (%s per day on average)", formatQuantity(*habit.TotalValue, habit.Unit), habit.HabitTitle, formatQuantity(*habit.AverageValue, habit.Unit)))
+		} else if habit.CompletionRate >= 70 {
+			improvements = append(improvements, fmt.Sprintf("Great consistency with %s (%.0f%% completion)", habit.HabitTitle, habit.CompletionRate))
+		}
+	}
+
+	if len(improvements) == 0 {
+		improvements = []string{"Started tracking habits consistently", "Building awareness of daily routines"}
+	}
+
+	// Generate areas to improve, starting with the habits that slipped
+	var areasToImprove []string
+	for _, change := range comparison.Dropped[:min(1, len(comparison.Dropped))] {
+		areasToImprove = append(areasToImprove, fmt.Sprintf("Get back to %s - %.0f%% last %s, no completions this %s", change.HabitTitle, change.PreviousRate, period, period))
+		mentioned[change.HabitID] = true
+	}
+	for _, change := range comparison.Declined[:min(1, len(comparison.Declined))] {
+		areasToImprove = append(areasToImprove, fmt.Sprintf("%s slipped from %.0f%% to %.0f%%", change.HabitTitle, change.PreviousRate, change.CurrentRate))
+		mentioned[change.HabitID] = true
+	}
+	for _, habit := range input.Habits {
+		if len(areasToImprove) >= 2 {
+			break
+		}
+		if habit.CompletionRate < 50 && habit.DueDays > 0 && !mentioned[habit.HabitID] {
+			areasToImprove = append(areasToImprove, fmt.Sprintf("Consider adjusting %s - currently at %.0f%% completion", habit.HabitTitle, habit.CompletionRate))
+		}
+	}
+
+	if len(areasToImprove) == 0 {
+		areasToImprove = []string{"Keep pushing for higher completion rates"}
+	}
+
+	// Generate revision suggestions, except in weekly check-ins which are too short for them
+	revisionSkills := skillsLearned[:min(2, len(skillsLearned))]
+	if input.Period.Type == models.ReportPeriodWeek {
+		revisionSkills = nil
+	}
+
+	var revisionSuggestions []models.RevisionSuggestion
+	for _, skill := range revisionSkills {
+		revisionSuggestions = append(revisionSuggestions, models.RevisionSuggestion{
+			Skill:                 skill,
+			Reason:                "Learned recently - reinforce through revision",
+			SuggestedDurationDays: 7,
+			DailyMinutes:          30,
+		})
+	}
+
+	summary := fmt.Sprintf("You tracked %d habits this %s with an overall completion rate of %.1f%%.", input.TotalHabits, period, input.OverallCompletion)
+	if comparison.PreviousCompletion > 0 {
+		switch {
+		case comparison.Change >= 1:
+			summary += fmt.Sprintf(" That's up from %.1f%% last %s.", comparison.PreviousCompletion, period)
+		case comparison.Change <= -1:
+			summary += fmt.Sprintf(" That's down from %.1f%% last %s.", comparison.PreviousCompletion, period)
+		}
+	}
+
+	return &models.ReportContent{
+		Summary:             summary + " Keep up the great work building consistent routines!",
+		Improvements:        improvements,
+		SkillsLearned:       skillsLearned,
+		AreasToImprove:      areasToImprove,
+		RevisionSuggestions: revisionSuggestions,
+		MotivationalNote:    "Every day you show up is a win. Keep building those positive habits!",
+	}
+}
+
+// WriteYearNarrative writes a year narrative from templates
+func (g *MockReportGenerator) WriteYearNarrative(ctx context.Context, userID uuid.UUID, content *models.YearReviewContent) (*models.YearNarrativeGeneration, error) {
+	return &models.YearNarrativeGeneration{
+		Text:   mockYearNarrative(content),
+		Source: models.ReportSourceMock,
+		Model:  g.Name(),
+	}, nil
+}
+
+// mockYearNarrative strings together the highlights of a year review
+func mockYearNarrative(content *models.YearReviewContent) string {
+	var sentences []string
+	if content.MonthsReported > 0 {
+		sentences = append(sentences, fmt.Sprintf("In %d you tracked %s across %s, completing %.0f%% of them on average.",
+			content.Year, countOf(len(content.Habits), "habit"), countOf(content.MonthsReported, "month"), content.OverallCompletion))
+	} else {
+		sentences = append(sentences, fmt.Sprintf("In %d you tracked %s.", content.Year, countOf(len(content.Habits), "habit")))
+	}
+
+	var best *models.YearReviewHabit
+	for _, habit := range content.Habits {
+		if best == nil || habit.LongestStreak > best.LongestStreak {
+			best = habit
+		}
+	}
+	if best != nil && best.LongestStreak > 0 {
+		sentence := fmt.Sprintf("Your longest streak was %d in a row with %s", best.LongestStreak, best.Title)
+		if best.BestMonth != nil {
+			sentence += fmt.Sprintf(", which peaked in %s", monthName(best.BestMonth.Month))
+		}
+		sentences = append(sentences, sentence+".")
+	}
+
+	if content.TotalSkillsLearned > 0 {
+		sentences = append(sentences, fmt.Sprintf("You learned %s along the way.", countOf(content.TotalSkillsLearned, "new skill")))
+	}
+	if len(content.HabitsStarted) > 0 || len(content.HabitsAbandoned) > 0 {
+		sentences = append(sentences, fmt.Sprintf("You started %s and let go of %d, shaping a routine that fits you.",
+			countOf(len(content.HabitsStarted), "habit"), len(content.HabitsAbandoned)))
+	}
+
+	sentences = append(sentences, "Every day you showed up counts. Here's to an even better next year!")
+	return strings.Join(sentences, " ")
+}
+
+// countOf formats a count of a noun, e.g. "1 habit" or "3 habits"
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
+
+// monthName returns the name of a YYYY-MM month, or the month as given
+// when it does not parse
+func monthName(month string) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month
+	}
+	return t.Month().String()
+}
+
+// formatQuantity formats a value with its unit, e.g. "12.5 km"
+func formatQuantity(value float64, unit *string) string {
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	if value != float64(int64(value)) {
+		formatted = strconv.FormatFloat(value, 'f', 1, 64)
+	}
+	if unit != nil && *unit != "" {
+		return formatted + " " + *unit
+	}
+	return formatted
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+const (
+	// maxNoteSearchLength bounds a learning note search query, in characters
+	maxNoteSearchLength = 200
+	// maxNoteSearchPageSize caps how many notes one search returns
+	maxNoteSearchPageSize = 50
+)
+
+var (
+	ErrNoteSearchEmpty   = errors.New("a search query or tag is required")
+	ErrInvalidNoteSearch = errors.New("invalid note search filters")
+)
+
+// noteHighlighter turns the match markers of an escaped headline into
+// <mark> tags
+var noteHighlighter = strings.NewReplacer(
+	repository.NoteHighlightStart, "<mark>",
+	repository.NoteHighlightStop, "</mark>",
+)
+
+// NoteService handles searching learning notes
+type NoteService struct {
+	noteRepo *repository.NoteRepository
+}
+
+// NewNoteService creates a new NoteService
+func NewNoteService(noteRepo *repository.NoteRepository) *NoteService {
+	return &NoteService{
+		noteRepo: noteRepo,
+	}
+}
+
+// Search finds a user's learning notes matching a request and returns a
+// page of them with their matches highlighted
+func (s *NoteService) Search(ctx context.Context, userID uuid.UUID, req *models.NoteSearchRequest) (*models.NoteSearchResponse, error) {
+	filter, err := noteSearchFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	results, total, err := s.noteRepo.Search(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		result.Highlight = noteHighlighter.Replace(html.EscapeString(result.Highlight))
+		if result.Tags == nil {
+			result.Tags = []string{}
+		}
+	}
+	if results == nil {
+		results = []*models.NoteSearchResult{}
+	}
+
+	return &models.NoteSearchResponse{
+		Results:    results,
+		TotalCount: total,
+	}, nil
+}
+
+// GetTags retrieves the tags in a user's learning notes
+func (s *NoteService) GetTags(ctx context.Context, userID uuid.UUID) ([]*models.NoteTag, error) {
+	tags, err := s.noteRepo.GetTags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = []*models.NoteTag{}
+	}
+	return tags, nil
+}
+
+// noteSearchFilter validates a search request and parses its filters
+func noteSearchFilter(req *models.NoteSearchRequest) (*models.NoteSearchFilter, error) {
+	filter := &models.NoteSearchFilter{
+		Query:  strings.TrimSpace(req.Query),
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+
+	if req.Tag != "" {
+		filter.Tag = models.NormalizeNoteTag(strings.TrimSpace(req.Tag))
+		if filter.Tag == "" {
+			return nil, ErrInvalidNoteSearch
+		}
+	}
+	if filter.Query == "" && filter.Tag == "" {
+		return nil, ErrNoteSearchEmpty
+	}
+	if utf8.RuneCountInString(filter.Query) > maxNoteSearchLength {
+		return nil, ErrInvalidNoteSearch
+	}
+
+	if req.HabitID != "" {
+		habitID, err := uuid.Parse(req.HabitID)
+		if err != nil {
+			return nil, ErrInvalidNoteSearch
+		}
+		filter.HabitID = &habitID
+	}
+
+	if req.From != "" {
+		from, err := time.Parse(models.DateLayout, req.From)
+		if err != nil {
+			return nil, ErrInvalidNoteSearch
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := time.Parse(models.DateLayout, req.To)
+		if err != nil {
+			return nil, ErrInvalidNoteSearch
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		return nil, ErrInvalidNoteSearch
+	}
+
+	if filter.Limit <= 0 || filter.Limit > maxNoteSearchPageSize {
+		filter.Limit = maxNoteSearchPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return filter, nil
+}
@@ -2,9 +2,9 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,11 +15,17 @@ import (
 
 // NotificationService handles push notifications
 type NotificationService struct {
-	userRepo   *repository.UserRepository
-	habitRepo  *repository.HabitRepository
-	streakRepo *repository.StreakRepository
-	config     *config.Config
-	httpClient *http.Client
+	userRepo    *repository.UserRepository
+	habitRepo   *repository.HabitRepository
+	streakRepo  *repository.StreakRepository
+	freezeRepo  *repository.FreezeRepository
+	deviceRepo  *repository.DeviceRepository
+	outboxRepo  *repository.NotificationRepository
+	prefRepo    *repository.NotificationPreferenceRepository
+	habitSvc    *HabitService
+	dayResolver *DayResolver
+	config      *config.Config
+	fcm         *FCMClient
 }
 
 // NewNotificationService creates a new NotificationService
@@ -27,35 +33,58 @@ func NewNotificationService(
 	userRepo *repository.UserRepository,
 	habitRepo *repository.HabitRepository,
 	streakRepo *repository.StreakRepository,
+	freezeRepo *repository.FreezeRepository,
+	deviceRepo *repository.DeviceRepository,
+	outboxRepo *repository.NotificationRepository,
+	prefRepo *repository.NotificationPreferenceRepository,
+	habitSvc *HabitService,
+	dayResolver *DayResolver,
+	fcm *FCMClient,
 	cfg *config.Config,
 ) *NotificationService {
 	return &NotificationService{
-		userRepo:   userRepo,
-		habitRepo:  habitRepo,
-		streakRepo: streakRepo,
-		config:     cfg,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		userRepo:    userRepo,
+		habitRepo:   habitRepo,
+		streakRepo:  streakRepo,
+		freezeRepo:  freezeRepo,
+		deviceRepo:  deviceRepo,
+		outboxRepo:  outboxRepo,
+		prefRepo:    prefRepo,
+		habitSvc:    habitSvc,
+		dayResolver: dayResolver,
+		config:      cfg,
+		fcm:         fcm,
 	}
 }
 
-// NotificationType represents the type of notification
-type NotificationType string
-
 const (
-	NotificationTypeMorningReminder NotificationType = "morning_reminder"
-	NotificationTypeEveningReminder NotificationType = "evening_reminder"
-	NotificationTypeStreakAlert     NotificationType = "streak_alert"
-	NotificationTypeReportReady     NotificationType = "report_ready"
-	NotificationTypeRevisionReminder NotificationType = "revision_reminder"
+	// notificationBatchSize is how many outbox entries a worker claims at once
+	notificationBatchSize = 100
+	// notificationMaxAttempts bounds push delivery attempts per notification
+	notificationMaxAttempts = 5
+	// notificationRetryBackoff is the delay before the first retry, doubled
+	// after each further failure
+	notificationRetryBackoff = time.Minute
+	// notificationMaxAge is how late a push is still worth delivering; older
+	// notifications stay in the inbox only
+	notificationMaxAge = 24 * time.Hour
 )
 
-// FCMMessage represents an FCM message
+var (
+	ErrNoDevices  = errors.New("user has no registered push devices")
+	ErrQuietHours = errors.New("within the user's quiet hours")
+	// ErrPushDisabled is recorded on notifications whose type the user only
+	// wants on other channels
+	ErrPushDisabled = errors.New("push disabled for this notification type")
+)
+
+// FCMMessage represents an FCM HTTP v1 message
 type FCMMessage struct {
-	To           string            `json:"to"`
-	Notification FCMNotification   `json:"notification"`
+	Token        string            `json:"token"`
+	Notification *FCMNotification  `json:"notification,omitempty"`
 	Data         map[string]string `json:"data,omitempty"`
+	Android      *FCMAndroidConfig `json:"android,omitempty"`
+	APNS         *FCMAPNSConfig    `json:"apns,omitempty"`
 }
 
 // FCMNotification represents the notification payload
@@ -64,54 +93,385 @@ type FCMNotification struct {
 	Body  string `json:"body"`
 }
 
-// SendNotification sends a push notification to a user
-func (s *NotificationService) SendNotification(ctx context.Context, userID uuid.UUID, notificationType NotificationType, title, body string, data map[string]string) error {
+// FCMAndroidConfig holds Android specific delivery options
+type FCMAndroidConfig struct {
+	Priority     string                  `json:"priority,omitempty"` // normal or high
+	TTL          string                  `json:"ttl,omitempty"`      // e.g. "3600s"
+	CollapseKey  string                  `json:"collapse_key,omitempty"`
+	Notification *FCMAndroidNotification `json:"notification,omitempty"`
+}
+
+// FCMAndroidNotification overrides the notification on Android
+type FCMAndroidNotification struct {
+	ChannelID string `json:"channel_id,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+	Sound     string `json:"sound,omitempty"`
+}
+
+// FCMAPNSConfig holds APNs specific delivery options; Payload carries the
+// aps dictionary
+type FCMAPNSConfig struct {
+	Headers map[string]string      `json:"headers,omitempty"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// SendNotification queues a notification for immediate delivery on the
+// channels the user chose for its type. It lands in the user's inbox, if
+// wanted there, whether or not a push can be delivered.
+func (s *NotificationService) SendNotification(ctx context.Context, userID uuid.UUID, notificationType models.NotificationType, title, body string, data map[string]string) error {
+	return s.ScheduleNotification(ctx, userID, notificationType, title, body, data, time.Now())
+}
+
+// ScheduleNotification writes a notification to the outbox, to be delivered
+// and shown in the inbox from scheduledFor on
+func (s *NotificationService) ScheduleNotification(ctx context.Context, userID uuid.UUID, notificationType models.NotificationType, title, body string, data map[string]string, scheduledFor time.Time) error {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
-	if user.FCMToken == nil || *user.FCMToken == "" {
-		return fmt.Errorf("user has no FCM token")
+	return s.enqueue(ctx, user, notificationType, "", title, body, data, scheduledFor)
+}
+
+// enqueue writes a notification to the outbox, enforcing the user's
+// preference for its type. Muted types and notifications beyond the type's
+// daily cap are dropped. One that falls in quiet hours is held until they
+// end, unless it is time-sensitive, in which case it is not pushed. A
+// non-empty occurrence key is queued at most once per user.
+func (s *NotificationService) enqueue(ctx context.Context, user *models.User, notificationType models.NotificationType, occurrence, title, body string, data map[string]string, scheduledFor time.Time) error {
+	preference, err := s.preference(ctx, user, notificationType)
+	if err != nil {
+		return err
+	}
+	if preference.Muted() {
+		return nil
+	}
+
+	loc := s.dayResolver.Location(user.Timezone)
+
+	if preference.MaxPerDay != nil {
+		dayStart := models.AtClock(scheduledFor.In(loc), time.Time{}, loc)
+		count, err := s.outboxRepo.CountByTypeSince(ctx, user.ID, notificationType, dayStart)
+		if err != nil {
+			return err
+		}
+		if count >= *preference.MaxPerDay {
+			return nil
+		}
+	}
+
+	notification := &models.Notification{
+		UserID:       user.ID,
+		Type:         notificationType,
+		Title:        title,
+		Body:         body,
+		Data:         data,
+		ScheduledFor: scheduledFor,
+		Push:         preference.Push,
+		Email:        preference.Email,
+		InApp:        preference.InApp,
+	}
+	if occurrence != "" {
+		notification.OccurrenceKey = &occurrence
+	}
+
+	if !preference.Push {
+		s.finishDelivery(notification, models.NotificationSkipped, ErrPushDisabled)
+	} else if end, quiet := s.quietHoursAt(user, preference, scheduledFor, loc); quiet {
+		if notificationType.TimeSensitive() {
+			s.finishDelivery(notification, models.NotificationSkipped, ErrQuietHours)
+		} else {
+			notification.ScheduledFor = end
+		}
+	}
+
+	return s.outboxRepo.Create(ctx, notification)
+}
+
+// preference returns the user's preference for a notification type, or the
+// default one if they never set it. Turning notifications off in the user's
+// settings turns push off for every type; the inbox and email still follow
+// the preference.
+func (s *NotificationService) preference(ctx context.Context, user *models.User, notificationType models.NotificationType) (*models.NotificationPreference, error) {
+	preference, err := s.prefRepo.GetByUserAndType(ctx, user.ID, notificationType)
+	if err == repository.ErrNotificationPreferenceNotFound {
+		preference, err = models.DefaultNotificationPreference(user.ID, notificationType), nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	if !user.NotificationEnabled {
-		return nil // Notifications disabled, skip
+		preference.Push = false
 	}
+	return preference, nil
+}
 
-	return s.sendFCMNotification(*user.FCMToken, title, body, data)
+// quietHoursAt applies the type's own quiet hours, or the user's when the
+// type has none
+func (s *NotificationService) quietHoursAt(user *models.User, preference *models.NotificationPreference, t time.Time, loc *time.Location) (time.Time, bool) {
+	if preference.HasQuietHours() {
+		return preference.QuietHoursAt(t, loc)
+	}
+	return user.QuietHoursAt(t, loc)
 }
 
-// sendFCMNotification sends a notification via FCM
-func (s *NotificationService) sendFCMNotification(token, title, body string, data map[string]string) error {
-	// For development/testing, implement actual FCM sending in production
+// DeliverPending pushes the due notifications in the outbox and returns how
+// many reached a device. Failed pushes are retried with exponential backoff.
+func (s *NotificationService) DeliverPending(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		notifications, err := s.outboxRepo.ClaimDue(ctx, notificationBatchSize)
+		if err != nil {
+			return delivered, err
+		}
+
+		for _, notification := range notifications {
+			s.deliver(ctx, notification)
+			if notification.Status == models.NotificationSent {
+				delivered++
+			}
+
+			if err := s.outboxRepo.UpdateDelivery(context.WithoutCancel(ctx), notification); err != nil {
+				log.Printf("failed to record delivery of notification %s: %v", notification.ID, err)
+			}
+		}
+
+		if len(notifications) < notificationBatchSize || ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+	}
+}
+
+// deliver makes one push attempt for a claimed notification and sets its
+// resulting status
+func (s *NotificationService) deliver(ctx context.Context, notification *models.Notification) {
+	notification.NextAttemptAt = nil
+
+	if time.Since(notification.ScheduledFor) > notificationMaxAge {
+		s.finishDelivery(notification, models.NotificationFailed, errors.New("expired before it could be delivered"))
+		return
+	}
+
+	user, err := s.userRepo.GetByID(ctx, notification.UserID)
+	if err == repository.ErrUserNotFound {
+		s.finishDelivery(notification, models.NotificationSkipped, err)
+		return
+	}
+	if err != nil {
+		s.retryDelivery(notification, err)
+		return
+	}
+
+	preference, err := s.preference(ctx, user, notification.Type)
+	if err != nil {
+		s.retryDelivery(notification, err)
+		return
+	}
+	if !preference.Push {
+		s.finishDelivery(notification, models.NotificationSkipped, ErrPushDisabled)
+		return
+	}
+
+	// A retry or a change of settings can land a push in quiet hours
+	if end, quiet := s.quietHoursAt(user, preference, time.Now(), s.dayResolver.Location(user.Timezone)); quiet {
+		if notification.Type.TimeSensitive() {
+			s.finishDelivery(notification, models.NotificationSkipped, ErrQuietHours)
+			return
+		}
+		notification.NextAttemptAt = &end
+		s.finishDelivery(notification, models.NotificationPending, ErrQuietHours)
+		return
+	}
+
+	err = s.push(ctx, user.ID, notification)
+	switch {
+	case err == nil:
+		now := time.Now()
+		notification.SentAt = &now
+		s.finishDelivery(notification, models.NotificationSent, nil)
+	case errors.Is(err, ErrNoDevices):
+		s.finishDelivery(notification, models.NotificationSkipped, err)
+	default:
+		s.retryDelivery(notification, err)
+	}
+}
+
+// retryDelivery puts a notification back in the outbox after a failed
+// attempt, or gives up once it ran out of attempts
+func (s *NotificationService) retryDelivery(notification *models.Notification, err error) {
+	if notification.Attempts >= notificationMaxAttempts {
+		s.finishDelivery(notification, models.NotificationFailed, err)
+		return
+	}
+
+	next := time.Now().Add(notificationRetryBackoff << (notification.Attempts - 1))
+	notification.NextAttemptAt = &next
+	s.finishDelivery(notification, models.NotificationPending, err)
+}
+
+// finishDelivery sets a notification's status and last error
+func (s *NotificationService) finishDelivery(notification *models.Notification, status models.NotificationStatus, err error) {
+	notification.Status = status
+	notification.LastError = nil
+	if err != nil {
+		message := err.Error()
+		notification.LastError = &message
+	}
+}
+
+// push sends a notification to all of a user's devices. Tokens FCM reports
+// as dead are pruned. It fails only if no device could be reached.
+func (s *NotificationService) push(ctx context.Context, userID uuid.UUID, notification *models.Notification) error {
+	devices, err := s.deviceRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if len(devices) == 0 {
+		return ErrNoDevices
+	}
+
+	data := make(map[string]string, len(notification.Data)+1)
+	for key, value := range notification.Data {
+		data[key] = value
+	}
+	data["notification_id"] = notification.ID.String()
+
+	var lastErr error
+	delivered, dead := 0, 0
+	for _, device := range devices {
+		err := s.sendFCMNotification(ctx, newFCMMessage(device.FCMToken, notification.Type, notification.Title, notification.Body, data))
+		if err == nil {
+			delivered++
+			continue
+		}
+
+		if errors.Is(err, ErrFCMTokenInvalid) {
+			// The app was uninstalled or the token rotated; stop sending to it
+			if err := s.deviceRepo.DeleteByToken(ctx, device.FCMToken); err != nil {
+				log.Printf("failed to prune device %s of user %s: %v", device.ID, userID, err)
+			}
+			dead++
+		}
+		lastErr = err
+	}
+
+	// Once every token turned out dead there is nothing left to retry
+	if dead == len(devices) {
+		return ErrNoDevices
+	}
+	if delivered == 0 {
+		return lastErr
+	}
+
 	return nil
 }
 
-// SendMorningReminder sends morning reminder notifications
-func (s *NotificationService) SendMorningReminder(ctx context.Context, user *models.User) error {
+// sendFCMNotification sends a notification via FCM. Without service account
+// credentials the message is only logged.
+func (s *NotificationService) sendFCMNotification(ctx context.Context, message *FCMMessage) error {
+	if s.fcm == nil {
+		log.Printf("FCM not configured, skipping %s notification", message.Data["type"])
+		return nil
+	}
+
+	_, err := s.fcm.Send(ctx, message)
+	return err
+}
+
+// newFCMMessage builds a message with per-platform overrides: streak alerts
+// go out with high priority, and each type has its own Android channel and
+// iOS thread so the OS groups them
+func newFCMMessage(token string, notificationType models.NotificationType, title, body string, data map[string]string) *FCMMessage {
+	priority, apnsPriority := "normal", "5"
+	if notificationType == models.NotificationTypeStreakAlert {
+		priority, apnsPriority = "high", "10"
+	}
+
+	channel := "reminders"
+	switch notificationType {
+	case models.NotificationTypeStreakAlert:
+		channel = "streaks"
+	case models.NotificationTypeReportReady, models.NotificationTypeRevisionReminder, models.NotificationTypeWeeklyDigest:
+		channel = "insights"
+	}
+
+	return &FCMMessage{
+		Token:        token,
+		Notification: &FCMNotification{Title: title, Body: body},
+		Data:         data,
+		Android: &FCMAndroidConfig{
+			Priority:    priority,
+			TTL:         "86400s",
+			CollapseKey: string(notificationType),
+			Notification: &FCMAndroidNotification{
+				ChannelID: channel,
+				Tag:       string(notificationType),
+				Sound:     "default",
+			},
+		},
+		APNS: &FCMAPNSConfig{
+			Headers: map[string]string{
+				"apns-priority":    apnsPriority,
+				"apns-collapse-id": string(notificationType),
+			},
+			Payload: map[string]interface{}{
+				"aps": map[string]interface{}{
+					"sound":     "default",
+					"thread-id": string(notificationType),
+				},
+			},
+		},
+	}
+}
+
+// SendMorningReminder sends the morning reminder of a date. Habits nudged
+// at a time learned from their history are left out, as the nudge already
+// reminds the user of them; no reminder is sent if that leaves none.
+func (s *NotificationService) SendMorningReminder(ctx context.Context, user *models.User, date time.Time) error {
 	habits, err := s.habitRepo.GetActiveByUserID(ctx, user.ID)
 	if err != nil {
 		return err
 	}
 
-	if len(habits) == 0 {
+	withReminders, err := s.habitRepo.GetWithReminders(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	nudged := make(map[uuid.UUID]bool)
+	for _, habit := range withReminders {
+		if habit.ReminderTime == nil && habit.LearnedReminderTime != nil {
+			nudged[habit.ID] = true
+		}
+	}
+
+	count := 0
+	for _, habit := range habits {
+		if !nudged[habit.ID] {
+			count++
+		}
+	}
+
+	if count == 0 {
 		return nil
 	}
 
 	title := "Good morning! ☀️"
-	body := fmt.Sprintf("Ready to crush your %d habits today?", len(habits))
+	body := fmt.Sprintf("Ready to crush your %d habits today?", count)
 
 	data := map[string]string{
-		"type":   string(NotificationTypeMorningReminder),
+		"type":   string(models.NotificationTypeMorningReminder),
 		"screen": "home",
 	}
 
-	return s.SendNotification(ctx, user.ID, NotificationTypeMorningReminder, title, body, data)
+	occurrence := models.NotificationOccurrence(models.NotificationTypeMorningReminder, date.Format(models.DateLayout))
+	return s.enqueue(ctx, user, models.NotificationTypeMorningReminder, occurrence, title, body, data, time.Now())
 }
 
-// SendEveningReminder sends evening reminder notifications
-func (s *NotificationService) SendEveningReminder(ctx context.Context, user *models.User, incompleteCount int) error {
+// SendEveningReminder sends the evening reminder of a date
+func (s *NotificationService) SendEveningReminder(ctx context.Context, user *models.User, incompleteCount int, date time.Time) error {
 	if incompleteCount == 0 {
 		return nil
 	}
@@ -120,24 +480,67 @@ func (s *NotificationService) SendEveningReminder(ctx context.Context, user *mod
 	body := fmt.Sprintf("You have %d habits left to complete today.", incompleteCount)
 
 	data := map[string]string{
-		"type":   string(NotificationTypeEveningReminder),
+		"type":   string(models.NotificationTypeEveningReminder),
 		"screen": "home",
 	}
 
-	return s.SendNotification(ctx, user.ID, NotificationTypeEveningReminder, title, body, data)
+	occurrence := models.NotificationOccurrence(models.NotificationTypeEveningReminder, date.Format(models.DateLayout))
+	return s.enqueue(ctx, user, models.NotificationTypeEveningReminder, occurrence, title, body, data, time.Now())
+}
+
+// SendHabitReminders sends the reminders of the given habits that are due
+// today and not yet completed. reminders maps each habit to the occurrence
+// of its reminder, its date or the time a snooze ended. It returns the
+// number of reminders sent.
+func (s *NotificationService) SendHabitReminders(ctx context.Context, user *models.User, reminders map[uuid.UUID]string) (int, error) {
+	status, err := s.habitSvc.GetTodayHabitsStatus(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, habit := range status.Habits {
+		occurrence, ok := reminders[habit.HabitID]
+		if !ok || !habit.IsDue || habit.Completed {
+			continue
+		}
+
+		if err := s.SendHabitReminder(ctx, user, habit.HabitID, habit.HabitTitle, occurrence); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
 }
 
-// SendStreakAtRiskAlert sends streak at risk notifications
-func (s *NotificationService) SendStreakAtRiskAlert(ctx context.Context, user *models.User, habitTitle string, streak int) error {
+// SendHabitReminder sends one occurrence of a habit's reminder
+func (s *NotificationService) SendHabitReminder(ctx context.Context, user *models.User, habitID uuid.UUID, habitTitle, occurrence string) error {
+	title := "Habit reminder ⏰"
+	body := fmt.Sprintf("It's time for '%s'.", habitTitle)
+
+	data := map[string]string{
+		"type":     string(models.NotificationTypeHabitReminder),
+		"screen":   "habit",
+		"habit_id": habitID.String(),
+	}
+
+	key := models.NotificationOccurrence(models.NotificationTypeHabitReminder, habitID.String(), occurrence)
+	return s.enqueue(ctx, user, models.NotificationTypeHabitReminder, key, title, body, data, time.Now())
+}
+
+// SendStreakAtRiskAlert sends a habit's streak at risk alert of a date
+func (s *NotificationService) SendStreakAtRiskAlert(ctx context.Context, user *models.User, habitID uuid.UUID, habitTitle string, streak int, date time.Time) error {
 	title := "Streak at risk! 🔥"
 	body := fmt.Sprintf("Your %d-day streak for '%s' is at risk! Complete it now.", streak, habitTitle)
 
 	data := map[string]string{
-		"type":   string(NotificationTypeStreakAlert),
+		"type":   string(models.NotificationTypeStreakAlert),
 		"screen": "home",
 	}
 
-	return s.SendNotification(ctx, user.ID, NotificationTypeStreakAlert, title, body, data)
+	occurrence := models.NotificationOccurrence(models.NotificationTypeStreakAlert, habitID.String(), date.Format(models.DateLayout))
+	return s.enqueue(ctx, user, models.NotificationTypeStreakAlert, occurrence, title, body, data, time.Now())
 }
 
 // SendReportReadyNotification sends report ready notifications
@@ -146,12 +549,13 @@ func (s *NotificationService) SendReportReadyNotification(ctx context.Context, u
 	body := fmt.Sprintf("Your %s progress report is ready. See your achievements!", month)
 
 	data := map[string]string{
-		"type":   string(NotificationTypeReportReady),
+		"type":   string(models.NotificationTypeReportReady),
 		"screen": "reports",
 		"month":  month,
 	}
 
-	return s.SendNotification(ctx, user.ID, NotificationTypeReportReady, title, body, data)
+	occurrence := models.NotificationOccurrence(models.NotificationTypeReportReady, month)
+	return s.enqueue(ctx, user, models.NotificationTypeReportReady, occurrence, title, body, data, time.Now())
 }
 
 // SendRevisionReminderNotification sends revision reminder notifications
@@ -160,49 +564,82 @@ func (s *NotificationService) SendRevisionReminderNotification(ctx context.Conte
 	body := fmt.Sprintf("AI suggests revising '%s' for %d days. Ready to refresh?", skill, days)
 
 	data := map[string]string{
-		"type":   string(NotificationTypeRevisionReminder),
+		"type":   string(models.NotificationTypeRevisionReminder),
 		"screen": "revisions",
 	}
 
-	return s.SendNotification(ctx, user.ID, NotificationTypeRevisionReminder, title, body, data)
+	return s.enqueue(ctx, user, models.NotificationTypeRevisionReminder, "", title, body, data, time.Now())
 }
 
-// CheckAndSendStreakAlerts checks for at-risk streaks and sends alerts
-func (s *NotificationService) CheckAndSendStreakAlerts(ctx context.Context) error {
-	// This would be called by a cron job
-	// Implementation would iterate through users and check their streaks
-	return nil
+// SendWeeklyDigest queues the weekly digest of a finished week. Its email
+// renders the full digest; the inbox and any push show a summary.
+func (s *NotificationService) SendWeeklyDigest(ctx context.Context, user *models.User, digest *models.WeeklyDigest) error {
+	title := "Your weekly digest 📬"
+	body := fmt.Sprintf("Last week you completed %d%% of your habits and earned %d XP.", digest.CompletionPercent(), digest.XPEarned)
+
+	data := map[string]string{
+		"type":       string(models.NotificationTypeWeeklyDigest),
+		"screen":     "home",
+		"week_start": digest.WeekStart.Format(models.DateLayout),
+	}
+
+	occurrence := models.NotificationOccurrence(models.NotificationTypeWeeklyDigest, data["week_start"])
+	return s.enqueue(ctx, user, models.NotificationTypeWeeklyDigest, occurrence, title, body, data, time.Now())
 }
 
-// ScheduleNotification schedules a notification for later
-type ScheduledNotification struct {
-	UserID           uuid.UUID
-	NotificationType NotificationType
-	Title            string
-	Body             string
-	Data             map[string]string
-	ScheduledFor     time.Time
-}
-
-// ScheduleNotificationPayload is used for serialization
-type ScheduleNotificationPayload struct {
-	UserID           string            `json:"user_id"`
-	NotificationType string            `json:"notification_type"`
-	Title            string            `json:"title"`
-	Body             string            `json:"body"`
-	Data             map[string]string `json:"data"`
-	ScheduledFor     string            `json:"scheduled_for"`
-}
-
-// ToJSON converts the scheduled notification to JSON
-func (sn *ScheduledNotification) ToJSON() ([]byte, error) {
-	payload := ScheduleNotificationPayload{
-		UserID:           sn.UserID.String(),
-		NotificationType: string(sn.NotificationType),
-		Title:            sn.Title,
-		Body:             sn.Body,
-		Data:             sn.Data,
-		ScheduledFor:     sn.ScheduledFor.Format(time.RFC3339),
-	}
-	return json.Marshal(payload)
+// CountIncompleteToday returns how many of the user's habits are due today
+// and not yet completed
+func (s *NotificationService) CountIncompleteToday(ctx context.Context, user *models.User) (int, error) {
+	status, err := s.habitSvc.GetTodayHabitsStatus(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, habit := range status.Habits {
+		if habit.IsDue && !habit.Completed {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// CheckAndSendStreakAlerts alerts the user about habits with a running streak
+// that are due today and not yet completed. Habits frozen for today are not
+// at risk. It returns the number of alerts sent.
+func (s *NotificationService) CheckAndSendStreakAlerts(ctx context.Context, user *models.User) (int, error) {
+	status, err := s.habitSvc.GetTodayHabitsStatus(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	today, err := time.Parse(models.DateLayout, status.Date)
+	if err != nil {
+		return 0, err
+	}
+
+	freezes, err := s.freezeRepo.GetByUserAndDateRange(ctx, user.ID, today, today)
+	if err != nil {
+		return 0, err
+	}
+
+	frozen := make(map[uuid.UUID]bool)
+	for _, freeze := range freezes {
+		frozen[freeze.HabitID] = true
+	}
+
+	sent := 0
+	for _, habit := range status.Habits {
+		if !habit.IsDue || habit.Completed || habit.CurrentStreak == 0 || frozen[habit.HabitID] {
+			continue
+		}
+
+		if err := s.SendStreakAtRiskAlert(ctx, user, habit.HabitID, habit.HabitTitle, habit.CurrentStreak, today); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
 }
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient calls an OpenAI-compatible chat completions API. Besides
+// OpenAI itself this covers local servers such as Ollama
+// (http://localhost:11434/v1) and llama.cpp, which need no API key.
+type OpenAIClient struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAIClient creates a new OpenAIClient for the API at baseURL, e.g.
+// https://api.openai.com/v1
+func NewOpenAIClient(baseURL, apiKey, model string, timeout time.Duration) *OpenAIClient {
+	return &OpenAIClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// OpenAIChatRequest represents a chat completions request
+type OpenAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []OpenAIChatMessage   `json:"messages"`
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// OpenAIResponseFormat constrains the reply to JSON matching a schema
+type OpenAIResponseFormat struct {
+	Type       string            `json:"type"` // json_schema
+	JSONSchema *OpenAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+// OpenAIJSONSchema names the schema of a structured output
+type OpenAIJSONSchema struct {
+	Name   string      `json:"name"`
+	Schema *JSONSchema `json:"schema"`
+	Strict bool        `json:"strict"`
+}
+
+// OpenAIChatMessage is one message of a chat
+type OpenAIChatMessage struct {
+	Role    string `json:"role"` // system, user or assistant
+	Content string `json:"content"`
+}
+
+// OpenAIChatResponse represents a chat completions response
+type OpenAIChatResponse struct {
+	Choices []struct {
+		Message OpenAIChatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Name returns the provider name
+func (c *OpenAIClient) Name() string {
+	return "openai"
+}
+
+// Model returns the model name
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
+
+// Complete sends the conversation and returns the reply
+func (c *OpenAIClient) Complete(ctx context.Context, messages []LLMMessage, schema *JSONSchema) (*LLMCompletion, error) {
+	reqBody := OpenAIChatRequest{
+		Model: c.model,
+	}
+	for _, message := range messages {
+		reqBody.Messages = append(reqBody.Messages, OpenAIChatMessage{Role: message.Role, Content: message.Content})
+	}
+	if schema != nil {
+		reqBody.ResponseFormat = &OpenAIResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &OpenAIJSONSchema{
+				Name:   "habit_report",
+				Schema: schema,
+				Strict: true,
+			},
+		}
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("chat completions API error (%d): %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp OpenAIChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, err
+	}
+
+	if len(chatResp.Choices) == 0 || chatResp.Choices[0].Message.Content == "" {
+		return nil, errors.New("empty response from chat completions API")
+	}
+
+	return &LLMCompletion{
+		Text:           chatResp.Choices[0].Message.Content,
+		PromptTokens:   chatResp.Usage.PromptTokens,
+		ResponseTokens: chatResp.Usage.CompletionTokens,
+	}, nil
+}
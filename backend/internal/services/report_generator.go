@@ -0,0 +1,510 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/config"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// reportMaxAttempts is how many times a model is asked for a report: the
+// first request plus one repair round-trip with the validation errors
+const reportMaxAttempts = 2
+
+// ReportGenerator writes the AI content of a report
+type ReportGenerator interface {
+	// Name identifies the provider, e.g. in logs
+	Name() string
+	GenerateReport(ctx context.Context, input *models.ReportGenerationInput) (*models.ReportGeneration, error)
+	// WriteYearNarrative writes the narrative of a user's year in review
+	WriteYearNarrative(ctx context.Context, userID uuid.UUID, content *models.YearReviewContent) (*models.YearNarrativeGeneration, error)
+}
+
+// LLMMessage is one turn of a conversation with a language model
+type LLMMessage struct {
+	Role    string // user or assistant
+	Content string
+}
+
+// LLMCompletion is a language model's reply with the tokens it used
+type LLMCompletion struct {
+	Text           string
+	PromptTokens   int
+	ResponseTokens int
+}
+
+// LLMClient sends a conversation to a language model and returns its reply.
+// When schema is set the model is constrained to JSON matching it.
+type LLMClient interface {
+	Name() string
+	Model() string
+	Complete(ctx context.Context, messages []LLMMessage, schema *JSONSchema) (*LLMCompletion, error)
+}
+
+// JSONSchema is the subset of JSON Schema used to constrain model output
+type JSONSchema struct {
+	Type                 string                 `json:"type"`
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Maximum              *int                   `json:"maximum,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+// NewReportGenerator returns the report generator of the configured provider.
+// Model-written reports are cached in Redis when a client is given.
+func NewReportGenerator(cfg *config.Config, redisClient *redis.Client) ReportGenerator {
+	var client LLMClient
+	switch cfg.LLMProvider {
+	case config.LLMProviderGemini:
+		client = NewGeminiClient(cfg.GeminiAPIKey, cfg.GeminiModel, cfg.GeminiTimeout)
+	case config.LLMProviderOpenAI:
+		client = NewOpenAIClient(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.OpenAIModel, cfg.OpenAITimeout)
+	default:
+		return NewMockReportGenerator()
+	}
+
+	generator := NewLLMReportGenerator(client)
+	if redisClient == nil || cfg.ReportCacheTTL <= 0 {
+		return generator
+	}
+	return NewCachedReportGenerator(generator, client.Model(), redisClient, cfg.ReportCacheTTL)
+}
+
+// LLMReportGenerator generates reports by prompting a language model for
+// JSON matching the report schema. Content that fails validation is sent
+// back once with the problems found. When the model fails or never produces
+// valid content, the deterministic mock report is served instead and
+// recorded as a fallback.
+type LLMReportGenerator struct {
+	client   LLMClient
+	fallback *MockReportGenerator
+}
+
+// NewLLMReportGenerator creates a new LLMReportGenerator
+func NewLLMReportGenerator(client LLMClient) *LLMReportGenerator {
+	return &LLMReportGenerator{
+		client:   client,
+		fallback: NewMockReportGenerator(),
+	}
+}
+
+// Name returns the name of the underlying model provider
+func (g *LLMReportGenerator) Name() string {
+	return g.client.Name()
+}
+
+// GenerateReport generates an AI report
+func (g *LLMReportGenerator) GenerateReport(ctx context.Context, input *models.ReportGenerationInput) (*models.ReportGeneration, error) {
+	messages := []LLMMessage{
+		{Role: "user", Content: buildReportPrompt(input)},
+	}
+
+	var calls []*models.LLMCall
+	var lastErr error
+	attempts := 0
+	for attempts < reportMaxAttempts {
+		attempts++
+
+		started := time.Now()
+		completion, err := g.client.Complete(ctx, messages, reportContentSchema)
+		calls = append(calls, g.newCall(input.UserID, input.Period.Type.Adjective()+"_report", completion, time.Since(started), err))
+		if err != nil {
+			// Repairing only helps with bad content, not a failed call
+			lastErr = err
+			break
+		}
+
+		content, err := parseReportContent(completion.Text)
+		if err == nil {
+			return &models.ReportGeneration{
+				Content:  content,
+				Source:   models.ReportSourceModel,
+				Model:    g.client.Model(),
+				Attempts: attempts,
+				Calls:    calls,
+			}, nil
+		}
+
+		lastErr = err
+		messages = append(messages,
+			LLMMessage{Role: "assistant", Content: completion.Text},
+			LLMMessage{Role: "user", Content: buildRepairPrompt(err)},
+		)
+	}
+
+	log.Printf("%s report generation failed after %d attempts, serving mock report: %v", g.client.Name(), attempts, lastErr)
+
+	generation, err := g.fallback.GenerateReport(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	generation.Source = models.ReportSourceFallback
+	generation.Model = g.client.Model()
+	generation.Attempts = attempts
+	generation.Calls = calls
+
+	return generation, nil
+}
+
+// WriteYearNarrative asks the model for the narrative of a year in review.
+// A narrative is a single text, so a bad reply is not repaired; the mock
+// narrative is served instead and recorded as a fallback.
+func (g *LLMReportGenerator) WriteYearNarrative(ctx context.Context, userID uuid.UUID, content *models.YearReviewContent) (*models.YearNarrativeGeneration, error) {
+	messages := []LLMMessage{
+		{Role: "user", Content: buildYearNarrativePrompt(content)},
+	}
+
+	started := time.Now()
+	completion, err := g.client.Complete(ctx, messages, yearNarrativeSchema)
+	calls := []*models.LLMCall{g.newCall(userID, "year_review", completion, time.Since(started), err)}
+
+	if err == nil {
+		var text string
+		text, err = parseYearNarrative(completion.Text)
+		if err == nil {
+			return &models.YearNarrativeGeneration{
+				Text:   text,
+				Source: models.ReportSourceModel,
+				Model:  g.client.Model(),
+				Calls:  calls,
+			}, nil
+		}
+	}
+
+	log.Printf("%s year narrative failed, serving mock narrative: %v", g.client.Name(), err)
+
+	generation, err := g.fallback.WriteYearNarrative(ctx, userID, content)
+	if err != nil {
+		return nil, err
+	}
+	generation.Source = models.ReportSourceFallback
+	generation.Model = g.client.Model()
+	generation.Calls = calls
+
+	return generation, nil
+}
+
+// newCall records a model call for usage accounting
+func (g *LLMReportGenerator) newCall(userID uuid.UUID, purpose string, completion *LLMCompletion, latency time.Duration, err error) *models.LLMCall {
+	call := &models.LLMCall{
+		UserID:    userID,
+		Provider:  g.client.Name(),
+		Model:     g.client.Model(),
+		Purpose:   purpose,
+		LatencyMs: int(latency.Milliseconds()),
+	}
+	if completion != nil {
+		call.PromptTokens = completion.PromptTokens
+		call.ResponseTokens = completion.ResponseTokens
+	}
+	if err != nil {
+		message := err.Error()
+		call.Error = &message
+	}
+	return call
+}
+
+// parseReportContent decodes and validates a model's reply. Servers that
+// ignore the response schema may wrap the JSON in other text, so the first
+// JSON object in the reply is tried as well.
+func parseReportContent(reply string) (*models.ReportContent, error) {
+	var content models.ReportContent
+	if err := json.Unmarshal([]byte(reply), &content); err != nil {
+		extracted, ok := parseReportFromText(reply)
+		if !ok {
+			return nil, fmt.Errorf("reply is not a JSON report: %w", err)
+		}
+		content = *extracted
+	}
+
+	if err := content.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &content, nil
+}
+
+// buildRepairPrompt asks the model to fix the problems of its last reply
+func buildRepairPrompt(err error) string {
+	problems := []string{err.Error()}
+	var validationErr *models.ReportValidationError
+	if errors.As(err, &validationErr) {
+		problems = validationErr.Problems
+	}
+
+	return fmt.Sprintf(`Your previous response could not be used because of these problems:
+- %s
+
+Return the corrected report as JSON with the same structure. Only output valid JSON, no other text.`,
+		strings.Join(problems, "\n- "))
+}
+
+// reportContentSchema describes models.ReportContent with its bounds
+var reportContentSchema = &JSONSchema{
+	Type: "object",
+	Properties: map[string]*JSONSchema{
+		"summary":          {Type: "string", Description: "A 2-3 sentence motivational summary of the period"},
+		"improvements":     stringListSchema(1, models.MaxReportImprovements),
+		"skills_learned":   stringListSchema(0, models.MaxReportSkillsLearned),
+		"areas_to_improve": stringListSchema(0, models.MaxReportAreasToImprove),
+		"revision_suggestions": {
+			Type:     "array",
+			MaxItems: intPtr(models.MaxReportRevisions),
+			Items: &JSONSchema{
+				Type: "object",
+				Properties: map[string]*JSONSchema{
+					"skill":  {Type: "string"},
+					"reason": {Type: "string"},
+					"suggested_duration_days": {
+						Type:    "integer",
+						Minimum: intPtr(models.MinRevisionDurationDays),
+						Maximum: intPtr(models.MaxRevisionDurationDays),
+					},
+					"daily_minutes": {
+						Type:    "integer",
+						Minimum: intPtr(models.MinRevisionDailyMinutes),
+						Maximum: intPtr(models.MaxRevisionDailyMinutes),
+					},
+				},
+				Required:             []string{"skill", "reason", "suggested_duration_days", "daily_minutes"},
+				AdditionalProperties: boolPtr(false),
+			},
+		},
+		"motivational_note": {Type: "string", Description: "A short encouraging message"},
+	},
+	Required: []string{
+		"summary", "improvements", "skills_learned", "areas_to_improve",
+		"revision_suggestions", "motivational_note",
+	},
+	AdditionalProperties: boolPtr(false),
+}
+
+// yearNarrativeSchema wraps the narrative of a year review in an object, as
+// providers only constrain replies to objects
+var yearNarrativeSchema = &JSONSchema{
+	Type: "object",
+	Properties: map[string]*JSONSchema{
+		"narrative": {Type: "string", Description: "A warm 4-6 sentence story of the user's year"},
+	},
+	Required:             []string{"narrative"},
+	AdditionalProperties: boolPtr(false),
+}
+
+// parseYearNarrative decodes and validates a model's narrative reply
+func parseYearNarrative(reply string) (string, error) {
+	var parsed struct {
+		Narrative string `json:"narrative"`
+	}
+	if err := json.Unmarshal([]byte(reply), &parsed); err != nil {
+		start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+		if start == -1 || end <= start || json.Unmarshal([]byte(reply[start:end+1]), &parsed) != nil {
+			return "", fmt.Errorf("reply is not a JSON narrative: %w", err)
+		}
+	}
+
+	narrative := strings.TrimSpace(parsed.Narrative)
+	if narrative == "" {
+		return "", errors.New("narrative is empty")
+	}
+	if len(narrative) > models.MaxYearNarrativeLength {
+		return "", fmt.Errorf("narrative is longer than %d characters", models.MaxYearNarrativeLength)
+	}
+
+	return narrative, nil
+}
+
+// buildYearNarrativePrompt builds the prompt for the narrative of a year review
+func buildYearNarrativePrompt(content *models.YearReviewContent) string {
+	reviewJSON, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		reviewJSON = []byte("{}")
+	}
+
+	return fmt.Sprintf(`You are an AI assistant for a habit tracking app. Write the narrative of the user's year in review for %d, based on the following data.
+
+Year in review data:
+%s
+
+Generate a JSON response with the following structure:
+{
+  "narrative": "A warm 4-6 sentence story of the user's year"
+}
+
+Rules:
+1. Speak to the user directly, in the second person
+2. Mention the habits with the best months and longest streaks by name
+3. Mention how many skills were learned, and the habits started or abandoned if there are any
+4. Describe trends across the months rather than listing numbers
+5. Be encouraging but honest, and end looking ahead to next year
+6. Use plain text without markdown
+7. Only output valid JSON, no other text
+
+JSON Response:`, content.Year, string(reviewJSON))
+}
+
+// stringListSchema describes a list of strings of bounded length
+func stringListSchema(minItems, maxItems int) *JSONSchema {
+	return &JSONSchema{
+		Type:     "array",
+		Items:    &JSONSchema{Type: "string"},
+		MinItems: intPtr(minItems),
+		MaxItems: intPtr(maxItems),
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+// reportPromptGuide tailors the report prompt to the length of the period
+type reportPromptGuide struct {
+	kind         string // what the report is, e.g. "a short weekly check-in"
+	improvements string // how many improvements to list
+	areas        string // how many areas to improve to list
+	revisionRule string
+	focusRule    string
+}
+
+// reportPromptGuides holds the prompt guide of each period type
+var reportPromptGuides = map[models.ReportPeriodType]reportPromptGuide{
+	models.ReportPeriodWeek: {
+		kind:         "a short weekly check-in",
+		improvements: "1-2",
+		areas:        "1",
+		revisionRule: "Leave revision_suggestions empty; weekly check-ins do not suggest revisions",
+		focusRule:    "Keep it light: reflect on how the week went and name one focus for the next week",
+	},
+	models.ReportPeriodMonth: {
+		kind:         "a monthly progress report",
+		improvements: "2-4",
+		areas:        "1-3",
+		revisionRule: "Suggest revisions for skills learned 2-4 weeks ago",
+		focusRule:    "Compare the habits with each other and point out the most consistent ones",
+	},
+	models.ReportPeriodQuarter: {
+		kind:         "a quarterly progress review",
+		improvements: "3-5",
+		areas:        "2-3",
+		revisionRule: "Suggest revisions for skills learned early in the quarter",
+		focusRule:    "Look for trends across the quarter rather than single weeks",
+	},
+	models.ReportPeriodYear: {
+		kind:         "a yearly review",
+		improvements: "4-6",
+		areas:        "2-4",
+		revisionRule: "Suggest revisions for the most valuable skills learned during the year",
+		focusRule:    "Celebrate the year's milestones and look for long-term trends",
+	},
+}
+
+// buildReportPrompt builds the prompt for report generation
+func buildReportPrompt(input *models.ReportGenerationInput) string {
+	habitsJSON, err := json.MarshalIndent(input.Habits, "", "  ")
+	if err != nil {
+		habitsJSON = []byte("[]")
+	}
+
+	comparisonJSON, err := json.MarshalIndent(input.Comparison, "", "  ")
+	if err != nil || input.Comparison == nil {
+		comparisonJSON = []byte("{}")
+	}
+
+	guide, ok := reportPromptGuides[input.Period.Type]
+	if !ok {
+		guide = reportPromptGuides[models.ReportPeriodMonth]
+	}
+	period := string(input.Period.Type)
+
+	return fmt.Sprintf(`You are an AI assistant for a habit tracking app. Generate %s based on the following data.
+
+User's habit data for %s:
+%s
+
+Total habits tracked: %d
+Overall completion rate: %.1f%%
+
+Comparison with the previous %s (rates are percentages, changes are percentage points):
+%s
+
+Generate a JSON response with the following structure:
+{
+  "summary": "A 2-3 sentence motivational summary of the %s",
+  "improvements": ["Array of %s specific improvements the user made"],
+  "skills_learned": ["Array of skills/topics learned from the learning notes"],
+  "areas_to_improve": ["Array of %s areas where the user could improve"],
+  "revision_suggestions": [
+    {
+      "skill": "Name of skill to revise",
+      "reason": "Why this skill should be revised",
+      "suggested_duration_days": 7,
+      "daily_minutes": 30
+    }
+  ],
+  "motivational_note": "A short encouraging message"
+}
+
+Rules:
+1. Be encouraging but honest
+2. Base skills_learned on the learning_notes in the data
+3. %s
+4. For habits with a unit, mention the total_value and average_value against the target_value
+5. Use the comparison for concrete changes with numbers, e.g. "improved from 40%% to 75%%": base improvements on the improved habits and areas_to_improve on the declined and dropped ones, and welcome new habits
+6. %s
+7. Keep the response concise and actionable
+8. Only output valid JSON, no other text
+
+JSON Response:`, guide.kind, input.Period.Label(), string(habitsJSON), input.TotalHabits, input.OverallCompletion,
+		period, string(comparisonJSON),
+		period, guide.improvements, guide.areas, guide.revisionRule, guide.focusRule)
+}
+
+// parseReportFromText attempts to extract report data from a reply that
+// wraps the JSON in other text, such as a Markdown code fence
+func parseReportFromText(text string) (*models.ReportContent, bool) {
+	// Try to find JSON in the text
+	start := -1
+	end := -1
+	braceCount := 0
+
+	for i, c := range text {
+		if c == '{' {
+			if start == -1 {
+				start = i
+			}
+			braceCount++
+		} else if c == '}' {
+			braceCount--
+			if braceCount == 0 && start != -1 {
+				end = i + 1
+				break
+			}
+		}
+	}
+
+	if start != -1 && end != -1 {
+		jsonStr := text[start:end]
+		var report models.ReportContent
+		if err := json.Unmarshal([]byte(jsonStr), &report); err == nil {
+			return &report, true
+		}
+	}
+
+	return nil, false
+}
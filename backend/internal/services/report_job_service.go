@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+const (
+	// reportJobClaimTimeout is how long a job may stay running before another
+	// worker reclaims it. It has to outlast a generation with its repair
+	// round-trip at the longest provider timeout.
+	reportJobClaimTimeout = 10 * time.Minute
+	// reportJobMaxAttempts bounds how often a job is reclaimed after its
+	// worker stopped responding
+	reportJobMaxAttempts = 3
+	// reportJobPollInterval is how often idle workers look for jobs queued
+	// on other replicas
+	reportJobPollInterval = 5 * time.Second
+	// reportJobRetention is how long finished jobs can be polled
+	reportJobRetention = 7 * 24 * time.Hour
+)
+
+var (
+	ErrReportQuotaExceeded = errors.New("monthly report regeneration quota exceeded")
+)
+
+// ReportJobService queues report generation and runs the queue on a pool of
+// workers, so clients do not hold a request open while a model writes the
+// report. The queue lives in Postgres; workers on every replica share it.
+type ReportJobService struct {
+	jobRepo           *repository.ReportJobRepository
+	reportService     *ReportService
+	yearReviewService *YearReviewService
+	userRepo          *repository.UserRepository
+	notificationSvc   *NotificationService
+	workers           int
+	quota             int
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+}
+
+// NewReportJobService creates a new ReportJobService with the given number
+// of workers. With no workers, jobs queued here are left to other replicas.
+// quota limits regenerations, and year review builds, per user and month;
+// 0 means no limit. Users are notified when a monthly report is generated.
+func NewReportJobService(
+	jobRepo *repository.ReportJobRepository,
+	reportService *ReportService,
+	yearReviewService *YearReviewService,
+	userRepo *repository.UserRepository,
+	notificationSvc *NotificationService,
+	workers int,
+	quota int,
+) *ReportJobService {
+	return &ReportJobService{
+		jobRepo:           jobRepo,
+		reportService:     reportService,
+		yearReviewService: yearReviewService,
+		userRepo:          userRepo,
+		notificationSvc:   notificationSvc,
+		workers:           workers,
+		quota:             quota,
+		wake:              make(chan struct{}, 1),
+	}
+}
+
+// Enqueue queues generation of a user's report, or year review, for a
+// period. A request for a period that already has an unfinished job returns
+// that job, with created set to false. Regenerations and year reviews beyond
+// the monthly quota return ErrReportQuotaExceeded.
+func (s *ReportJobService) Enqueue(ctx context.Context, userID uuid.UUID, kind models.ReportJobKind, period models.ReportPeriod) (*models.ReportJob, bool, error) {
+	job := &models.ReportJob{
+		UserID:      userID,
+		Kind:        kind,
+		PeriodType:  period.Type,
+		PeriodStart: period.Start,
+	}
+
+	var created bool
+	var err error
+	if kind.Limited() && s.quota > 0 {
+		// A repeated tap joins the running job without using the quota
+		job, created, err = s.jobRepo.EnqueueWithinQuota(ctx, job, quotaMonthStart(time.Now()), s.quota)
+		if err == repository.ErrReportQuotaExhausted {
+			return nil, false, ErrReportQuotaExceeded
+		}
+	} else {
+		job, created, err = s.jobRepo.Enqueue(ctx, job)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if created {
+		// Let an idle worker start right away instead of at its next poll
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return job, created, nil
+}
+
+// RegenerationQuota returns a user's regeneration quota for the current
+// month, or nil when regenerations are not limited. Failed jobs do not
+// count against it.
+func (s *ReportJobService) RegenerationQuota(ctx context.Context, userID uuid.UUID) (*models.ReportQuota, error) {
+	if s.quota <= 0 {
+		return nil, nil
+	}
+
+	monthStart := quotaMonthStart(time.Now())
+
+	used, err := s.jobRepo.CountSince(ctx, userID, models.ReportJobRegenerate, monthStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReportQuota{
+		Limit:    s.quota,
+		Used:     used,
+		ResetsAt: monthStart.AddDate(0, 1, 0),
+	}, nil
+}
+
+// quotaMonthStart returns the start of the calendar month (UTC) the
+// regeneration quota covers at a time
+func quotaMonthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// GetJob retrieves a user's report job
+func (s *ReportJobService) GetJob(ctx context.Context, userID, jobID uuid.UUID) (*models.ReportJob, error) {
+	return s.jobRepo.GetByID(ctx, jobID, userID)
+}
+
+// Start runs the workers in the background until Stop is called
+func (s *ReportJobService) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil || s.workers <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.work(ctx)
+	}
+
+	s.wg.Add(1)
+	go s.sweep(ctx)
+
+	log.Printf("Report job workers started (%d)", s.workers)
+}
+
+// Stop cancels the running jobs, which go back to the queue, and waits for
+// the workers to exit, or for ctx to expire
+func (s *ReportJobService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("Report job workers stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work runs queued jobs until ctx is cancelled, polling while idle
+func (s *ReportJobService) work(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(reportJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			job, err := s.jobRepo.Claim(ctx, time.Now().Add(-reportJobClaimTimeout), reportJobMaxAttempts)
+			if err != nil {
+				if err != repository.ErrReportJobNotFound && ctx.Err() == nil {
+					log.Printf("Report job worker failed to claim a job: %v", err)
+				}
+				break
+			}
+			s.run(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// run generates the report of a claimed job and records the outcome
+func (s *ReportJobService) run(ctx context.Context, job *models.ReportJob) {
+	var report *models.Report
+	var err error
+	switch job.Kind {
+	case models.ReportJobRegenerate:
+		report, err = s.reportService.RegenerateReport(ctx, job.UserID, job.Period())
+	case models.ReportJobYearReview:
+		_, err = s.yearReviewService.Build(ctx, job.UserID, job.PeriodStart.Year())
+	default:
+		report, err = s.reportService.GenerateReport(ctx, job.UserID, job.Period())
+	}
+
+	// ctx is cancelled when the workers stop, so record the outcome apart
+	finishCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err != nil && ctx.Err() != nil {
+		// Shutting down; another worker picks the job up again
+		if err := s.jobRepo.Requeue(finishCtx, job); err != nil {
+			log.Printf("Failed to requeue report job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	if err != nil {
+		log.Printf("Report job %s for user %s failed: %v", job.ID, job.UserID, err)
+		message := err.Error()
+		job.Status = models.ReportJobFailed
+		job.Error = &message
+	} else {
+		job.Status = models.ReportJobSucceeded
+		if report != nil {
+			job.ReportID = &report.ID
+		}
+	}
+
+	if err := s.jobRepo.Finish(finishCtx, job); err != nil {
+		log.Printf("Failed to record outcome of report job %s: %v", job.ID, err)
+	}
+
+	if job.Status == models.ReportJobSucceeded && job.Kind == models.ReportJobGenerate && job.PeriodType == models.ReportPeriodMonth {
+		s.notifyReportReady(finishCtx, job)
+	}
+}
+
+// notifyReportReady tells a user their monthly report is ready. The
+// notification is queued once per month, and regardless of push settings
+// so the inbox shows it.
+func (s *ReportJobService) notifyReportReady(ctx context.Context, job *models.ReportJob) {
+	user, err := s.userRepo.GetByID(ctx, job.UserID)
+	if err != nil {
+		log.Printf("Failed to load user %s of report job %s: %v", job.UserID, job.ID, err)
+		return
+	}
+
+	if err := s.notificationSvc.SendReportReadyNotification(ctx, user, job.Period().Key()); err != nil {
+		log.Printf("Failed to queue report notification for user %s: %v", user.ID, err)
+	}
+}
+
+// sweep periodically fails jobs abandoned by crashed workers and prunes
+// finished jobs
+func (s *ReportJobService) sweep(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		if _, err := s.jobRepo.FailStale(ctx, now.Add(-reportJobClaimTimeout), reportJobMaxAttempts); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to fail stale report jobs: %v", err)
+		}
+		// Limited jobs are kept through their month, as they count against the quota
+		limited := []string{string(models.ReportJobRegenerate), string(models.ReportJobYearReview)}
+		if _, err := s.jobRepo.DeleteFinishedBefore(ctx, now.Add(-reportJobRetention), limited, quotaMonthStart(now)); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to prune report jobs: %v", err)
+		}
+	}
+}
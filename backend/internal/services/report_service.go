@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"log"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/habittracker/backend/internal/models"
@@ -17,7 +16,9 @@ type ReportService struct {
 	habitRepo    *repository.HabitRepository
 	logRepo      *repository.LogRepository
 	revisionRepo *repository.RevisionRepository
-	geminiSvc    *GeminiService
+	llmCallRepo  *repository.LLMCallRepository
+	generator    ReportGenerator
+	dayResolver  *DayResolver
 }
 
 // NewReportService creates a new ReportService
@@ -26,207 +27,201 @@ func NewReportService(
 	habitRepo *repository.HabitRepository,
 	logRepo *repository.LogRepository,
 	revisionRepo *repository.RevisionRepository,
-	geminiSvc *GeminiService,
+	llmCallRepo *repository.LLMCallRepository,
+	generator ReportGenerator,
+	dayResolver *DayResolver,
 ) *ReportService {
 	return &ReportService{
 		reportRepo:   reportRepo,
 		habitRepo:    habitRepo,
 		logRepo:      logRepo,
 		revisionRepo: revisionRepo,
-		geminiSvc:    geminiSvc,
+		llmCallRepo:  llmCallRepo,
+		generator:    generator,
+		dayResolver:  dayResolver,
 	}
 }
 
-// GenerateReport generates a monthly report for a user
-func (s *ReportService) GenerateReport(ctx context.Context, userID uuid.UUID, year, month int) (*models.Report, error) {
-	// Create report month date (first day of month)
-	reportMonth := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-
+// GenerateReport generates a user's report for a period, returning the
+// existing report if there is one
+func (s *ReportService) GenerateReport(ctx context.Context, userID uuid.UUID, period models.ReportPeriod) (*models.Report, error) {
 	// Check if report already exists
-	existing, err := s.reportRepo.GetByUserAndMonth(ctx, userID, reportMonth)
+	existing, err := s.reportRepo.GetByUserAndPeriod(ctx, userID, period)
 	if err == nil && existing != nil {
 		// Report exists, return it
 		return existing, nil
 	}
 
-	// Gather habit completion data
-	habitData, err := s.reportRepo.GetHabitCompletionDataForMonth(ctx, userID, year, month)
+	// Generate AI report
+	input, err := s.buildInput(ctx, userID, period)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get learning notes
-	learningNotes, err := s.logRepo.GetLearningNotesByUserAndMonth(ctx, userID, year, month)
+	generation, err := s.generator.GenerateReport(ctx, input)
 	if err != nil {
 		return nil, err
 	}
+	s.recordCalls(ctx, generation)
 
-	// Add learning notes to habit data
-	for _, habit := range habitData {
-		if notes, ok := learningNotes[habit.HabitID]; ok {
-			habit.LearningNotes = notes
-		}
+	// Create report
+	report := &models.Report{
+		UserID:      userID,
+		PeriodType:  period.Type,
+		PeriodStart: period.Start,
+		PeriodEnd:   period.End(),
+	}
+	if err := applyGeneration(report, input, generation); err != nil {
+		return nil, err
 	}
 
-	// Calculate overall completion
-	var totalCompletion float64
-	for _, habit := range habitData {
-		totalCompletion += habit.CompletionRate
+	if err := s.reportRepo.Create(ctx, report); err != nil {
+		return nil, err
 	}
-	if len(habitData) > 0 {
-		totalCompletion /= float64(len(habitData))
+
+	// Create revision habits from suggestions
+	if len(generation.Content.RevisionSuggestions) > 0 {
+		if err := s.reportRepo.CreateRevisionHabitsFromReport(ctx, userID, period.Start, generation.Content.RevisionSuggestions); err != nil {
+			log.Printf("failed to create revision habits for user %s: %v", userID, err)
+		}
 	}
 
-	// Prepare input for AI
-	input := &models.ReportGenerationInput{
-		UserID:            userID,
-		Month:             reportMonth.Format("2006-01"),
-		Habits:            habitData,
-		TotalHabits:       len(habitData),
-		OverallCompletion: totalCompletion,
+	return report, nil
+}
+
+// GetReport retrieves a user's report of a period
+func (s *ReportService) GetReport(ctx context.Context, userID uuid.UUID, period models.ReportPeriod) (*models.Report, error) {
+	return s.reportRepo.GetByUserAndPeriod(ctx, userID, period)
+}
+
+// GetAllReports retrieves a user's reports of a period type, or of every
+// type when periodType is empty
+func (s *ReportService) GetAllReports(ctx context.Context, userID uuid.UUID, periodType models.ReportPeriodType) ([]*models.Report, error) {
+	return s.reportRepo.GetByUser(ctx, userID, periodType)
+}
+
+// RegenerateReport regenerates a user's report for a period, replacing the
+// existing one
+func (s *ReportService) RegenerateReport(ctx context.Context, userID uuid.UUID, period models.ReportPeriod) (*models.Report, error) {
+	existing, err := s.reportRepo.GetByUserAndPeriod(ctx, userID, period)
+	if err != nil {
+		if err == repository.ErrReportNotFound {
+			// Generate new report
+			return s.GenerateReport(ctx, userID, period)
+		}
+		return nil, err
 	}
 
-	// Generate AI report
-	reportContent, err := s.geminiSvc.GenerateMonthlyReport(ctx, input)
+	input, err := s.buildInput(ctx, userID, period)
 	if err != nil {
 		return nil, err
 	}
 
-	// Serialize report content
-	contentJSON, err := json.Marshal(reportContent)
+	generation, err := s.generator.GenerateReport(ctx, input)
 	if err != nil {
 		return nil, err
 	}
+	s.recordCalls(ctx, generation)
 
-	// Serialize habits completion percentage
-	habitsPercentage := make(map[string]float64)
-	for _, habit := range habitData {
-		habitsPercentage[habit.HabitID.String()] = habit.CompletionRate
+	if err := applyGeneration(existing, input, generation); err != nil {
+		return nil, err
 	}
-	habitsPercentageJSON, err := json.Marshal(habitsPercentage)
-	if err != nil {
+
+	if err := s.reportRepo.Update(ctx, existing); err != nil {
 		return nil, err
 	}
 
-	// Serialize revision suggestions
-	suggestionsJSON, err := json.Marshal(reportContent.RevisionSuggestions)
+	return existing, nil
+}
+
+// buildInput gathers the habit data of a period for the report generator
+func (s *ReportService) buildInput(ctx context.Context, userID uuid.UUID, period models.ReportPeriod) (*models.ReportGenerationInput, error) {
+	loc, err := s.dayResolver.UserLocation(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create report
-	report := &models.Report{
-		UserID:                    userID,
-		ReportMonth:               reportMonth,
-		ReportContent:             contentJSON,
-		SkillsLearned:             reportContent.SkillsLearned,
-		HabitsCompletedPercentage: habitsPercentageJSON,
-		RevisionSuggestions:       suggestionsJSON,
+	// Gather habit completion data
+	habitData, err := s.reportRepo.GetHabitCompletionData(ctx, userID, period.Start, period.End(), loc)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.reportRepo.Create(ctx, report); err != nil {
+	// Get learning notes
+	learningNotes, err := s.logRepo.GetLearningNotesByUserAndRange(ctx, userID, period.Start, period.End())
+	if err != nil {
 		return nil, err
 	}
 
-	// Create revision habits from suggestions
-	if len(reportContent.RevisionSuggestions) > 0 {
-		if err := s.reportRepo.CreateRevisionHabitsFromReport(ctx, userID, reportMonth, reportContent.RevisionSuggestions); err != nil {
-			log.Printf("failed to create revision habits for user %s: %v", userID, err)
+	// Add learning notes to habit data
+	for _, habit := range habitData {
+		if notes, ok := learningNotes[habit.HabitID]; ok {
+			habit.LearningNotes = notes
 		}
 	}
 
-	return report, nil
-}
-
-// GetReport retrieves a report by month
-func (s *ReportService) GetReport(ctx context.Context, userID uuid.UUID, monthStr string) (*models.Report, error) {
-	reportMonth, err := time.Parse("2006-01", monthStr)
+	// Compare with the previous period of the same type
+	previous := period.Previous()
+	previousData, err := s.reportRepo.GetHabitCompletionData(ctx, userID, previous.Start, previous.End(), loc)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.reportRepo.GetByUserAndMonth(ctx, userID, reportMonth)
-}
-
-// GetAllReports retrieves all reports for a user
-func (s *ReportService) GetAllReports(ctx context.Context, userID uuid.UUID) ([]*models.Report, error) {
-	return s.reportRepo.GetByUser(ctx, userID)
+	return &models.ReportGenerationInput{
+		UserID:            userID,
+		Period:            period,
+		Habits:            habitData,
+		TotalHabits:       len(habitData),
+		OverallCompletion: models.OverallCompletion(habitData),
+		Comparison:        models.CompareHabitData(previous, previousData, habitData),
+	}, nil
 }
 
-// RegenerateReport regenerates a report for a month
-func (s *ReportService) RegenerateReport(ctx context.Context, userID uuid.UUID, year, month int) (*models.Report, error) {
-	reportMonth := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-
-	// Delete existing report if any
-	existing, err := s.reportRepo.GetByUserAndMonth(ctx, userID, reportMonth)
-	if err == nil && existing != nil {
-		// Update instead of create
-		habitData, err := s.reportRepo.GetHabitCompletionDataForMonth(ctx, userID, year, month)
-		if err != nil {
-			return nil, err
-		}
-
-		learningNotes, err := s.logRepo.GetLearningNotesByUserAndMonth(ctx, userID, year, month)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, habit := range habitData {
-			if notes, ok := learningNotes[habit.HabitID]; ok {
-				habit.LearningNotes = notes
-			}
-		}
+// applyGeneration stores generated content and the habit data it was
+// generated from on a report
+func applyGeneration(report *models.Report, input *models.ReportGenerationInput, generation *models.ReportGeneration) error {
+	// The comparison is computed, not generated
+	generation.Content.Comparison = input.Comparison
 
-		var totalCompletion float64
-		for _, habit := range habitData {
-			totalCompletion += habit.CompletionRate
-		}
-		if len(habitData) > 0 {
-			totalCompletion /= float64(len(habitData))
-		}
+	// Serialize report content
+	contentJSON, err := json.Marshal(generation.Content)
+	if err != nil {
+		return err
+	}
 
-		input := &models.ReportGenerationInput{
-			UserID:            userID,
-			Month:             reportMonth.Format("2006-01"),
-			Habits:            habitData,
-			TotalHabits:       len(habitData),
-			OverallCompletion: totalCompletion,
-		}
+	// Serialize habits completion percentage
+	habitsPercentage := make(map[string]float64)
+	for _, habit := range input.Habits {
+		habitsPercentage[habit.HabitID.String()] = habit.CompletionRate
+	}
+	habitsPercentageJSON, err := json.Marshal(habitsPercentage)
+	if err != nil {
+		return err
+	}
 
-		reportContent, err := s.geminiSvc.GenerateMonthlyReport(ctx, input)
-		if err != nil {
-			return nil, err
-		}
+	// Serialize revision suggestions
+	suggestionsJSON, err := json.Marshal(generation.Content.RevisionSuggestions)
+	if err != nil {
+		return err
+	}
 
-		contentJSON, err := json.Marshal(reportContent)
-		if err != nil {
-			return nil, err
-		}
-		suggestionsJSON, err := json.Marshal(reportContent.RevisionSuggestions)
-		if err != nil {
-			return nil, err
-		}
-		habitsPercentage := make(map[string]float64)
-		for _, habit := range habitData {
-			habitsPercentage[habit.HabitID.String()] = habit.CompletionRate
-		}
-		habitsPercentageJSON, err := json.Marshal(habitsPercentage)
-		if err != nil {
-			return nil, err
-		}
+	report.ReportContent = contentJSON
+	report.SkillsLearned = generation.Content.SkillsLearned
+	report.HabitsCompletedPercentage = habitsPercentageJSON
+	report.RevisionSuggestions = suggestionsJSON
+	report.GenerationSource = generation.Source
+	report.GenerationModel = generation.Model
+	report.GenerationAttempts = generation.Attempts
 
-		existing.ReportContent = contentJSON
-		existing.SkillsLearned = reportContent.SkillsLearned
-		existing.HabitsCompletedPercentage = habitsPercentageJSON
-		existing.RevisionSuggestions = suggestionsJSON
+	return nil
+}
 
-		if err := s.reportRepo.Update(ctx, existing); err != nil {
-			return nil, err
+// recordCalls stores the model calls of a generation for usage accounting.
+// Failing to record them does not fail the report.
+func (s *ReportService) recordCalls(ctx context.Context, generation *models.ReportGeneration) {
+	for _, call := range generation.Calls {
+		if err := s.llmCallRepo.Create(ctx, call); err != nil {
+			log.Printf("failed to record %s call for user %s: %v", call.Provider, call.UserID, err)
 		}
-
-		return existing, nil
 	}
-
-	// Generate new report
-	return s.GenerateReport(ctx, userID, year, month)
 }
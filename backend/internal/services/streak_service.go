@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+// StreakService recomputes streaks from log history
+type StreakService struct {
+	userRepo    *repository.UserRepository
+	habitRepo   *repository.HabitRepository
+	streakRepo  *repository.StreakRepository
+	dayResolver *DayResolver
+}
+
+// NewStreakService creates a new StreakService
+func NewStreakService(
+	userRepo *repository.UserRepository,
+	habitRepo *repository.HabitRepository,
+	streakRepo *repository.StreakRepository,
+	dayResolver *DayResolver,
+) *StreakService {
+	return &StreakService{
+		userRepo:    userRepo,
+		habitRepo:   habitRepo,
+		streakRepo:  streakRepo,
+		dayResolver: dayResolver,
+	}
+}
+
+// RecomputeHabit rebuilds a habit's streak as of the user's today
+func (s *StreakService) RecomputeHabit(ctx context.Context, habit *models.Habit) (*models.Streak, error) {
+	today, err := s.dayResolver.Today(ctx, habit.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.streakRepo.Recompute(ctx, habit, today)
+}
+
+// RecomputeUser rebuilds the streaks of all of a user's habits and returns
+// how many were rebuilt
+func (s *StreakService) RecomputeUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	today := s.dayResolver.TodayFor(user)
+
+	habits, err := s.habitRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, habit := range habits {
+		if _, err := s.streakRepo.Recompute(ctx, habit, today); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(habits), nil
+}
+
+// RecomputeAll rebuilds every user's streaks. A failure for one user is
+// logged and does not stop the others; it returns the number of habits rebuilt.
+func (s *StreakService) RecomputeAll(ctx context.Context) (int, error) {
+	userIDs, err := s.userRepo.GetAllIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		count, err := s.RecomputeUser(ctx, userID)
+		if err != nil {
+			log.Printf("failed to recompute streaks for user %s: %v", userID, err)
+			continue
+		}
+		total += count
+	}
+
+	return total, nil
+}
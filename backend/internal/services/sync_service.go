@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,16 +15,19 @@ import (
 type SyncService struct {
 	habitRepo *repository.HabitRepository
 	logRepo   *repository.LogRepository
+	streakSvc *StreakService
 }
 
 // NewSyncService creates a new SyncService
 func NewSyncService(
 	habitRepo *repository.HabitRepository,
 	logRepo *repository.LogRepository,
+	streakSvc *StreakService,
 ) *SyncService {
 	return &SyncService{
 		habitRepo: habitRepo,
 		logRepo:   logRepo,
+		streakSvc: streakSvc,
 	}
 }
 
@@ -33,14 +37,24 @@ func (s *SyncService) PushChanges(ctx context.Context, userID uuid.UUID, req *mo
 	failedCount := 0
 	var failedItems []uuid.UUID
 
+	// Habits whose streak must be rebuilt once all items are applied
+	touched := make(map[uuid.UUID]bool)
+
 	for _, item := range req.Items {
 		var err error
 
 		switch item.EntityType {
 		case models.SyncEntityHabit:
 			err = s.processHabitSync(ctx, userID, item)
+			if err == nil && item.Action == models.SyncActionUpdate {
+				touched[item.EntityID] = true
+			}
 		case models.SyncEntityDailyLog:
-			err = s.processDailyLogSync(ctx, userID, item)
+			var habitID uuid.UUID
+			habitID, err = s.processDailyLogSync(ctx, userID, item)
+			if err == nil && habitID != uuid.Nil {
+				touched[habitID] = true
+			}
 		}
 
 		if err != nil {
@@ -51,6 +65,10 @@ func (s *SyncService) PushChanges(ctx context.Context, userID uuid.UUID, req *mo
 		}
 	}
 
+	for habitID := range touched {
+		s.recomputeStreak(ctx, userID, habitID)
+	}
+
 	return &models.SyncPushResponse{
 		SyncedCount:  syncedCount,
 		FailedCount:  failedCount,
@@ -86,23 +104,44 @@ func (s *SyncService) processHabitSync(ctx context.Context, userID uuid.UUID, it
 	return nil
 }
 
-// processDailyLogSync processes a daily log sync item
-func (s *SyncService) processDailyLogSync(ctx context.Context, userID uuid.UUID, item *models.SyncPushItem) error {
+// processDailyLogSync processes a daily log sync item and returns the ID of
+// the habit whose log was written
+func (s *SyncService) processDailyLogSync(ctx context.Context, userID uuid.UUID, item *models.SyncPushItem) (uuid.UUID, error) {
 	switch item.Action {
 	case models.SyncActionCreate, models.SyncActionUpdate:
 		var log models.DailyLog
 		if err := json.Unmarshal(item.Payload, &log); err != nil {
-			return err
+			return uuid.Nil, err
 		}
+
+		// Verify habit ownership
+		if _, err := s.habitRepo.GetByIDAndUserID(ctx, log.HabitID, userID); err != nil {
+			return uuid.Nil, err
+		}
+
 		log.UserID = userID
-		return s.logRepo.CreateOrUpdate(ctx, &log)
+		return log.HabitID, s.logRepo.CreateOrUpdate(ctx, &log)
 
 	case models.SyncActionDelete:
 		// Daily logs typically aren't deleted, they're updated to completed=false
-		return nil
+		return uuid.Nil, nil
 	}
 
-	return nil
+	return uuid.Nil, nil
+}
+
+// recomputeStreak rebuilds a synced habit's streak. Failures are logged
+// rather than failing the push, since the changes themselves were applied.
+func (s *SyncService) recomputeStreak(ctx context.Context, userID, habitID uuid.UUID) {
+	habit, err := s.habitRepo.GetByIDAndUserID(ctx, habitID, userID)
+	if err != nil {
+		log.Printf("failed to load synced habit %s: %v", habitID, err)
+		return
+	}
+
+	if _, err := s.streakSvc.RecomputeHabit(ctx, habit); err != nil {
+		log.Printf("failed to recompute streak for habit %s: %v", habitID, err)
+	}
 }
 
 // PullChanges retrieves changes since last sync
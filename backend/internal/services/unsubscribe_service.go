@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+// UnsubscribeAll is the unsubscribe scope covering every notification type
+const UnsubscribeAll = "all"
+
+var (
+	ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+)
+
+// UnsubscribeService signs the one-click unsubscribe links put in emails and
+// turns email off when one is followed. The signature alone authorizes the
+// change, so the links work without logging in.
+type UnsubscribeService struct {
+	prefRepo *repository.NotificationPreferenceRepository
+	secret   []byte
+	baseURL  string
+}
+
+// NewUnsubscribeService creates a new UnsubscribeService signing with secret
+// and linking to the API at baseURL
+func NewUnsubscribeService(
+	prefRepo *repository.NotificationPreferenceRepository,
+	secret string,
+	baseURL string,
+) *UnsubscribeService {
+	return &UnsubscribeService{
+		prefRepo: prefRepo,
+		secret:   []byte(secret),
+		baseURL:  strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// URL returns the link unsubscribing a user from emails of one notification
+// type, or of all of them for UnsubscribeAll
+func (s *UnsubscribeService) URL(userID uuid.UUID, scope string) string {
+	return s.baseURL + "/api/v1/email/unsubscribe?token=" + url.QueryEscape(s.Token(userID, scope))
+}
+
+// Token signs a user ID and scope as base64url(payload).base64url(HMAC-SHA256)
+func (s *UnsubscribeService) Token(userID uuid.UUID, scope string) string {
+	payload := []byte(userID.String() + ":" + scope)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// Scope verifies a token without acting on it and returns its scope
+func (s *UnsubscribeService) Scope(token string) (string, error) {
+	_, scope, err := s.verify(token)
+	if err != nil {
+		return "", err
+	}
+	if scope == UnsubscribeAll {
+		return scope, nil
+	}
+
+	for _, notificationType := range models.NotificationTypes {
+		if string(notificationType) == scope {
+			return scope, nil
+		}
+	}
+	return "", ErrInvalidUnsubscribeToken
+}
+
+// Unsubscribe verifies a token and turns email off for its scope, returning
+// the scope. Following a link twice is harmless.
+func (s *UnsubscribeService) Unsubscribe(ctx context.Context, token string) (string, error) {
+	userID, scope, err := s.verify(token)
+	if err != nil {
+		return "", err
+	}
+
+	stored, err := s.prefRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	var changed []*models.NotificationPreference
+	for _, preference := range models.NotificationPreferenceMatrix(userID, stored) {
+		if scope == UnsubscribeAll || string(preference.Type) == scope {
+			preference.Email = false
+			changed = append(changed, preference)
+		}
+	}
+	if len(changed) == 0 {
+		return "", ErrInvalidUnsubscribeToken
+	}
+
+	if err := s.prefRepo.UpsertAll(ctx, changed); err != nil {
+		return "", err
+	}
+
+	return scope, nil
+}
+
+// verify checks a token's signature and returns the user ID and scope it
+// carries
+func (s *UnsubscribeService) verify(token string) (uuid.UUID, string, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(payload)) {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+
+	id, scope, ok := strings.Cut(string(payload), ":")
+	if !ok {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+
+	return userID, scope, nil
+}
+
+// sign computes the HMAC-SHA256 of a token payload
+func (s *UnsubscribeService) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUnsubscribeTokenRoundTrip(t *testing.T) {
+	svc := NewUnsubscribeService(nil, "secret", "https://api.example.com/")
+	userID := uuid.New()
+
+	for _, scope := range []string{UnsubscribeAll, "weekly_digest", "report_ready"} {
+		gotID, gotScope, err := svc.verify(svc.Token(userID, scope))
+		if err != nil {
+			t.Fatalf("verify(%q): %v", scope, err)
+		}
+		if gotID != userID || gotScope != scope {
+			t.Errorf("verify(%q) = %s, %q; want %s, %q", scope, gotID, gotScope, userID, scope)
+		}
+	}
+
+	link, err := url.Parse(svc.URL(userID, UnsubscribeAll))
+	if err != nil {
+		t.Fatalf("URL: %v", err)
+	}
+	if link.Path != "/api/v1/email/unsubscribe" {
+		t.Errorf("URL path = %q", link.Path)
+	}
+	if scope, err := svc.Scope(link.Query().Get("token")); err != nil || scope != UnsubscribeAll {
+		t.Errorf("Scope(URL token) = %q, %v; want %q", scope, err, UnsubscribeAll)
+	}
+}
+
+func TestUnsubscribeTokenTampering(t *testing.T) {
+	svc := NewUnsubscribeService(nil, "secret", "https://api.example.com")
+	userID := uuid.New()
+	token := svc.Token(userID, "weekly_digest")
+	payload, signature, _ := strings.Cut(token, ".")
+	encode := base64.RawURLEncoding.EncodeToString
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", payload},
+		{"other scope", encode([]byte(userID.String()+":all")) + "." + signature},
+		{"other user", encode([]byte(uuid.New().String()+":weekly_digest")) + "." + signature},
+		{"truncated signature", payload + "." + signature[:len(signature)-2]},
+		{"bad encoding", payload + ".!!!"},
+		{"other secret", NewUnsubscribeService(nil, "other", "").Token(userID, "weekly_digest")},
+		{"unsigned payload", encode([]byte(userID.String()+":weekly_digest")) + "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := svc.verify(tt.token); err != ErrInvalidUnsubscribeToken {
+				t.Errorf("verify = %v; want ErrInvalidUnsubscribeToken", err)
+			}
+		})
+	}
+}
+
+func TestUnsubscribeScopeRejectsUnknownTypes(t *testing.T) {
+	svc := NewUnsubscribeService(nil, "secret", "https://api.example.com")
+
+	if _, err := svc.Scope(svc.Token(uuid.New(), "marketing")); err != ErrInvalidUnsubscribeToken {
+		t.Errorf("Scope(unknown type) = %v; want ErrInvalidUnsubscribeToken", err)
+	}
+}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/habittracker/backend/internal/models"
+	"github.com/habittracker/backend/internal/repository"
+)
+
+const (
+	// yearReviewRefreshInterval is how long the review of a year that has not
+	// ended yet is served before it is rebuilt with the latest data
+	yearReviewRefreshInterval = 24 * time.Hour
+	// yearReviewTrendThreshold is how many completion points the second half
+	// of a habit's months has to differ from the first for a trend
+	yearReviewTrendThreshold = 10.0
+	// yearReviewFirstYear is the earliest year that can be reviewed
+	yearReviewFirstYear = 2000
+	// yearReviewShareTTL is how long a share link works after it was handed out
+	yearReviewShareTTL = 30 * 24 * time.Hour
+	// yearReviewSharePurpose prefixes signed share token payloads, so a
+	// signature made for anything else with the same secret does not verify
+	yearReviewSharePurpose = "year-review-share"
+)
+
+var (
+	ErrInvalidYear       = errors.New("year must be between 2000 and the current year")
+	ErrYearReviewEmpty   = errors.New("no habits were tracked in this year")
+	ErrInvalidShareToken = errors.New("invalid share token")
+	ErrShareTokenExpired = errors.New("share token expired")
+)
+
+// YearReviewService builds a user's year in review from the year's monthly
+// reports and logs, and signs the links that share it. Reviews are built by
+// report jobs and stored with their narrative; reading one never calls the
+// model. A review of the current year goes stale daily.
+type YearReviewService struct {
+	reportRepo     *repository.ReportRepository
+	yearReviewRepo *repository.YearReviewRepository
+	llmCallRepo    *repository.LLMCallRepository
+	generator      ReportGenerator
+	dayResolver    *DayResolver
+	shareSecret    []byte
+	baseURL        string
+}
+
+// NewYearReviewService creates a new YearReviewService signing share links
+// with shareSecret and pointing them at the API at baseURL
+func NewYearReviewService(
+	reportRepo *repository.ReportRepository,
+	yearReviewRepo *repository.YearReviewRepository,
+	llmCallRepo *repository.LLMCallRepository,
+	generator ReportGenerator,
+	dayResolver *DayResolver,
+	shareSecret string,
+	baseURL string,
+) *YearReviewService {
+	return &YearReviewService{
+		reportRepo:     reportRepo,
+		yearReviewRepo: yearReviewRepo,
+		llmCallRepo:    llmCallRepo,
+		generator:      generator,
+		dayResolver:    dayResolver,
+		shareSecret:    []byte(shareSecret),
+		baseURL:        strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// GetYearReview returns a user's stored review of a year, and whether it is
+// stale and should be rebuilt. Returns repository.ErrYearReviewNotFound when
+// it has not been built yet.
+func (s *YearReviewService) GetYearReview(ctx context.Context, userID uuid.UUID, year int) (*models.YearReview, bool, error) {
+	loc, err := s.dayResolver.UserLocation(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !yearReviewable(year, models.DateOf(time.Now(), loc)) {
+		return nil, false, ErrInvalidYear
+	}
+
+	review, err := s.yearReviewRepo.GetByUserAndYear(ctx, userID, year)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return review, yearReviewStale(review), nil
+}
+
+// Build builds and stores a user's review of a year. Report jobs call it;
+// requests queue one instead, as the model writes the narrative.
+func (s *YearReviewService) Build(ctx context.Context, userID uuid.UUID, year int) (*models.YearReview, error) {
+	loc, err := s.dayResolver.UserLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := models.DateOf(time.Now(), loc)
+	if !yearReviewable(year, today) {
+		return nil, ErrInvalidYear
+	}
+
+	return s.build(ctx, userID, year, today, loc)
+}
+
+// GetSharedYearReview returns the stored year review a share token points
+// to. Shared reviews are never built or rebuilt.
+func (s *YearReviewService) GetSharedYearReview(ctx context.Context, token string) (*models.YearReview, error) {
+	userID, year, err := s.verify(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.yearReviewRepo.GetByUserAndYear(ctx, userID, year)
+}
+
+// ShareURL returns a public link to a user's review of a year, working for
+// yearReviewShareTTL
+func (s *YearReviewService) ShareURL(userID uuid.UUID, year int) string {
+	return s.baseURL + "/api/v1/share/year-review?token=" + url.QueryEscape(s.token(userID, year, time.Now().Add(yearReviewShareTTL)))
+}
+
+// token signs a user ID, year and expiry as
+// base64url(payload).base64url(HMAC-SHA256)
+func (s *YearReviewService) token(userID uuid.UUID, year int, expires time.Time) string {
+	payload := []byte(strings.Join([]string{
+		yearReviewSharePurpose,
+		userID.String(),
+		strconv.Itoa(year),
+		strconv.FormatInt(expires.Unix(), 10),
+	}, ":"))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// build gathers the year's reports and logs, has the narrative written and
+// stores the review
+func (s *YearReviewService) build(ctx context.Context, userID uuid.UUID, year int, today time.Time, loc *time.Location) (*models.YearReview, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	if today.Before(end) {
+		end = today
+	}
+
+	reports, err := s.reportRepo.GetByUserBetween(ctx, userID, models.ReportPeriodMonth, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, err := s.reportRepo.GetHabitActivity(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reports) == 0 && len(activity) == 0 {
+		return nil, ErrYearReviewEmpty
+	}
+
+	content := buildYearReviewContent(year, reports, activity, start, end, loc)
+
+	narrative, err := s.generator.WriteYearNarrative(ctx, userID, content)
+	if err != nil {
+		return nil, err
+	}
+	s.recordCalls(ctx, narrative)
+
+	contentJSON, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &models.YearReview{
+		UserID:          userID,
+		Year:            year,
+		Content:         contentJSON,
+		Narrative:       narrative.Text,
+		NarrativeSource: narrative.Source,
+		NarrativeModel:  narrative.Model,
+	}
+	if err := s.yearReviewRepo.Upsert(ctx, review); err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// recordCalls stores the model calls of a narrative for usage accounting.
+// Failing to record them does not fail the review.
+func (s *YearReviewService) recordCalls(ctx context.Context, narrative *models.YearNarrativeGeneration) {
+	for _, call := range narrative.Calls {
+		if err := s.llmCallRepo.Create(ctx, call); err != nil {
+			log.Printf("failed to record %s call for user %s: %v", call.Provider, call.UserID, err)
+		}
+	}
+}
+
+// yearReviewable reports whether a year can be reviewed on a date
+func yearReviewable(year int, today time.Time) bool {
+	return year >= yearReviewFirstYear && year <= today.Year()
+}
+
+// yearReviewStale reports whether a stored review should be rebuilt. Reviews
+// built after the year ended everywhere are final.
+func yearReviewStale(review *models.YearReview) bool {
+	yearOver := time.Date(review.Year+1, time.January, 2, 0, 0, 0, 0, time.UTC)
+	if review.GeneratedAt.After(yearOver) {
+		return false
+	}
+	return time.Since(review.GeneratedAt) > yearReviewRefreshInterval
+}
+
+// buildYearReviewContent computes the trends of a year from its monthly
+// reports, oldest first, and the activity of its habits from start to end
+func buildYearReviewContent(year int, reports []*models.Report, activity []*models.HabitActivity, start, end time.Time, loc *time.Location) *models.YearReviewContent {
+	content := &models.YearReviewContent{
+		Year:            year,
+		MonthsReported:  len(reports),
+		Months:          []models.YearReviewMonth{},
+		Habits:          []*models.YearReviewHabit{},
+		SkillsLearned:   []string{},
+		HabitsStarted:   []models.YearReviewEvent{},
+		HabitsAbandoned: []models.YearReviewEvent{},
+	}
+
+	habitMonths := make(map[string][]models.YearReviewMonth)
+	seenSkills := make(map[string]bool)
+	var monthsTotal float64
+	for _, report := range reports {
+		month := report.Period().Key()
+
+		var rates map[string]float64
+		if err := json.Unmarshal(report.HabitsCompletedPercentage, &rates); err == nil && len(rates) > 0 {
+			var total float64
+			for habitID, rate := range rates {
+				habitMonths[habitID] = append(habitMonths[habitID], models.YearReviewMonth{Month: month, CompletionRate: rate})
+				total += rate
+			}
+			average := total / float64(len(rates))
+			content.Months = append(content.Months, models.YearReviewMonth{Month: month, CompletionRate: average})
+			monthsTotal += average
+		}
+
+		for _, skill := range report.SkillsLearned {
+			skill = strings.TrimSpace(skill)
+			key := strings.ToLower(skill)
+			if skill == "" || seenSkills[key] {
+				continue
+			}
+			seenSkills[key] = true
+			content.SkillsLearned = append(content.SkillsLearned, skill)
+		}
+	}
+	if len(content.Months) > 0 {
+		content.OverallCompletion = monthsTotal / float64(len(content.Months))
+	}
+	content.TotalSkillsLearned = len(content.SkillsLearned)
+
+	for _, item := range activity {
+		habit := item.Habit
+		reviewed := &models.YearReviewHabit{
+			HabitID:       habit.ID,
+			Title:         habit.Title,
+			Category:      string(habit.Category),
+			Completions:   item.Completions,
+			LongestStreak: item.LongestStreak,
+		}
+
+		// Months are in report order, so the earliest best or worst month wins ties
+		months := habitMonths[habit.ID.String()]
+		for i := range months {
+			if reviewed.BestMonth == nil || months[i].CompletionRate > reviewed.BestMonth.CompletionRate {
+				reviewed.BestMonth = &months[i]
+			}
+			if reviewed.WorstMonth == nil || months[i].CompletionRate < reviewed.WorstMonth.CompletionRate {
+				reviewed.WorstMonth = &months[i]
+			}
+		}
+		if len(months) < 2 {
+			// One month is neither best nor worst, and shows no trend
+			reviewed.WorstMonth = nil
+		} else {
+			reviewed.Trend = habitTrend(months)
+		}
+		content.Habits = append(content.Habits, reviewed)
+
+		if created := models.DateOf(habit.CreatedAt, loc); !created.Before(start) {
+			content.HabitsStarted = append(content.HabitsStarted, models.YearReviewEvent{
+				HabitID: habit.ID,
+				Title:   habit.Title,
+				Date:    created.Format(models.DateLayout),
+			})
+		}
+
+		// Pausing a habit is the last change to an inactive one, so its
+		// updated_at tells when it was paused
+		var abandoned *time.Time
+		if habit.DeletedAt != nil {
+			abandoned = habit.DeletedAt
+		} else if !habit.IsActive {
+			abandoned = &habit.UpdatedAt
+		}
+		if abandoned != nil {
+			if date := models.DateOf(*abandoned, loc); !date.Before(start) && !date.After(end) {
+				content.HabitsAbandoned = append(content.HabitsAbandoned, models.YearReviewEvent{
+					HabitID: habit.ID,
+					Title:   habit.Title,
+					Date:    date.Format(models.DateLayout),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(content.Habits, func(i, j int) bool {
+		return content.Habits[i].Completions > content.Habits[j].Completions
+	})
+
+	return content
+}
+
+// habitTrend compares the average completion rate of the second half of a
+// habit's months with that of the first half
+func habitTrend(months []models.YearReviewMonth) models.HabitTrend {
+	half := len(months) / 2
+	var first, second float64
+	for i := 0; i < half; i++ {
+		first += months[i].CompletionRate
+		second += months[len(months)-half+i].CompletionRate
+	}
+
+	switch change := (second - first) / float64(half); {
+	case change >= yearReviewTrendThreshold:
+		return models.HabitTrendImproving
+	case change <= -yearReviewTrendThreshold:
+		return models.HabitTrendDeclining
+	}
+	return models.HabitTrendSteady
+}
+
+// verify checks a share token's signature and expiry at now and returns the
+// user ID and year it carries
+func (s *YearReviewService) verify(token string, now time.Time) (uuid.UUID, int, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, 0, ErrInvalidShareToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return uuid.Nil, 0, ErrInvalidShareToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(payload)) {
+		return uuid.Nil, 0, ErrInvalidShareToken
+	}
+
+	parts := strings.Split(string(payload), ":")
+	if len(parts) != 4 || parts[0] != yearReviewSharePurpose {
+		return uuid.Nil, 0, ErrInvalidShareToken
+	}
+	userID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return uuid.Nil, 0, ErrInvalidShareToken
+	}
+	year, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return uuid.Nil, 0, ErrInvalidShareToken
+	}
+	expires, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return uuid.Nil, 0, ErrInvalidShareToken
+	}
+	if !now.Before(time.Unix(expires, 0)) {
+		return uuid.Nil, 0, ErrShareTokenExpired
+	}
+
+	return userID, year, nil
+}
+
+// sign computes the HMAC-SHA256 of a share token payload
+func (s *YearReviewService) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.shareSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}